@@ -9,7 +9,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/gocql/gocql/internal/lru"
+	"github.com/freimer/gocql/internal/lru"
 )
 
 const defaultMaxPreparedStmts = 1000
@@ -97,6 +97,7 @@ type ClusterConfig struct {
 	CQLVersion        string            // CQL version (default: 3.0.0)
 	ProtoVersion      int               // version of the native protocol (default: 2)
 	Timeout           time.Duration     // connection timeout (default: 600ms)
+	ConnectTimeout    time.Duration     // initial connection timeout, used during initial dial to server (default: Timeout)
 	Port              int               // port (default: 9042)
 	Keyspace          string            // initial keyspace (optional)
 	NumConns          int               // number of connections per host (default: 2)
@@ -115,6 +116,17 @@ type ClusterConfig struct {
 	// configuration of host selection and connection selection policies.
 	PoolConfig PoolConfig
 
+	// MaxConcurrentConnects caps the number of connections which are being
+	// dialed to a single host at once while filling its pool. This prevents
+	// a large NumConns against a slow host from starting all of its dials
+	// at the same time. Zero or less means no limit. (default: 8)
+	MaxConcurrentConnects int
+
+	// HostStateListener, if set, is notified whenever a host is marked up
+	// or down, so that applications can report on the health of their
+	// connection to the cluster. (default: nil)
+	HostStateListener HostStateListener
+
 	Discovery DiscoveryConfig
 
 	// The maximum amount of time to wait for schema agreement in a cluster after
@@ -140,6 +152,7 @@ func NewCluster(hosts ...string) *ClusterConfig {
 		PageSize:               5000,
 		DefaultTimestamp:       true,
 		MaxWaitSchemaAgreement: 60 * time.Second,
+		MaxConcurrentConnects:  8,
 	}
 	return cfg
 }
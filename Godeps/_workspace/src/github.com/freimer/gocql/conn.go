@@ -19,7 +19,7 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/gocql/gocql/internal/streams"
+	"github.com/freimer/gocql/internal/streams"
 )
 
 var (
@@ -90,13 +90,14 @@ type SslOptions struct {
 }
 
 type ConnConfig struct {
-	ProtoVersion  int
-	CQLVersion    string
-	Timeout       time.Duration
-	Compressor    Compressor
-	Authenticator Authenticator
-	Keepalive     time.Duration
-	tlsConfig     *tls.Config
+	ProtoVersion   int
+	CQLVersion     string
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+	Compressor     Compressor
+	Authenticator  Authenticator
+	Keepalive      time.Duration
+	tlsConfig      *tls.Config
 }
 
 type ConnErrorHandler interface {
@@ -147,8 +148,14 @@ func Connect(addr string, cfg *ConnConfig, errorHandler ConnErrorHandler, sessio
 		conn net.Conn
 	)
 
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = cfg.Timeout
+	}
+
 	dialer := &net.Dialer{
-		Timeout: cfg.Timeout,
+		Timeout:   connectTimeout,
+		KeepAlive: cfg.Keepalive,
 	}
 
 	if cfg.tlsConfig != nil {
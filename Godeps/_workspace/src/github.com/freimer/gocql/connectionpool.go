@@ -89,13 +89,14 @@ func connConfig(session *Session) (*ConnConfig, error) {
 	}
 
 	return &ConnConfig{
-		ProtoVersion:  cfg.ProtoVersion,
-		CQLVersion:    cfg.CQLVersion,
-		Timeout:       cfg.Timeout,
-		Compressor:    cfg.Compressor,
-		Authenticator: cfg.Authenticator,
-		Keepalive:     cfg.SocketKeepalive,
-		tlsConfig:     tlsConfig,
+		ProtoVersion:   cfg.ProtoVersion,
+		CQLVersion:     cfg.CQLVersion,
+		Timeout:        cfg.Timeout,
+		ConnectTimeout: cfg.ConnectTimeout,
+		Compressor:     cfg.Compressor,
+		Authenticator:  cfg.Authenticator,
+		Keepalive:      cfg.SocketKeepalive,
+		tlsConfig:      tlsConfig,
 	}, nil
 }
 
@@ -480,13 +481,25 @@ func (pool *hostConnPool) connectMany(count int) {
 	if count == 0 {
 		return
 	}
+	// limit the number of dials in flight at once, if configured
+	var sem chan struct{}
+	if max := pool.session.cfg.MaxConcurrentConnects; max > 0 && max < count {
+		sem = make(chan struct{}, max)
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(count)
 	for i := 0; i < count; i++ {
+		if sem != nil {
+			sem <- struct{}{}
+		}
 		go func() {
 			defer wg.Done()
 			err := pool.connect()
 			pool.logConnectErr(err)
+			if sem != nil {
+				<-sem
+			}
 		}()
 	}
 	// wait for all connections are done
@@ -182,6 +182,9 @@ func (s *Session) handleNewNode(host net.IP, port int, waitForBinary bool) {
 		hostInfo = existing
 	}
 
+	if s.cfg.HostStateListener != nil {
+		s.cfg.HostStateListener.HostUp(hostInfo)
+	}
 	s.pool.addHost(hostInfo)
 
 	if s.control != nil {
@@ -212,6 +215,11 @@ func (s *Session) handleNodeUp(ip net.IP, port int, waitForBinary bool) {
 		}
 
 		host.setState(NodeUp)
+		// notify before the pool connects, as a failure to connect marks
+		// the host down again
+		if s.cfg.HostStateListener != nil {
+			s.cfg.HostStateListener.HostUp(host)
+		}
 		s.pool.hostUp(host)
 		return
 	}
@@ -224,6 +232,9 @@ func (s *Session) handleNodeDown(ip net.IP, port int) {
 	host := s.ring.getHost(addr)
 	if host != nil {
 		host.setState(NodeDown)
+		if s.cfg.HostStateListener != nil {
+			s.cfg.HostStateListener.HostDown(host)
+		}
 	}
 
 	s.pool.hostDown(addr)
@@ -9,6 +9,7 @@ import (
 	"log"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/hailocab/go-hostpool"
 )
@@ -126,6 +127,7 @@ func (c *cowHostList) remove(addr string) bool {
 type RetryableQuery interface {
 	Attempts() int
 	GetConsistency() Consistency
+	IsIdempotent() bool
 }
 
 // RetryPolicy interface is used by gocql to determine if a query can be attempted
@@ -159,12 +161,124 @@ func (s *SimpleRetryPolicy) Attempt(q RetryableQuery) bool {
 	return q.Attempts() <= s.NumRetries
 }
 
+// RetryType is the action a BackoffRetryPolicy chooses after an error.
+type RetryType uint16
+
+const (
+	Retry   RetryType = 0x00 // retry the query, possibly on another host
+	Rethrow RetryType = 0x01 // return the error without retrying
+)
+
+// BackoffRetryPolicy is a RetryPolicy that is also told the error each
+// attempt failed with, and can ask the session to wait before the next
+// attempt. The session checks for it on the policy of each query.
+type BackoffRetryPolicy interface {
+	RetryPolicy
+
+	// GetRetryType decides whether the error may be retried at all
+	GetRetryType(RetryableQuery, error) RetryType
+
+	// Backoff returns how long to wait before the next attempt
+	Backoff(RetryableQuery) time.Duration
+}
+
+// IdempotentRetryPolicy retries queries up to NumRetries times, waiting
+// between attempts with an exponential backoff from Min up to Max.
+//
+// Statements that are not marked idempotent with Query.Idempotent are
+// only retried when the error shows that they were not applied, such as
+// when no connection was available or the coordinator was unavailable,
+// overloaded or bootstrapping. Otherwise the error is returned, since
+// running a non-idempotent write twice is not safe.
+//
+//     cluster.RetryPolicy = &gocql.IdempotentRetryPolicy{
+//         NumRetries: 3,
+//         Min:        100 * time.Millisecond,
+//         Max:        time.Second,
+//     }
+//
+type IdempotentRetryPolicy struct {
+	NumRetries int
+	Min, Max   time.Duration
+}
+
+// Attempt tells gocql to attempt the query again if fewer than NumRetries
+// retries have been made.
+func (e *IdempotentRetryPolicy) Attempt(q RetryableQuery) bool {
+	return q.Attempts() <= e.NumRetries
+}
+
+// GetRetryType rethrows errors for non-idempotent queries unless the
+// query was not applied.
+func (e *IdempotentRetryPolicy) GetRetryType(q RetryableQuery, err error) RetryType {
+	if q.IsIdempotent() || notApplied(err) {
+		return Retry
+	}
+	return Rethrow
+}
+
+// Backoff doubles the wait from Min for each attempt made, up to Max.
+func (e *IdempotentRetryPolicy) Backoff(q RetryableQuery) time.Duration {
+	backoff := e.Min
+	for i := 1; i < q.Attempts() && backoff < e.Max; i++ {
+		backoff *= 2
+	}
+	if e.Max > 0 && backoff > e.Max {
+		backoff = e.Max
+	}
+	return backoff
+}
+
+// notApplied returns whether the error shows that the statement was not
+// applied by any node.
+func notApplied(err error) bool {
+	if err == ErrNoConnections {
+		return true
+	}
+	switch e := err.(type) {
+	case *RequestErrUnavailable:
+		return true
+	case RequestError:
+		return e.Code() == errOverloaded || e.Code() == errBootstrapping
+	}
+	return false
+}
+
+// shouldRetry consults the retry policy after an attempt at the query
+// failed with err, waiting for the policy's backoff if it is to be retried.
+func shouldRetry(rt RetryPolicy, q RetryableQuery, err error) bool {
+	if rt == nil {
+		return false
+	}
+
+	brt, ok := rt.(BackoffRetryPolicy)
+	if ok && brt.GetRetryType(q, err) == Rethrow {
+		return false
+	}
+	if !rt.Attempt(q) {
+		return false
+	}
+	if ok {
+		time.Sleep(brt.Backoff(q))
+	}
+	return true
+}
+
 type HostStateNotifier interface {
 	AddHost(host *HostInfo)
 	RemoveHost(addr string)
 	// TODO(zariel): add host up/down
 }
 
+// HostStateListener is notified when the session marks a host up or down,
+// either because the cluster reported a status change or because the
+// connection pool could not connect to it. Calls may be made from any
+// goroutine and must not block.
+type HostStateListener interface {
+	HostUp(host *HostInfo)
+	HostDown(host *HostInfo)
+}
+
 // HostSelectionPolicy is an interface for selecting
 // the most appropriate host to execute a given query.
 type HostSelectionPolicy interface {
@@ -0,0 +1,117 @@
+// Copyright (c) 2012 The gocql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocql
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdempotentRetryPolicy_GetRetryType(t *testing.T) {
+	rt := &IdempotentRetryPolicy{NumRetries: 2}
+
+	writeTimeout := &RequestErrWriteTimeout{errorFrame: errorFrame{code: errWriteTimeout}}
+	cases := []struct {
+		name       string
+		err        error
+		idempotent bool
+		expected   RetryType
+	}{
+		{"idempotent write timeout", writeTimeout, true, Retry},
+		{"write timeout", writeTimeout, false, Rethrow},
+		{"read timeout", &RequestErrReadTimeout{errorFrame: errorFrame{code: errReadTimeout}}, false, Rethrow},
+		{"other error", errors.New("connection reset"), false, Rethrow},
+		{"idempotent other error", errors.New("connection reset"), true, Retry},
+
+		// These errors show that the statement was not applied, so even a
+		// non-idempotent statement is retried
+		{"no connections", ErrNoConnections, false, Retry},
+		{"unavailable", &RequestErrUnavailable{errorFrame: errorFrame{code: errUnavailable}}, false, Retry},
+		{"overloaded", errorFrame{code: errOverloaded}, false, Retry},
+		{"bootstrapping", errorFrame{code: errBootstrapping}, false, Retry},
+		{"server error", errorFrame{code: errServer}, false, Rethrow},
+	}
+
+	for _, c := range cases {
+		q := &Query{idempotent: c.idempotent}
+		if got := rt.GetRetryType(q, c.err); got != c.expected {
+			t.Errorf("%s: expected retry type %v, got %v", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestIdempotentRetryPolicy_Backoff(t *testing.T) {
+	rt := &IdempotentRetryPolicy{
+		NumRetries: 10,
+		Min:        100 * time.Millisecond,
+		Max:        time.Second,
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for attempts, backoff := range expected {
+		q := &Query{attempts: attempts}
+		if got := rt.Backoff(q); got != backoff {
+			t.Errorf("after %d attempts: expected backoff %v, got %v", attempts, backoff, got)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	rt := &IdempotentRetryPolicy{NumRetries: 2}
+	writeTimeout := &RequestErrWriteTimeout{errorFrame: errorFrame{code: errWriteTimeout}}
+
+	// An idempotent query is retried until it runs out of attempts
+	q := &Query{idempotent: true}
+	for q.attempts = 1; q.attempts <= rt.NumRetries; q.attempts++ {
+		if !shouldRetry(rt, q, writeTimeout) {
+			t.Fatalf("idempotent query not retried after %d attempts", q.attempts)
+		}
+	}
+	if shouldRetry(rt, q, writeTimeout) {
+		t.Fatalf("idempotent query retried after %d attempts", q.attempts)
+	}
+
+	// A non-idempotent query that may have been applied is not retried
+	q = &Query{attempts: 1}
+	if shouldRetry(rt, q, writeTimeout) {
+		t.Fatal("non-idempotent query retried after a write timeout")
+	}
+
+	// Unless the error shows that it was not applied
+	if !shouldRetry(rt, q, ErrNoConnections) {
+		t.Fatal("non-idempotent query not retried without connections")
+	}
+
+	// Policies that do not implement BackoffRetryPolicy retry any error
+	if !shouldRetry(&SimpleRetryPolicy{NumRetries: 2}, q, writeTimeout) {
+		t.Fatal("simple retry policy did not retry")
+	}
+	if shouldRetry(nil, q, writeTimeout) {
+		t.Fatal("query retried without a retry policy")
+	}
+}
+
+func TestShouldRetry_batch(t *testing.T) {
+	rt := &IdempotentRetryPolicy{NumRetries: 2}
+	writeTimeout := &RequestErrWriteTimeout{errorFrame: errorFrame{code: errWriteTimeout}}
+
+	b := &Batch{attempts: 1}
+	if shouldRetry(rt, b, writeTimeout) {
+		t.Fatal("non-idempotent batch retried after a write timeout")
+	}
+	b.Idempotent(true)
+	if !shouldRetry(rt, b, writeTimeout) {
+		t.Fatal("idempotent batch not retried")
+	}
+}
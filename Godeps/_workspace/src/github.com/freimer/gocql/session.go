@@ -17,7 +17,7 @@ import (
 	"time"
 	"unicode"
 
-	"github.com/gocql/gocql/internal/lru"
+	"github.com/freimer/gocql/internal/lru"
 )
 
 // Session is the interface used by users to interact with the database.
@@ -225,6 +225,12 @@ func (s *Session) Bind(stmt string, b func(q *QueryInfo) ([]interface{}, error))
 	return qry
 }
 
+// Hosts returns the hosts of the ring known to the session, along with
+// their last known state.
+func (s *Session) Hosts() []*HostInfo {
+	return s.ring.allHosts()
+}
+
 // Close closes all connections. The session is unusable after this
 // operation.
 func (s *Session) Close() {
@@ -274,7 +280,7 @@ func (s *Session) executeQuery(qry *Query) *Iter {
 		qry.attempts++
 		//Assign the error unavailable to the iterator
 		if conn == nil {
-			if qry.rt == nil || !qry.rt.Attempt(qry) {
+			if !shouldRetry(qry.rt, qry, ErrNoConnections) {
 				iter = &Iter{err: ErrNoConnections}
 				break
 			}
@@ -295,7 +301,7 @@ func (s *Session) executeQuery(qry *Query) *Iter {
 		// Mark host as ok
 		host.Mark(nil)
 
-		if qry.rt == nil || !qry.rt.Attempt(qry) {
+		if !shouldRetry(qry.rt, qry, iter.err) {
 			break
 		}
 	}
@@ -480,7 +486,7 @@ func (s *Session) executeBatch(batch *Batch) (*Iter, error) {
 		// Mark host as OK
 		host.Mark(nil)
 
-		if batch.rt == nil || !batch.rt.Attempt(batch) {
+		if !shouldRetry(batch.rt, batch, err) {
 			break
 		}
 	}
@@ -560,6 +566,7 @@ type Query struct {
 	totalLatency     int64
 	serialCons       SerialConsistency
 	defaultTimestamp bool
+	idempotent       bool
 
 	disableAutoPage bool
 }
@@ -596,6 +603,19 @@ func (q *Query) GetConsistency() Consistency {
 	return q.cons
 }
 
+// Idempotent marks the query as safe to execute more than once, which
+// allows a retry policy to retry it after errors where it may already
+// have been applied.
+func (q *Query) Idempotent(value bool) *Query {
+	q.idempotent = value
+	return q
+}
+
+// IsIdempotent returns whether the query is marked as idempotent.
+func (q *Query) IsIdempotent() bool {
+	return q.idempotent
+}
+
 // Trace enables tracing of this query. Look at the documentation of the
 // Tracer interface to learn more about tracing.
 func (q *Query) Trace(trace Tracer) *Query {
@@ -982,6 +1002,7 @@ type Batch struct {
 	totalLatency     int64
 	serialCons       SerialConsistency
 	defaultTimestamp bool
+	idempotent       bool
 }
 
 // NewBatch creates a new batch operation without defaults from the cluster
@@ -1017,6 +1038,17 @@ func (b *Batch) GetConsistency() Consistency {
 	return b.Cons
 }
 
+// Idempotent marks the batch as safe to execute more than once.
+func (b *Batch) Idempotent(value bool) *Batch {
+	b.idempotent = value
+	return b
+}
+
+// IsIdempotent returns whether the batch is marked as idempotent.
+func (b *Batch) IsIdempotent() bool {
+	return b.idempotent
+}
+
 // Query adds the query to the batch operation
 func (b *Batch) Query(stmt string, args ...interface{}) {
 	b.Entries = append(b.Entries, BatchEntry{Stmt: stmt, Args: args})
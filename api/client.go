@@ -17,6 +17,8 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
 )
 
 const EnvVaultAddress = "VAULT_ADDR"
@@ -25,6 +27,7 @@ const EnvVaultCAPath = "VAULT_CAPATH"
 const EnvVaultClientCert = "VAULT_CLIENT_CERT"
 const EnvVaultClientKey = "VAULT_CLIENT_KEY"
 const EnvVaultInsecure = "VAULT_SKIP_VERIFY"
+const EnvVaultReplayProtection = "VAULT_REPLAY_PROTECTION"
 
 var (
 	errRedirect = errors.New("redirect")
@@ -42,6 +45,11 @@ type Config struct {
 	// same values as http.DefaultClient. This is used to control redirect behavior.
 	HttpClient *http.Client
 
+	// ReplayProtection, if true, adds a unique nonce and the current time
+	// to each request, as required by servers with replay protection
+	// enabled.
+	ReplayProtection bool
+
 	redirectSetup sync.Once
 }
 
@@ -81,6 +89,8 @@ func (c *Config) ReadEnvironment() error {
 	var envClientKey string
 	var envInsecure bool
 	var foundInsecure bool
+	var envReplayProtection bool
+	var foundReplayProtection bool
 
 	var newCertPool *x509.CertPool
 	var clientCert tls.Certificate
@@ -109,6 +119,14 @@ func (c *Config) ReadEnvironment() error {
 		}
 		foundInsecure = true
 	}
+	if v := os.Getenv(EnvVaultReplayProtection); v != "" {
+		var err error
+		envReplayProtection, err = strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("Could not parse VAULT_REPLAY_PROTECTION")
+		}
+		foundReplayProtection = true
+	}
 	// If we need custom TLS configuration, then set it
 	if envCACert != "" || envCAPath != "" || envClientCert != "" || envClientKey != "" || envInsecure {
 		var err error
@@ -135,6 +153,9 @@ func (c *Config) ReadEnvironment() error {
 	if envAddress != "" {
 		c.Address = envAddress
 	}
+	if foundReplayProtection {
+		c.ReplayProtection = envReplayProtection
+	}
 
 	clientTLSConfig := c.HttpClient.Transport.(*http.Transport).TLSClientConfig
 	if foundInsecure {
@@ -150,12 +171,23 @@ func (c *Config) ReadEnvironment() error {
 	return nil
 }
 
+// RequestCallback is called with each request before it is sent, and
+// may modify it, such as to add headers.
+type RequestCallback func(*Request)
+
+// ResponseCallback is called with each response once it is received,
+// including responses with an error status. It must not consume the body.
+type ResponseCallback func(*Response)
+
 // Client is the client to the Vault API. Create a client with
 // NewClient.
 type Client struct {
 	addr   *url.URL
 	config *Config
 	token  string
+
+	requestCallbacks  []RequestCallback
+	responseCallbacks []ResponseCallback
 }
 
 // NewClient returns a new client for the given configuration.
@@ -215,6 +247,28 @@ func (c *Client) ClearToken() {
 	c.token = ""
 }
 
+// WithRequestCallbacks returns a copy of the client that calls the given
+// callbacks, after those of this client, with each request it sends. The
+// copy can be kept to apply the callbacks to every request, or used for a
+// single call, such as client.WithRequestCallbacks(cb).Logical().Read(path).
+func (c *Client) WithRequestCallbacks(callbacks ...RequestCallback) *Client {
+	c2 := *c
+	c2.requestCallbacks = make([]RequestCallback, 0, len(c.requestCallbacks)+len(callbacks))
+	c2.requestCallbacks = append(c2.requestCallbacks, c.requestCallbacks...)
+	c2.requestCallbacks = append(c2.requestCallbacks, callbacks...)
+	return &c2
+}
+
+// WithResponseCallbacks returns a copy of the client that calls the given
+// callbacks, after those of this client, with each response it receives.
+func (c *Client) WithResponseCallbacks(callbacks ...ResponseCallback) *Client {
+	c2 := *c
+	c2.responseCallbacks = make([]ResponseCallback, 0, len(c.responseCallbacks)+len(callbacks))
+	c2.responseCallbacks = append(c2.responseCallbacks, c.responseCallbacks...)
+	c2.responseCallbacks = append(c2.responseCallbacks, callbacks...)
+	return &c2
+}
+
 // NewRequest creates a new raw request object to query the Vault server
 // configured for this client. This is an advanced method and generally
 // doesn't need to be called externally.
@@ -226,8 +280,9 @@ func (c *Client) NewRequest(method, path string) *Request {
 			Host:   c.addr.Host,
 			Path:   path,
 		},
-		ClientToken: c.token,
-		Params:      make(map[string][]string),
+		ClientToken:      c.token,
+		Params:           make(map[string][]string),
+		ReplayProtection: c.config.ReplayProtection,
 	}
 
 	return req
@@ -237,6 +292,28 @@ func (c *Client) NewRequest(method, path string) *Request {
 // a Vault server not configured with this client. This is an advanced operation
 // that generally won't need to be called externally.
 func (c *Client) RawRequest(r *Request) (*Response, error) {
+	return c.RawRequestWithContext(context.Background(), r)
+}
+
+// RawRequestWithContext performs the raw request like RawRequest, but
+// cancels it if the context is done before the response body has been
+// closed. The body is not buffered, so large responses can be streamed
+// by reading it directly, and the caller must close it.
+func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Response, error) {
+	for _, cb := range c.requestCallbacks {
+		cb(r)
+	}
+
+	result, err := c.rawRequestWithContext(ctx, r)
+	if result != nil {
+		for _, cb := range c.responseCallbacks {
+			cb(result)
+		}
+	}
+	return result, err
+}
+
+func (c *Client) rawRequestWithContext(ctx context.Context, r *Request) (*Response, error) {
 	redirectCount := 0
 START:
 	req, err := r.ToHTTP()
@@ -245,7 +322,7 @@ START:
 	}
 
 	var result *Response
-	resp, err := c.config.HttpClient.Do(req)
+	resp, err := ctxhttp.Do(ctx, c.config.HttpClient, req)
 	if resp != nil {
 		result = &Response{Response: resp}
 	}
@@ -291,7 +368,8 @@ START:
 			return result, err
 		}
 
-		// Retry the request
+		// Retry the request, releasing the redirect response first
+		resp.Body.Close()
 		redirectCount++
 		goto START
 	}
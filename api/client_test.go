@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -134,3 +135,60 @@ func TestClientEnvSettings(t *testing.T) {
 		t.Fatalf("bad: %s", tlsConfig.InsecureSkipVerify)
 	}
 }
+
+func TestClientCallbacks(t *testing.T) {
+	var header string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header.Get("X-Test")
+		w.WriteHeader(204)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var order []string
+	var status int
+	client2 := client.WithRequestCallbacks(func(r *Request) {
+		order = append(order, "first")
+		if r.Headers == nil {
+			r.Headers = make(http.Header)
+		}
+		r.Headers.Set("X-Test", "foo")
+	})
+	client3 := client2.WithRequestCallbacks(func(r *Request) {
+		order = append(order, "second")
+	}).WithResponseCallbacks(func(r *Response) {
+		status = r.StatusCode
+	})
+
+	resp, err := client3.RawRequest(client3.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+
+	if header != "foo" {
+		t.Fatalf("bad: %q", header)
+	}
+	if !reflect.DeepEqual(order, []string{"first", "second"}) {
+		t.Fatalf("bad: %v", order)
+	}
+	if status != 204 {
+		t.Fatalf("bad: %d", status)
+	}
+
+	// The callbacks do not apply to the client they were added to
+	order = nil
+	resp, err = client.RawRequest(client.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+	if header != "" || len(order) != 0 {
+		t.Fatalf("bad: %q %v", header, order)
+	}
+}
@@ -1,5 +1,9 @@
 package api
 
+import (
+	"golang.org/x/net/context"
+)
+
 // Logical is used to perform logical backend operations on Vault.
 type Logical struct {
 	c *Client
@@ -26,6 +30,33 @@ func (c *Logical) Read(path string) (*Secret, error) {
 	return ParseSecret(resp.Body)
 }
 
+// ReadRaw reads the given path and returns the response without parsing
+// it, so that large or non-JSON responses can be streamed from the body.
+// The caller must close the body. If nothing exists at the path, a nil
+// response is returned.
+func (c *Logical) ReadRaw(path string) (*Response, error) {
+	return c.ReadRawWithContext(context.Background(), path)
+}
+
+// ReadRawWithContext is like ReadRaw, but cancels the request if the
+// context is done before the body has been closed.
+func (c *Logical) ReadRawWithContext(ctx context.Context, path string) (*Response, error) {
+	r := c.c.NewRequest("GET", "/v1/"+path)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil && resp.StatusCode == 404 {
+		resp.Body.Close()
+		return nil, nil
+	}
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func (c *Logical) Write(path string, data map[string]interface{}) (*Secret, error) {
 	r := c.c.NewRequest("PUT", "/v1/"+path)
 	if err := r.SetJSONBody(data); err != nil {
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestLogical_ReadRaw(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/secret/foo" {
+			w.WriteHeader(404)
+			return
+		}
+		w.Write([]byte("raw body"))
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := client.Logical().ReadRaw("secret/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(body) != "raw body" {
+		t.Fatalf("bad: %q", body)
+	}
+
+	resp, err = client.Logical().ReadRaw("secret/bar")
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+}
+
+func TestLogical_ReadRawWithContext(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		// Send part of the body and then stall
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-done
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := client.Logical().ReadRawWithContext(ctx, "secret/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+
+	buf := make([]byte, len("partial"))
+	if _, err := resp.Body.Read(buf); err != nil || string(buf) != "partial" {
+		t.Fatalf("bad: %q %v", buf, err)
+	}
+
+	// Canceling the context unblocks the reader
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := ioutil.ReadAll(resp.Body)
+		errCh <- err
+	}()
+	cancel()
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("read was not canceled")
+	}
+}
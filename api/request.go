@@ -2,10 +2,14 @@ package api
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // Request is a raw request configuration structure used to initiate
@@ -15,9 +19,13 @@ type Request struct {
 	URL         *url.URL
 	Params      url.Values
 	ClientToken string
+	Headers     http.Header
 	Obj         interface{}
 	Body        io.Reader
 	BodySize    int64
+
+	// ReplayProtection adds a nonce and timestamp to the request
+	ReplayProtection bool
 }
 
 // SetJSONBody is used to set a request body that is a JSON-encoded value.
@@ -34,11 +42,16 @@ func (r *Request) SetJSONBody(val interface{}) error {
 	return nil
 }
 
-// ResetJSONBody is used to reset the body for a redirect
+// ResetJSONBody is used to reset the body for a redirect. Bodies that
+// are not JSON are rewound if they can be.
 func (r *Request) ResetJSONBody() error {
 	if r.Body == nil {
 		return nil
 	}
+	if seeker, ok := r.Body.(io.Seeker); ok && r.Obj == nil {
+		_, err := seeker.Seek(0, 0)
+		return err
+	}
 	return r.SetJSONBody(r.Obj)
 }
 
@@ -58,9 +71,24 @@ func (r *Request) ToHTTP() (*http.Request, error) {
 	req.URL.Host = r.URL.Host
 	req.Host = r.URL.Host
 
+	for k, v := range r.Headers {
+		req.Header[k] = v
+	}
+
 	if len(r.ClientToken) != 0 {
 		req.Header.Set("X-Vault-Token", r.ClientToken)
 	}
 
+	// A fresh nonce is generated each time so that the request can be
+	// retried against another server after a redirect.
+	if r.ReplayProtection {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Vault-Request-Nonce", hex.EncodeToString(nonce))
+		req.Header.Set("X-Vault-Request-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	}
+
 	return req, nil
 }
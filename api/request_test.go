@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"io"
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -61,3 +62,28 @@ func TestRequestResetJSONBody(t *testing.T) {
 		t.Fatalf("bad: %d", len(actual))
 	}
 }
+
+func TestRequestToHTTP_replayProtection(t *testing.T) {
+	r := &Request{
+		Method:           "PUT",
+		URL:              &url.URL{Scheme: "http", Host: "127.0.0.1:8200", Path: "/v1/sys/unseal"},
+		ReplayProtection: true,
+	}
+
+	req1, err := r.ToHTTP()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req2, err := r.ToHTTP()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	nonce := req1.Header.Get("X-Vault-Request-Nonce")
+	if nonce == "" || nonce == req2.Header.Get("X-Vault-Request-Nonce") {
+		t.Fatalf("bad: %#v %#v", req1.Header, req2.Header)
+	}
+	if req1.Header.Get("X-Vault-Request-Timestamp") == "" {
+		t.Fatalf("bad: %#v", req1.Header)
+	}
+}
@@ -22,7 +22,8 @@ func (r *Response) DecodeJSON(out interface{}) error {
 }
 
 // Error returns an error response if there is one. If there is an error,
-// this will fully consume the response body, but will not close it. The
+// this will fully consume the response body and replace it with a copy,
+// so that it may still be decoded for any details beyond the errors. The
 // body must still be closed manually.
 func (r *Response) Error() error {
 	// 200 to 399 are okay status codes
@@ -36,6 +37,10 @@ func (r *Response) Error() error {
 	if _, err := io.Copy(&bodyBuf, r.Body); err != nil {
 		return err
 	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{bytes.NewReader(bodyBuf.Bytes()), r.Body}
 
 	// Decode the error response if we can. Note that we wrap the bodyBuf
 	// in a bytes.Reader here so that the JSON decoder doesn't move the
@@ -0,0 +1,48 @@
+package api
+
+// Copy copies the secret at source to destination, or every secret under
+// source to destination if both end in "/". The data is copied by the
+// server and is not returned to the client. The paths of the secrets
+// copied, relative to source, are returned, including on error those
+// copied before it occurred.
+func (c *Sys) Copy(source, destination string) ([]string, error) {
+	return c.copy(source, destination, false)
+}
+
+// Move is like Copy, but deletes each source secret once it has been
+// copied.
+func (c *Sys) Move(source, destination string) ([]string, error) {
+	return c.copy(source, destination, true)
+}
+
+func (c *Sys) copy(source, destination string, move bool) ([]string, error) {
+	body := map[string]interface{}{
+		"source":      source,
+		"destination": destination,
+		"move":        move,
+	}
+
+	r := c.c.NewRequest("PUT", "/v1/sys/copy")
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequest(r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+
+	var result struct {
+		Copied []string `json:"copied"`
+	}
+	if err != nil {
+		// The server lists what was copied before the error
+		if resp != nil {
+			resp.DecodeJSON(&result)
+		}
+		return result.Copied, err
+	}
+
+	err = resp.DecodeJSON(&result)
+	return result.Copied, err
+}
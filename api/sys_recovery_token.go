@@ -0,0 +1,83 @@
+package api
+
+func (c *Sys) RecoveryTokenStatus() (*RecoveryTokenStatusResponse, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/generate-recovery-token/init")
+	resp, err := c.c.RawRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result RecoveryTokenStatusResponse
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+func (c *Sys) RecoveryTokenInit(pgpKey string) (*RecoveryTokenStatusResponse, error) {
+	body := map[string]interface{}{
+		"pgp_key": pgpKey,
+	}
+
+	r := c.c.NewRequest("PUT", "/v1/sys/generate-recovery-token/init")
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result RecoveryTokenStatusResponse
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+func (c *Sys) RecoveryTokenCancel() error {
+	r := c.c.NewRequest("DELETE", "/v1/sys/generate-recovery-token/init")
+	resp, err := c.c.RawRequest(r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) RecoveryTokenUpdate(shard, nonce string) (*RecoveryTokenUpdateResponse, error) {
+	body := map[string]interface{}{
+		"key":   shard,
+		"nonce": nonce,
+	}
+
+	r := c.c.NewRequest("PUT", "/v1/sys/generate-recovery-token/update")
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result RecoveryTokenUpdateResponse
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+type RecoveryTokenStatusResponse struct {
+	Nonce          string
+	Started        bool
+	Progress       int
+	Required       int
+	PGPFingerprint string `json:"pgp_fingerprint"`
+}
+
+type RecoveryTokenUpdateResponse struct {
+	Nonce          string
+	Complete       bool
+	Progress       int
+	EncodedToken   string `json:"encoded_token"`
+	PGPFingerprint string `json:"pgp_fingerprint"`
+	TTL            int
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// SnapshotSave writes a snapshot of the storage of the Vault to w. The
+// snapshot is gzip compressed and its entries remain encrypted by the
+// barrier.
+func (c *Sys) SnapshotSave(w io.Writer) error {
+	r := c.c.NewRequest("GET", "/v1/sys/storage/snapshot")
+	resp, err := c.c.RawRequest(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// SnapshotRestore replaces the storage of the Vault with the snapshot
+// read from snapshot. The Vault is sealed afterwards and must be unsealed
+// with the keys in use when the snapshot was taken.
+func (c *Sys) SnapshotRestore(snapshot io.Reader) error {
+	// Buffer the snapshot so that it can be sent again on a redirect
+	raw, err := ioutil.ReadAll(snapshot)
+	if err != nil {
+		return err
+	}
+
+	r := c.c.NewRequest("PUT", "/v1/sys/storage/snapshot")
+	r.Body = bytes.NewReader(raw)
+	r.BodySize = int64(len(raw))
+	resp, err := c.c.RawRequest(r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
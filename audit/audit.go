@@ -38,3 +38,17 @@ type BackendConfig struct {
 
 // Factory is the factory function to create an audit backend.
 type Factory func(*BackendConfig) (Backend, error)
+
+// EntryBackend is implemented by backends that can encode an entry
+// separately from writing it out, which allows entries to be spooled.
+type EntryBackend interface {
+	Backend
+
+	// EncodeRequest and EncodeResponse return the entry that LogRequest
+	// and LogResponse would write, with any sensitive information hashed
+	EncodeRequest(*logical.Auth, *logical.Request, error) ([]byte, error)
+	EncodeResponse(*logical.Auth, *logical.Request, *logical.Response, error) ([]byte, error)
+
+	// WriteEntry writes out an encoded entry
+	WriteEntry([]byte) error
+}
@@ -109,6 +109,7 @@ func (f *FormatJSON) FormatResponse(
 			Secret:   respSecret,
 			Data:     resp.Data,
 			Redirect: resp.Redirect,
+			Warnings: resp.Warnings(),
 		},
 	})
 }
@@ -145,6 +146,7 @@ type JSONResponse struct {
 	Secret   *JSONSecret            `json:"secret,emitempty"`
 	Data     map[string]interface{} `json:"data"`
 	Redirect string                 `json:"redirect"`
+	Warnings []string               `json:"warnings,omitempty"`
 }
 
 type JSONAuth struct {
@@ -0,0 +1,331 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// spoolMinBackoff and spoolMaxBackoff bound the wait between attempts
+	// to deliver an entry
+	spoolMinBackoff = 1 * time.Second
+	spoolMaxBackoff = 1 * time.Minute
+
+	// spoolReadSize is the size of the chunks the spool is read in
+	spoolReadSize = 64 * 1024
+)
+
+// Spool is a Backend that appends entries to a local file, synced to disk
+// before they are acknowledged, and delivers them to another backend in the
+// background. Entries are delivered at least once, in order, and are
+// retried until the backend accepts them, so an unavailable backend neither
+// blocks requests nor loses entries.
+//
+// The offset of the first undelivered entry is kept next to the spool, with
+// an ".offset" suffix, so that delivery resumes where it left off after a
+// restart. The spool is truncated whenever every entry has been delivered.
+type Spool struct {
+	backend EntryBackend
+	path    string
+	logger  *log.Logger
+
+	l      sync.Mutex
+	f      *os.File
+	size   int64
+	offset int64
+
+	notifyCh chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// NewSpool opens or creates the spool at the given path and starts
+// delivering its entries to the backend
+func NewSpool(path string, backend EntryBackend, logger *log.Logger) (*Spool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Spool{
+		backend:  backend,
+		path:     path,
+		logger:   logger,
+		f:        f,
+		notifyCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to recover audit spool %s: %v", path, err)
+	}
+
+	go s.run()
+	return s, nil
+}
+
+func (s *Spool) GetHash(data string) string {
+	return s.backend.GetHash(data)
+}
+
+func (s *Spool) LogRequest(auth *logical.Auth, req *logical.Request, outerErr error) error {
+	entry, err := s.backend.EncodeRequest(auth, req, outerErr)
+	if err != nil {
+		return err
+	}
+	return s.append(entry)
+}
+
+func (s *Spool) LogResponse(auth *logical.Auth, req *logical.Request,
+	resp *logical.Response, err error) error {
+	entry, err := s.backend.EncodeResponse(auth, req, resp, err)
+	if err != nil {
+		return err
+	}
+	return s.append(entry)
+}
+
+// Pending returns the number of bytes that have not been delivered yet
+func (s *Spool) Pending() int64 {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.size - s.offset
+}
+
+// Close stops delivery and closes the spool. Undelivered entries remain
+// in the spool and are delivered once it is opened again.
+func (s *Spool) Close() error {
+	close(s.stopCh)
+	<-s.doneCh
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.f.Close()
+}
+
+// append adds an entry to the spool, returning once it is on disk
+func (s *Spool) append(entry []byte) error {
+	if len(entry) == 0 || entry[len(entry)-1] != '\n' {
+		entry = append(entry, '\n')
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	if _, err := s.f.Write(entry); err != nil {
+		// Drop a partial write so that it is not taken as an entry
+		s.f.Truncate(s.size)
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		s.f.Truncate(s.size)
+		return err
+	}
+	s.size += int64(len(entry))
+
+	select {
+	case s.notifyCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run delivers entries until the spool is closed
+func (s *Spool) run() {
+	defer close(s.doneCh)
+
+	backoff := spoolMinBackoff
+	for {
+		entry, err := s.next()
+		if err != nil {
+			s.logger.Printf("[ERR] audit: failed to read spool %s: %v", s.path, err)
+		} else if entry == nil {
+			// Everything is delivered, so wait for more
+			s.compact()
+			select {
+			case <-s.notifyCh:
+				continue
+			case <-s.stopCh:
+				return
+			}
+		} else if err = s.backend.WriteEntry(entry); err != nil {
+			s.logger.Printf("[ERR] audit: failed to deliver spooled entry, retrying in %s: %v",
+				backoff, err)
+		} else {
+			s.advance(int64(len(entry)))
+			backoff = spoolMinBackoff
+			continue
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stopCh:
+			return
+		}
+		backoff *= 2
+		if backoff > spoolMaxBackoff {
+			backoff = spoolMaxBackoff
+		}
+	}
+}
+
+// next returns the first undelivered entry, or nil if there is none
+func (s *Spool) next() ([]byte, error) {
+	s.l.Lock()
+	offset, size := s.offset, s.size
+	s.l.Unlock()
+
+	var entry []byte
+	chunk := make([]byte, spoolReadSize)
+	for pos := offset; pos < size; {
+		n := int64(len(chunk))
+		if size-pos < n {
+			n = size - pos
+		}
+		read, err := s.f.ReadAt(chunk[:n], pos)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if read == 0 {
+			return nil, fmt.Errorf("spool is shorter than expected")
+		}
+		if i := bytes.IndexByte(chunk[:read], '\n'); i >= 0 {
+			return append(entry, chunk[:i+1]...), nil
+		}
+		entry = append(entry, chunk[:read]...)
+		pos += int64(read)
+	}
+	if len(entry) > 0 {
+		return nil, fmt.Errorf("spool ends with a partial entry")
+	}
+	return nil, nil
+}
+
+// advance marks the given number of bytes as delivered
+func (s *Spool) advance(n int64) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.offset += n
+
+	// A lost offset only causes entries to be delivered again, so it is
+	// not synced to disk
+	if err := s.writeOffset(); err != nil {
+		s.logger.Printf("[WARN] audit: failed to record spool offset for %s: %v", s.path, err)
+	}
+}
+
+// compact truncates the spool once every entry has been delivered
+func (s *Spool) compact() {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if s.size == 0 || s.offset < s.size {
+		return
+	}
+	if err := s.f.Truncate(0); err != nil {
+		s.logger.Printf("[WARN] audit: failed to truncate spool %s: %v", s.path, err)
+		return
+	}
+	s.size = 0
+	s.offset = 0
+	if err := s.writeOffset(); err != nil {
+		s.logger.Printf("[WARN] audit: failed to record spool offset for %s: %v", s.path, err)
+	}
+}
+
+// writeOffset records the offset. It is written to a temporary file that
+// is then renamed over the offset file, so that a crash never leaves a
+// partially written offset behind.
+func (s *Spool) writeOffset() error {
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".offset")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+
+	if _, err := f.WriteString(strconv.FormatInt(s.offset, 10)); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, s.path+".offset"); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// recover loads the size and offset of an existing spool, dropping a
+// partial entry left at its end by a crash
+func (s *Spool) recover() error {
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	s.size = info.Size()
+
+	// Find the end of the last complete entry
+	end := s.size
+	chunk := make([]byte, spoolReadSize)
+	for end > 0 {
+		start := end - int64(len(chunk))
+		if start < 0 {
+			start = 0
+		}
+		read, err := s.f.ReadAt(chunk[:end-start], start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if i := bytes.LastIndexByte(chunk[:read], '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end < s.size {
+		s.logger.Printf("[WARN] audit: dropping %d bytes of a partial entry at the end of spool %s",
+			s.size-end, s.path)
+		if err := s.f.Truncate(end); err != nil {
+			return err
+		}
+		s.size = end
+	}
+
+	raw, err := ioutil.ReadFile(s.path + ".offset")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(raw) > 0 {
+		offset, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid offset: %v", err)
+		}
+		s.offset = offset
+	}
+
+	// An offset past the end can only come from a spool that was truncated
+	// without the offset being recorded, so everything was delivered
+	if s.offset > s.size {
+		s.offset = s.size
+	}
+	return nil
+}
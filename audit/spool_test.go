@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// testEntryBackend encodes requests as their path and records the
+// entries written to it, failing while fail is set
+type testEntryBackend struct {
+	l       sync.Mutex
+	fail    bool
+	entries []string
+}
+
+func (b *testEntryBackend) GetHash(data string) string {
+	return data
+}
+
+func (b *testEntryBackend) LogRequest(auth *logical.Auth, req *logical.Request, err error) error {
+	return nil
+}
+
+func (b *testEntryBackend) LogResponse(auth *logical.Auth, req *logical.Request,
+	resp *logical.Response, err error) error {
+	return nil
+}
+
+func (b *testEntryBackend) EncodeRequest(auth *logical.Auth, req *logical.Request, err error) ([]byte, error) {
+	return []byte(req.Path + "\n"), nil
+}
+
+func (b *testEntryBackend) EncodeResponse(auth *logical.Auth, req *logical.Request,
+	resp *logical.Response, err error) ([]byte, error) {
+	return []byte(req.Path + "\n"), nil
+}
+
+func (b *testEntryBackend) WriteEntry(entry []byte) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+	if b.fail {
+		return fmt.Errorf("unavailable")
+	}
+	b.entries = append(b.entries, string(entry))
+	return nil
+}
+
+func (b *testEntryBackend) setFail(fail bool) {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.fail = fail
+}
+
+func (b *testEntryBackend) written() []string {
+	b.l.Lock()
+	defer b.l.Unlock()
+	return append([]string{}, b.entries...)
+}
+
+func testSpoolWait(t *testing.T, b *testEntryBackend, expected []string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if reflect.DeepEqual(b.written(), expected) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("bad: %#v", b.written())
+}
+
+func TestSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-spool")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.spool")
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	backend := &testEntryBackend{}
+	s, err := NewSpool(path, backend, logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := s.LogRequest(nil, &logical.Request{Path: "foo"}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s.LogResponse(nil, &logical.Request{Path: "bar"}, nil, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	testSpoolWait(t, backend, []string{"foo\n", "bar\n"})
+
+	// The spool is truncated once everything is delivered
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Fatalf("spool was not truncated: %v", err)
+	}
+
+	// Entries are accepted while the backend is down, and kept across a
+	// restart of the spool
+	backend.setFail(true)
+	if err := s.LogRequest(nil, &logical.Request{Path: "baz"}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.Pending() == 0 {
+		t.Fatalf("entry should be pending")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The offset is replaced by renaming a temporary file, which is not
+	// left behind
+	raw, err := ioutil.ReadFile(path + ".offset")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(raw) != "0" {
+		t.Fatalf("bad: %q", raw)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("bad: %#v", files)
+	}
+
+	backend.setFail(false)
+	s, err = NewSpool(path, backend, logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer s.Close()
+	testSpoolWait(t, backend, []string{"foo\n", "bar\n", "baz\n"})
+}
+
+func TestSpool_partialEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-spool")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.spool")
+
+	// A spool with a delivered entry, an undelivered one and a partial
+	// one left by a crash
+	if err := ioutil.WriteFile(path, []byte("foo\nbar\nba"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := ioutil.WriteFile(path+".offset", []byte("4"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	backend := &testEntryBackend{}
+	s, err := NewSpool(path, backend, log.New(os.Stderr, "", log.LstdFlags))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer s.Close()
+	testSpoolWait(t, backend, []string{"bar\n"})
+}
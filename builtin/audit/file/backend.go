@@ -1,6 +1,7 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -68,9 +69,26 @@ func (b *Backend) GetHash(data string) string {
 }
 
 func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr error) error {
-	if err := b.open(); err != nil {
+	entry, err := b.EncodeRequest(auth, req, outerErr)
+	if err != nil {
+		return err
+	}
+	return b.WriteEntry(entry)
+}
+
+func (b *Backend) LogResponse(
+	auth *logical.Auth,
+	req *logical.Request,
+	resp *logical.Response,
+	err error) error {
+	entry, err := b.EncodeResponse(auth, req, resp, err)
+	if err != nil {
 		return err
 	}
+	return b.WriteEntry(entry)
+}
+
+func (b *Backend) EncodeRequest(auth *logical.Auth, req *logical.Request, outerErr error) ([]byte, error) {
 	if !b.logRaw {
 		// Before we copy the structure we must nil out some data
 		// otherwise we will cause reflection to panic and die
@@ -86,37 +104,38 @@ func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr
 		// Copy the structures
 		cp, err := copystructure.Copy(auth)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		auth = cp.(*logical.Auth)
 
 		cp, err = copystructure.Copy(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		req = cp.(*logical.Request)
 
 		// Hash any sensitive information
 		if err := audit.Hash(b.salt, auth); err != nil {
-			return err
+			return nil, err
 		}
 		if err := audit.Hash(b.salt, req); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
+	var buf bytes.Buffer
 	var format audit.FormatJSON
-	return format.FormatRequest(b.f, auth, req, outerErr)
+	if err := format.FormatRequest(&buf, auth, req, outerErr); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-func (b *Backend) LogResponse(
+func (b *Backend) EncodeResponse(
 	auth *logical.Auth,
 	req *logical.Request,
 	resp *logical.Response,
-	err error) error {
-	if err := b.open(); err != nil {
-		return err
-	}
+	err error) ([]byte, error) {
 	if !b.logRaw {
 		// Before we copy the structure we must nil out some data
 		// otherwise we will cause reflection to panic and die
@@ -132,36 +151,49 @@ func (b *Backend) LogResponse(
 		// Copy the structure
 		cp, err := copystructure.Copy(auth)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		auth = cp.(*logical.Auth)
 
 		cp, err = copystructure.Copy(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		req = cp.(*logical.Request)
 
 		cp, err = copystructure.Copy(resp)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		resp = cp.(*logical.Response)
 
 		// Hash any sensitive information
 		if err := audit.Hash(b.salt, auth); err != nil {
-			return err
+			return nil, err
 		}
 		if err := audit.Hash(b.salt, req); err != nil {
-			return err
+			return nil, err
 		}
 		if err := audit.Hash(b.salt, resp); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
+	var buf bytes.Buffer
 	var format audit.FormatJSON
-	return format.FormatResponse(b.f, auth, req, resp, err)
+	if err := format.FormatResponse(&buf, auth, req, resp, err); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteEntry appends an encoded entry to the file
+func (b *Backend) WriteEntry(entry []byte) error {
+	if err := b.open(); err != nil {
+		return err
+	}
+	_, err := b.f.Write(entry)
+	return err
 }
 
 func (b *Backend) open() error {
@@ -65,6 +65,23 @@ func (b *Backend) GetHash(data string) string {
 }
 
 func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr error) error {
+	entry, err := b.EncodeRequest(auth, req, outerErr)
+	if err != nil {
+		return err
+	}
+	return b.WriteEntry(entry)
+}
+
+func (b *Backend) LogResponse(auth *logical.Auth, req *logical.Request,
+	resp *logical.Response, err error) error {
+	entry, err := b.EncodeResponse(auth, req, resp, err)
+	if err != nil {
+		return err
+	}
+	return b.WriteEntry(entry)
+}
+
+func (b *Backend) EncodeRequest(auth *logical.Auth, req *logical.Request, outerErr error) ([]byte, error) {
 	if !b.logRaw {
 		// Before we copy the structure we must nil out some data
 		// otherwise we will cause reflection to panic and die
@@ -80,39 +97,38 @@ func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr
 		// Copy the structures
 		cp, err := copystructure.Copy(auth)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		auth = cp.(*logical.Auth)
 
 		cp, err = copystructure.Copy(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		req = cp.(*logical.Request)
 
 		// Hash any sensitive information
 		if err := audit.Hash(b.salt, auth); err != nil {
-			return err
+			return nil, err
 		}
 		if err := audit.Hash(b.salt, req); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// Encode the entry as JSON
 	var buf bytes.Buffer
 	var format audit.FormatJSON
 	if err := format.FormatRequest(&buf, auth, req, outerErr); err != nil {
-		return err
+		return nil, err
 	}
-
-	// Write out to syslog
-	_, err := b.logger.Write(buf.Bytes())
-	return err
+	return buf.Bytes(), nil
 }
 
-func (b *Backend) LogResponse(auth *logical.Auth, req *logical.Request,
-	resp *logical.Response, err error) error {
+func (b *Backend) EncodeResponse(
+	auth *logical.Auth,
+	req *logical.Request,
+	resp *logical.Response,
+	err error) ([]byte, error) {
 	if !b.logRaw {
 		// Before we copy the structure we must nil out some data
 		// otherwise we will cause reflection to panic and die
@@ -128,42 +144,44 @@ func (b *Backend) LogResponse(auth *logical.Auth, req *logical.Request,
 		// Copy the structure
 		cp, err := copystructure.Copy(auth)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		auth = cp.(*logical.Auth)
 
 		cp, err = copystructure.Copy(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		req = cp.(*logical.Request)
 
 		cp, err = copystructure.Copy(resp)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		resp = cp.(*logical.Response)
 
 		// Hash any sensitive information
 		if err := audit.Hash(b.salt, auth); err != nil {
-			return err
+			return nil, err
 		}
 		if err := audit.Hash(b.salt, req); err != nil {
-			return err
+			return nil, err
 		}
 		if err := audit.Hash(b.salt, resp); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// Encode the entry as JSON
 	var buf bytes.Buffer
 	var format audit.FormatJSON
 	if err := format.FormatResponse(&buf, auth, req, resp, err); err != nil {
-		return err
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	// Write otu to syslog
-	_, err = b.logger.Write(buf.Bytes())
+// WriteEntry writes an encoded entry out to syslog
+func (b *Backend) WriteEntry(entry []byte) error {
+	_, err := b.logger.Write(entry)
 	return err
 }
@@ -28,7 +28,8 @@ func pathLogin(b *backend) *framework.Path {
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.UpdateOperation: b.pathLogin,
+			logical.UpdateOperation:       b.pathLogin,
+			logical.ResolveLoginOperation: b.pathLoginResolve,
 		},
 
 		HelpSynopsis:    pathLoginSyn,
@@ -46,6 +47,37 @@ func (b *backend) pathLogin(
 		return logical.ErrorResponse("missing 'app_id' or 'user_id'"), nil
 	}
 
+	if resp, err := b.checkUserId(req, appId, userId); resp != nil || err != nil {
+		return resp, err
+	}
+
+	return b.appLoginResponse(req, appId, userId)
+}
+
+// pathLoginResolve returns what a login with the app ID would be granted.
+// The user ID is optional: if given, it is checked against the app ID and
+// any CIDR restriction on the user ID is applied to the connection.
+func (b *backend) pathLoginResolve(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	appId := data.Get("app_id").(string)
+	userId := data.Get("user_id").(string)
+
+	if appId == "" {
+		return logical.ErrorResponse("missing 'app_id'"), nil
+	}
+	if userId != "" {
+		if resp, err := b.checkUserId(req, appId, userId); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	return b.appLoginResponse(req, appId, userId)
+}
+
+// checkUserId verifies that the user ID may log in to the app ID from the
+// address of the connection, returning an error response if not
+func (b *backend) checkUserId(
+	req *logical.Request, appId, userId string) (*logical.Response, error) {
 	// Look up the apps that this user is allowed to access
 	appsMap, err := b.MapUserId.Get(req.Storage, userId)
 	if err != nil {
@@ -94,7 +126,13 @@ func (b *backend) pathLogin(
 	if !found {
 		return logical.ErrorResponse("invalid user ID or app ID"), nil
 	}
+	return nil, nil
+}
 
+// appLoginResponse is the response to a successful login with the app ID.
+// The user ID is only used for the metadata, and may be empty.
+func (b *backend) appLoginResponse(
+	req *logical.Request, appId, userId string) (*logical.Response, error) {
 	// Get the raw data associated with the app
 	appRaw, err := b.MapAppId.Get(req.Storage, appId)
 	if err != nil {
@@ -118,10 +156,12 @@ func (b *backend) pathLogin(
 
 	// Store hashes of the app ID and user ID for the metadata
 	appIdHash := sha1.Sum([]byte(appId))
-	userIdHash := sha1.Sum([]byte(userId))
 	metadata := map[string]string{
-		"app-id":  "sha1:" + hex.EncodeToString(appIdHash[:]),
-		"user-id": "sha1:" + hex.EncodeToString(userIdHash[:]),
+		"app-id": "sha1:" + hex.EncodeToString(appIdHash[:]),
+	}
+	if userId != "" {
+		userIdHash := sha1.Sum([]byte(userId))
+		metadata["user-id"] = "sha1:" + hex.EncodeToString(userIdHash[:])
 	}
 
 	return &logical.Response{
@@ -139,4 +179,5 @@ Log in with an App ID and User ID.
 
 const pathLoginDesc = `
 This endpoint authenticates using an application ID, user ID and potential the IP address of the connecting client.
+A login dry run of this path only needs the application ID.
 `
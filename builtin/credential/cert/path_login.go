@@ -20,9 +20,15 @@ type ParsedCert struct {
 func pathLogin(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "login",
-		Fields:  map[string]*framework.FieldSchema{},
+		Fields: map[string]*framework.FieldSchema{
+			"certificate": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "PEM encoded client certificate, followed by any intermediates, to check instead of the certificate of the connection. Only used by login dry runs.",
+			},
+		},
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.UpdateOperation: b.pathLogin,
+			logical.UpdateOperation:       b.pathLogin,
+			logical.ResolveLoginOperation: b.pathLoginResolve,
 		},
 	}
 }
@@ -33,8 +39,29 @@ func (b *backend) pathLogin(
 	if req.Connection == nil || req.Connection.ConnState == nil {
 		return logical.ErrorResponse("tls connection required"), nil
 	}
-	connState := req.Connection.ConnState
+	return b.loginWithConnState(req, req.Connection.ConnState)
+}
+
+// pathLoginResolve returns what a login with the given certificate would
+// be granted, or with the certificate of the connection if none is given
+func (b *backend) pathLoginResolve(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if raw := data.Get("certificate").(string); raw != "" {
+		certs := parsePEM([]byte(raw))
+		if len(certs) == 0 {
+			return logical.ErrorResponse("failed to parse certificate"), nil
+		}
+		return b.loginWithConnState(req, &tls.ConnectionState{
+			PeerCertificates: certs,
+		})
+	}
+	return b.pathLogin(req, data)
+}
 
+// loginWithConnState authenticates the client by the certificates
+// presented in the connection state
+func (b *backend) loginWithConnState(
+	req *logical.Request, connState *tls.ConnectionState) (*logical.Response, error) {
 	// Load the trusted certificates
 	roots, trusted := b.loadTrustedCerts(req.Storage)
 
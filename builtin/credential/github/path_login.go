@@ -3,6 +3,7 @@ package github
 import (
 	"fmt"
 	"net/url"
+	"strings"
 
 	"github.com/google/go-github/github"
 	"github.com/hashicorp/vault/logical"
@@ -17,10 +18,21 @@ func pathLogin(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "GitHub personal API token",
 			},
+
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "GitHub username. Only used by login dry runs.",
+			},
+
+			"teams": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Comma-separated list of the teams the user is a member of in the organization. Only used by login dry runs.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.UpdateOperation: b.pathLogin,
+			logical.UpdateOperation:       b.pathLogin,
+			logical.ResolveLoginOperation: b.pathLoginResolve,
 		},
 	}
 }
@@ -119,6 +131,42 @@ func (b *backend) pathLogin(
 		}
 	}
 
+	return b.loginResponse(req, config, *user.Login, *org.Login, teamNames)
+}
+
+// pathLoginResolve returns what a login by the user would be granted.
+// GitHub is not contacted, so the teams of the user are taken from the
+// request.
+func (b *backend) pathLoginResolve(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.Config(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config.Org == "" {
+		return logical.ErrorResponse(
+			"configure the github credential backend first"), nil
+	}
+
+	username := data.Get("username").(string)
+	if username == "" {
+		return logical.ErrorResponse("missing 'username'"), nil
+	}
+
+	var teamNames []string
+	for _, t := range strings.Split(data.Get("teams").(string), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			teamNames = append(teamNames, t)
+		}
+	}
+
+	return b.loginResponse(req, config, username, config.Org, teamNames)
+}
+
+// loginResponse is the response to a successful login by a member of the
+// given teams of the organization
+func (b *backend) loginResponse(req *logical.Request, config *config,
+	username, org string, teamNames []string) (*logical.Response, error) {
 	policiesList, err := b.Map.Policies(req.Storage, teamNames...)
 	if err != nil {
 		return nil, err
@@ -133,10 +181,10 @@ func (b *backend) pathLogin(
 		Auth: &logical.Auth{
 			Policies: policiesList,
 			Metadata: map[string]string{
-				"username": *user.Login,
-				"org":      *org.Login,
+				"username": username,
+				"org":      org,
 			},
-			DisplayName: *user.Login,
+			DisplayName: username,
 			LeaseOptions: logical.LeaseOptions{
 				TTL:         ttl,
 				GracePeriod: ttl / 10,
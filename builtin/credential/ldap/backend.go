@@ -2,9 +2,11 @@ package ldap
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/go-ldap/ldap"
 	"github.com/hashicorp/vault/helper/mfa"
+	"github.com/hashicorp/vault/helper/ratelimit"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -14,7 +16,9 @@ func Factory(conf *logical.BackendConfig) (logical.Backend, error) {
 }
 
 func Backend() *framework.Backend {
-	var b backend
+	b := backend{
+		passwordLimiter: ratelimit.NewFailureLimiter(0, 0),
+	}
 	b.Backend = &framework.Backend{
 		Help: backendHelp,
 
@@ -23,12 +27,14 @@ func Backend() *framework.Backend {
 				"config",
 				"groups/*",
 				"users/*",
+				"test-login/*",
 			},
 				mfa.MFARootPaths()...,
 			),
 
 			Unauthenticated: []string{
 				"login/*",
+				"password/*",
 			},
 		},
 
@@ -36,6 +42,8 @@ func Backend() *framework.Backend {
 			pathConfig(&b),
 			pathGroups(&b),
 			pathUsers(&b),
+			pathPassword(&b),
+			pathTestLogin(&b),
 		},
 			mfa.MFAPaths(b.Backend, pathLogin(&b))...,
 		),
@@ -48,6 +56,10 @@ func Backend() *framework.Backend {
 
 type backend struct {
 	*framework.Backend
+
+	// passwordLimiter tracks failed old password checks on the
+	// self-service password endpoint, keyed by username.
+	passwordLimiter *ratelimit.FailureLimiter
 }
 
 func EscapeLDAPValue(input string) string {
@@ -84,7 +96,18 @@ func EscapeLDAPValue(input string) string {
 	return input
 }
 
-func (b *backend) Login(req *logical.Request, username string, password string) ([]string, *logical.Response, error) {
+// loginResult describes what a successful bind as a user grants.
+type loginResult struct {
+	// Username is the normalized name the user logged in as
+	Username string
+	UserDN   string
+	Groups   []string
+	Policies []string
+}
+
+// Login authenticates the user and returns their groups and policies. It
+// does not require the user to be granted any policies.
+func (b *backend) Login(req *logical.Request, username string, password string) (*loginResult, *logical.Response, error) {
 
 	cfg, err := b.Config(req)
 	if err != nil {
@@ -98,34 +121,20 @@ func (b *backend) Login(req *logical.Request, username string, password string)
 	if err != nil {
 		return nil, logical.ErrorResponse(err.Error()), nil
 	}
+	defer c.Close()
 
-	// Try to authenticate to the server using the provided credentials
-	binddn := ""
-	if cfg.UPNDomain != "" {
-		binddn = fmt.Sprintf("%s@%s", EscapeLDAPValue(username), cfg.UPNDomain)
-	} else {
-		binddn = fmt.Sprintf("%s=%s,%s", cfg.UserAttr, EscapeLDAPValue(username), cfg.UserDN)
-	}
+	// Try to authenticate to the server using the provided credentials.
+	// Every spelling of the username is the same user, for the bind as
+	// well as for the users/ entry.
+	name := cfg.NormalizeUsername(username)
+	binddn := cfg.BindDN(name)
 	if err = c.Bind(binddn, password); err != nil {
 		return nil, logical.ErrorResponse(fmt.Sprintf("LDAP bind failed: %v", err)), nil
 	}
 
-	userdn := ""
-	if cfg.UPNDomain != "" {
-		// Find the distinguished name for the user if userPrincipalName used for login
-		sresult, err := c.Search(&ldap.SearchRequest{
-			BaseDN: cfg.UserDN,
-			Scope:  2, // subtree
-			Filter: fmt.Sprintf("(userPrincipalName=%s)", binddn),
-		})
-		if err != nil {
-			return nil, logical.ErrorResponse(fmt.Sprintf("LDAP search failed: %v", err)), nil
-		}
-		for _, e := range sresult.Entries {
-			userdn = e.DN
-		}
-	} else {
-		userdn = binddn
+	userdn, err := cfg.UserDNFor(c, binddn)
+	if err != nil {
+		return nil, logical.ErrorResponse(err.Error()), nil
 	}
 
 	// Enumerate all groups the user is member of. The search filter should
@@ -133,41 +142,65 @@ func (b *backend) Login(req *logical.Request, username string, password string)
 	sresult, err := c.Search(&ldap.SearchRequest{
 		BaseDN: cfg.GroupDN,
 		Scope:  2, // subtree
-		Filter: fmt.Sprintf("(|(memberUid=%s)(member=%s)(uniqueMember=%s))", username, userdn, userdn),
+		Filter: fmt.Sprintf("(|(memberUid=%s)(member=%s)(uniqueMember=%s))", name, userdn, userdn),
 	})
 	if err != nil {
 		return nil, logical.ErrorResponse(fmt.Sprintf("LDAP search failed: %v", err)), nil
 	}
 
-	var allgroups []string
-	var policies []string
-
-	user, err := b.User(req.Storage, username)
-	if err == nil && user != nil {
-		allgroups = append(allgroups, user.Groups...)
-	}
-
+	var ldapGroups []string
 	for _, e := range sresult.Entries {
 		dn, err := ldap.ParseDN(e.DN)
 		if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
 			continue
 		}
 		gname := dn.RDNs[0].Attributes[0].Value
-		allgroups = append(allgroups, gname)
+		ldapGroups = append(ldapGroups, gname)
 	}
 
+	result := &loginResult{
+		Username: name,
+		UserDN:   userdn,
+	}
+	result.Groups, result.Policies = b.resolvePolicies(req.Storage, name, ldapGroups)
+
+	return result, nil, nil
+}
+
+// resolvePolicies returns the groups of the user, from the LDAP server and
+// their users/ entry, and the policies of those groups. The username must
+// already be normalized.
+func (b *backend) resolvePolicies(s logical.Storage, username string, ldapGroups []string) ([]string, []string) {
+	var allgroups []string
+	user, err := b.User(s, username)
+	if err == nil && user != nil {
+		allgroups = append(allgroups, user.Groups...)
+	}
+	allgroups = dedupe(append(allgroups, ldapGroups...))
+
+	var policies []string
 	for _, gname := range allgroups {
-		group, err := b.Group(req.Storage, gname)
+		group, err := b.Group(s, gname)
 		if err == nil && group != nil {
 			policies = append(policies, group.Policies...)
 		}
 	}
+	return allgroups, dedupe(policies)
+}
 
-	if len(policies) == 0 {
-		return nil, logical.ErrorResponse("user is not member of any authorized group"), nil
+// dedupe returns the distinct values of the list, sorted.
+func dedupe(list []string) []string {
+	seen := make(map[string]struct{}, len(list))
+	result := make([]string, 0, len(list))
+	for _, v := range list {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
 	}
-
-	return policies, nil, nil
+	sort.Strings(result)
+	return result
 }
 
 const backendHelp = `
@@ -177,5 +210,11 @@ to set of policies.
 
 Configuration of the server is done through the "config" and "groups"
 endpoints by a user with root access. Authentication is then done
-by suppying the two fields for "login".
+by suppying the two fields for "login". If enabled in the configuration,
+users may change their directory password with the "password" endpoint.
+
+When "upndomain" is configured for Active Directory, users may log in
+with their sAMAccountName, DOMAIN\name or userPrincipalName, which all
+result in the same username. The "test-login" endpoint reports the groups
+and policies a login would be granted without issuing a token.
 `
@@ -2,9 +2,11 @@ package ldap
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/vault/helper/ratelimit"
 	"github.com/hashicorp/vault/logical"
 	logicaltest "github.com/hashicorp/vault/logical/testing"
 	"github.com/mitchellh/mapstructure"
@@ -37,6 +39,7 @@ func TestBackend_basic(t *testing.T) {
 			testAccStepGroup(t, "engineers", "bar"),
 			testAccStepUser(t, "tesla", "engineers"),
 			testAccStepLogin(t, "tesla", "password"),
+			testAccStepTestLogin(t, "tesla", "password"),
 		},
 	})
 }
@@ -187,6 +190,75 @@ func testAccStepLogin(t *testing.T, user string, pass string) logicaltest.TestSt
 	}
 }
 
+func testAccStepTestLogin(t *testing.T, user string, pass string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "test-login/" + user,
+		Data: map[string]interface{}{
+			"password": pass,
+		},
+
+		Check: func(resp *logical.Response) error {
+			if resp.Auth != nil {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+
+			var d struct {
+				Username string   `mapstructure:"username"`
+				Groups   []string `mapstructure:"groups"`
+				Policies []string `mapstructure:"policies"`
+			}
+			if err := mapstructure.Decode(resp.Data, &d); err != nil {
+				return err
+			}
+
+			if d.Username != user {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+			if !reflect.DeepEqual(d.Policies, []string{"bar", "foo"}) {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+
+			return nil
+		},
+	}
+}
+
+func TestConfigEntry_NormalizeUsername(t *testing.T) {
+	cfg := &ConfigEntry{UPNDomain: "Example.com"}
+	for _, username := range []string{
+		"jdoe",
+		"JDoe",
+		"EXAMPLE\\jdoe",
+		"jdoe@example.com",
+		"JDOE@EXAMPLE.COM",
+	} {
+		if res := cfg.NormalizeUsername(username); res != "jdoe" {
+			t.Errorf("bad: %s: %s", username, res)
+		}
+	}
+
+	// Other domains are left alone
+	if res := cfg.NormalizeUsername("jdoe@other.com"); res != "jdoe@other.com" {
+		t.Fatalf("bad: %s", res)
+	}
+
+	// Without a UPN domain nothing is changed
+	cfg = &ConfigEntry{}
+	if res := cfg.NormalizeUsername("EXAMPLE\\JDoe"); res != "EXAMPLE\\JDoe" {
+		t.Fatalf("bad: %s", res)
+	}
+}
+
+func TestSamePolicies(t *testing.T) {
+	if !samePolicies([]string{"dev", "ops"}, []string{"ops", "dev", "ops"}) {
+		t.Fatalf("expected policies to match")
+	}
+	if samePolicies([]string{"dev", "ops"}, []string{"dev"}) {
+		t.Fatalf("expected policies to differ")
+	}
+}
+
 func TestLDAPEscape(t *testing.T) {
 	testcases := map[string]string{
 		"#test":       "\\#test",
@@ -203,3 +275,143 @@ func TestLDAPEscape(t *testing.T) {
 		}
 	}
 }
+
+func TestEncodeADPassword(t *testing.T) {
+	expected := "\"\x00p\x00w\x00\"\x00"
+	if res := encodeADPassword("pw"); res != expected {
+		t.Fatalf("bad: %q", res)
+	}
+}
+
+func TestBackend_passwordChangeDisabled(t *testing.T) {
+	b := Backend()
+	storage := &logical.InmemStorage{}
+
+	entry, err := logical.StorageEntryJSON("config", &ConfigEntry{
+		Url: "ldap://127.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := storage.Put(entry); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "password/tesla",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"old_password": "password",
+			"password":     "changed",
+		},
+	})
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestBackend_userNormalized(t *testing.T) {
+	b := Backend()
+	storage := &logical.InmemStorage{}
+
+	entry, err := logical.StorageEntryJSON("config", &ConfigEntry{
+		Url:       "ldap://127.0.0.1",
+		UPNDomain: "example.com",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := storage.Put(entry); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for path, data := range map[string]map[string]interface{}{
+		"groups/engineers":    {"policies": "bar"},
+		"users/EXAMPLE\\JDoe": {"groups": "engineers"},
+	} {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: %#v %v", path, resp, err)
+		}
+	}
+
+	// The user entry is found whichever spelling is used
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "users/jdoe@EXAMPLE.COM",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || resp.Data["groups"] != "engineers" {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+
+	cfg := &ConfigEntry{UPNDomain: "example.com"}
+	inner := &backend{Backend: b}
+	for _, username := range []string{"jdoe", "JDoe", "EXAMPLE\\jdoe", "jdoe@example.com"} {
+		groups, policies := inner.resolvePolicies(storage, cfg.NormalizeUsername(username), nil)
+		if !reflect.DeepEqual(groups, []string{"engineers"}) ||
+			!reflect.DeepEqual(policies, []string{"bar"}) {
+			t.Fatalf("bad: %s: %v %v", username, groups, policies)
+		}
+	}
+
+	// A login dry run resolves the same user without contacting the server
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ResolveLoginOperation,
+		Path:      "login/JDOE@example.com",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+	if resp.Auth.DisplayName != "jdoe" || !reflect.DeepEqual(resp.Auth.Policies, []string{"bar"}) {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+}
+
+func TestBackend_passwordChangeServerDown(t *testing.T) {
+	b := Backend()
+	storage := &logical.InmemStorage{}
+
+	// Nothing listens on this port
+	entry, err := logical.StorageEntryJSON("config", &ConfigEntry{
+		Url:            "ldap://127.0.0.1:1",
+		UserAttr:       "cn",
+		PasswordChange: passwordChangeExop,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := storage.Put(entry); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Failures to reach the server don't use up the user's attempts, and
+	// the error doesn't leak the details
+	for i := 0; i < ratelimit.DefaultMaxFailures+1; i++ {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "password/tesla",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"old_password": "password",
+				"password":     "changed",
+			},
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Data["error"] != "failed to change password" {
+			t.Fatalf("bad: %d: %#v", i, resp)
+		}
+	}
+}
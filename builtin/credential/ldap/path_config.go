@@ -49,6 +49,10 @@ func pathConfig(b *backend) *framework.Path {
 				Type:        framework.TypeBool,
 				Description: "Issue a StartTLS command after establishing unencrypted connection (optional)",
 			},
+			"password_change": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "How users may change their password through Vault: \"exop\" for the RFC 3062 password modify operation, \"ad\" for Active Directory, or empty to disable (optional)",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -90,14 +94,15 @@ func (b *backend) pathConfigRead(
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"url":          cfg.Url,
-			"userdn":       cfg.UserDN,
-			"groupdn":      cfg.GroupDN,
-			"upndomain":    cfg.UPNDomain,
-			"userattr":     cfg.UserAttr,
-			"certificate":  cfg.Certificate,
-			"insecure_tls": cfg.InsecureTLS,
-			"starttls":     cfg.StartTLS,
+			"url":             cfg.Url,
+			"userdn":          cfg.UserDN,
+			"groupdn":         cfg.GroupDN,
+			"upndomain":       cfg.UPNDomain,
+			"userattr":        cfg.UserAttr,
+			"certificate":     cfg.Certificate,
+			"insecure_tls":    cfg.InsecureTLS,
+			"starttls":        cfg.StartTLS,
+			"password_change": cfg.PasswordChange,
 		},
 	}, nil
 }
@@ -123,7 +128,7 @@ func (b *backend) pathConfigWrite(
 		cfg.GroupDN = groupdn
 	}
 	upndomain := d.Get("upndomain").(string)
-	if groupdn != "" {
+	if upndomain != "" {
 		cfg.UPNDomain = upndomain
 	}
 	certificate := d.Get("certificate").(string)
@@ -138,6 +143,14 @@ func (b *backend) pathConfigWrite(
 	if startTLS {
 		cfg.StartTLS = startTLS
 	}
+	passwordChange := strings.ToLower(d.Get("password_change").(string))
+	switch passwordChange {
+	case "", passwordChangeExop, passwordChangeAD:
+		cfg.PasswordChange = passwordChange
+	default:
+		return logical.ErrorResponse(fmt.Sprintf(
+			"invalid password_change: %s", passwordChange)), nil
+	}
 
 	// Try to connect to the LDAP server, to validate the URL configuration
 	// We can also check the URL at this stage, as anything else would probably
@@ -168,6 +181,60 @@ type ConfigEntry struct {
 	Certificate string
 	InsecureTLS bool
 	StartTLS    bool
+
+	// PasswordChange is the method used to change passwords on behalf
+	// of users, or empty if password changes are disabled.
+	PasswordChange string
+}
+
+// NormalizeUsername maps the different forms an Active Directory user may
+// log in with onto one name, so that "jdoe", "EXAMPLE\jdoe" and
+// "jdoe@example.com" all log in as "jdoe". AD names are case insensitive,
+// so the result is also lowercased. Without a UPNDomain the username is
+// returned unchanged.
+func (c *ConfigEntry) NormalizeUsername(username string) string {
+	if c.UPNDomain == "" {
+		return username
+	}
+
+	// Down-level logon names are of the form DOMAIN\user
+	if idx := strings.LastIndex(username, "\\"); idx != -1 {
+		username = username[idx+1:]
+	}
+
+	username = strings.ToLower(username)
+	return strings.TrimSuffix(username, "@"+strings.ToLower(c.UPNDomain))
+}
+
+// BindDN returns the DN used to bind to the server as the given user.
+func (c *ConfigEntry) BindDN(username string) string {
+	if c.UPNDomain != "" {
+		return fmt.Sprintf("%s@%s", EscapeLDAPValue(username), c.UPNDomain)
+	}
+	return fmt.Sprintf("%s=%s,%s", c.UserAttr, EscapeLDAPValue(username), c.UserDN)
+}
+
+// UserDNFor returns the distinguished name of the user bound as binddn.
+// When a userPrincipalName was used to bind this requires a search.
+func (c *ConfigEntry) UserDNFor(conn *ldap.Conn, binddn string) (string, error) {
+	if c.UPNDomain == "" {
+		return binddn, nil
+	}
+
+	// Find the distinguished name for the user if userPrincipalName used for login
+	sresult, err := conn.Search(&ldap.SearchRequest{
+		BaseDN: c.UserDN,
+		Scope:  2, // subtree
+		Filter: fmt.Sprintf("(userPrincipalName=%s)", binddn),
+	})
+	if err != nil {
+		return "", fmt.Errorf("LDAP search failed: %v", err)
+	}
+	userdn := ""
+	for _, e := range sresult.Entries {
+		userdn = e.DN
+	}
+	return userdn, nil
 }
 
 func (c *ConfigEntry) GetTLSConfig(host string) (*tls.Config, error) {
@@ -1,7 +1,6 @@
 package ldap
 
 import (
-	"sort"
 	"strings"
 	"time"
 
@@ -22,10 +21,16 @@ func pathLogin(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Password for this user.",
 			},
+
+			"groups": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Comma-separated list of the groups the user is a member of in the directory. Only used by login dry runs.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.UpdateOperation: b.pathLogin,
+			logical.UpdateOperation:       b.pathLogin,
+			logical.ResolveLoginOperation: b.pathLoginResolve,
 		},
 
 		HelpSynopsis:    pathLoginSyn,
@@ -38,26 +43,64 @@ func (b *backend) pathLogin(
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
 
-	policies, resp, err := b.Login(req, username, password)
-	if len(policies) == 0 {
+	result, resp, err := b.Login(req, username, password)
+	if result == nil {
 		return resp, err
 	}
+	if len(result.Policies) == 0 {
+		return logical.ErrorResponse("user is not member of any authorized group"), nil
+	}
+
+	resp = loginResponse(result)
+	resp.Auth.InternalData = map[string]interface{}{
+		"password": password,
+	}
+	return resp, nil
+}
+
+// pathLoginResolve returns what a login by the user would be granted. The
+// directory is not contacted, so the groups the user is a member of there
+// are taken from the request.
+func (b *backend) pathLoginResolve(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	cfg, err := b.Config(req)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return logical.ErrorResponse("ldap backend not configured"), nil
+	}
+
+	var ldapGroups []string
+	for _, g := range strings.Split(d.Get("groups").(string), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			ldapGroups = append(ldapGroups, g)
+		}
+	}
+
+	result := &loginResult{
+		Username: cfg.NormalizeUsername(d.Get("username").(string)),
+	}
+	result.Groups, result.Policies = b.resolvePolicies(req.Storage, result.Username, ldapGroups)
+	if len(result.Policies) == 0 {
+		return logical.ErrorResponse("user is not member of any authorized group"), nil
+	}
 
-	sort.Strings(policies)
+	return loginResponse(result), nil
+}
 
+// loginResponse is the response to a successful login
+func loginResponse(result *loginResult) *logical.Response {
 	return &logical.Response{
 		Auth: &logical.Auth{
-			Policies: policies,
+			Policies: result.Policies,
 			Metadata: map[string]string{
-				"username": username,
-				"policies": strings.Join(policies, ","),
-			},
-			InternalData: map[string]interface{}{
-				"password": password,
+				"username": result.Username,
+				"policies": strings.Join(result.Policies, ","),
 			},
-			DisplayName: username,
+			DisplayName: result.Username,
 		},
-	}, nil
+	}
 }
 
 func (b *backend) pathLoginRenew(
@@ -67,23 +110,44 @@ func (b *backend) pathLoginRenew(
 	password := req.Auth.InternalData["password"].(string)
 	prevpolicies := req.Auth.Metadata["policies"]
 
-	policies, resp, err := b.Login(req, username, password)
-	if len(policies) == 0 {
+	result, resp, err := b.Login(req, username, password)
+	if result == nil {
 		return resp, err
 	}
+	if len(result.Policies) == 0 {
+		return logical.ErrorResponse("user is not member of any authorized group"), nil
+	}
 
-	sort.Strings(policies)
-	if strings.Join(policies, ",") != prevpolicies {
+	if !samePolicies(result.Policies, strings.Split(prevpolicies, ",")) {
 		return logical.ErrorResponse("policies have changed, revoking login"), nil
 	}
 
 	return framework.LeaseExtend(1*time.Hour, 0, false)(req, d)
 }
 
+// samePolicies reports whether the two lists hold the same policies,
+// ignoring order and duplicates. Tokens issued by older versions may list
+// a policy more than once.
+func samePolicies(a, b []string) bool {
+	a, b = dedupe(a), dedupe(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 const pathLoginSyn = `
 Log in with a username and password.
 `
 
 const pathLoginDesc = `
-This endpoint authenticates using a username and password.
+This endpoint authenticates using a username and password. A login dry run
+of this path takes the username and, optionally, the directory groups of the
+user in "groups", and resolves the policies from the stored users and groups
+without contacting the directory.
 `
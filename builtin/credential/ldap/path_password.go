@@ -0,0 +1,166 @@
+package ldap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/go-ldap/ldap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// passwordChangeExop uses the RFC 3062 password modify extended
+	// operation, supported by OpenLDAP and most other directories.
+	passwordChangeExop = "exop"
+
+	// passwordChangeAD replaces the unicodePwd attribute, as required by
+	// Active Directory. AD only permits this over an encrypted connection.
+	passwordChangeAD = "ad"
+)
+
+func pathPassword(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `password/(?P<username>.+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username of the user whose password is changed.",
+			},
+
+			"old_password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Current password for this user.",
+			},
+
+			"password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "New password for this user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathPasswordUpdate,
+		},
+
+		HelpSynopsis:    pathPasswordSyn,
+		HelpDescription: pathPasswordDesc,
+	}
+}
+
+func (b *backend) pathPasswordUpdate(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := d.Get("username").(string)
+	oldPassword := d.Get("old_password").(string)
+	password := d.Get("password").(string)
+	if oldPassword == "" {
+		return logical.ErrorResponse("missing old_password"), logical.ErrInvalidRequest
+	}
+	if password == "" {
+		return logical.ErrorResponse("missing password"), logical.ErrInvalidRequest
+	}
+
+	cfg, err := b.Config(req)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return logical.ErrorResponse("ldap backend not configured"), nil
+	}
+	if cfg.PasswordChange == "" {
+		return logical.ErrorResponse("password changes are not enabled"), logical.ErrPermissionDenied
+	}
+
+	// Normalize the name first so that every form the user may log in
+	// with shares one failure budget and binds as the same DN.
+	username = cfg.NormalizeUsername(username)
+
+	// Refuse to even try binding if there have been too many recent
+	// failures, so this endpoint can't be used to guess passwords.
+	key := strings.ToLower(username)
+	if ok, wait := b.passwordLimiter.Allow(key); !ok {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"too many failed attempts; try again in %s", wait)), logical.ErrPermissionDenied
+	}
+
+	c, err := cfg.DialLDAP()
+	if err != nil {
+		// The attempt could not be made, so it does not count
+		b.passwordLimiter.Release(key)
+		b.Logger().Printf("[ERR] ldap: failed to connect to change the password of '%s': %v", username, err)
+		return logical.ErrorResponse("failed to change password"), nil
+	}
+	defer c.Close()
+
+	binddn := cfg.BindDN(username)
+	if err := c.Bind(binddn, oldPassword); err != nil {
+		// Only a rejected password counts as a failed attempt
+		if !ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			b.passwordLimiter.Release(key)
+			b.Logger().Printf("[ERR] ldap: failed to bind to change the password of '%s': %v", username, err)
+			return logical.ErrorResponse("failed to change password"), nil
+		}
+		b.Logger().Printf("[WARN] ldap: invalid old password given for '%s': %v", username, err)
+		return logical.ErrorResponse("invalid credentials"), logical.ErrPermissionDenied
+	}
+
+	// The old password is correct, whether or not the change succeeds
+	b.passwordLimiter.Reset(key)
+
+	switch cfg.PasswordChange {
+	case passwordChangeExop:
+		// An empty identity changes the password of the bound user
+		_, err = c.PasswordModify(ldap.NewPasswordModifyRequest("", oldPassword, password))
+	case passwordChangeAD:
+		var userdn string
+		userdn, err = cfg.UserDNFor(c, binddn)
+		if err == nil {
+			// Deleting the old value and adding the new one in a single
+			// request is how AD allows users to change their own password.
+			modify := ldap.NewModifyRequest(userdn)
+			modify.Delete("unicodePwd", []string{encodeADPassword(oldPassword)})
+			modify.Add("unicodePwd", []string{encodeADPassword(password)})
+			err = c.Modify(modify)
+		}
+	}
+	if err != nil {
+		b.Logger().Printf("[ERR] ldap: failed to change the password of '%s': %v", username, err)
+		return logical.ErrorResponse("failed to change password"), nil
+	}
+
+	return nil, nil
+}
+
+// encodeADPassword encodes a password for the unicodePwd attribute, which
+// must be the quoted password in UTF-16LE.
+func encodeADPassword(password string) string {
+	encoded := utf16.Encode([]rune(`"` + password + `"`))
+	buf := make([]byte, len(encoded)*2)
+	for i, r := range encoded {
+		binary.LittleEndian.PutUint16(buf[i*2:], r)
+	}
+	return string(buf)
+}
+
+const pathPasswordSyn = `
+Change a directory password.
+`
+
+const pathPasswordDesc = `
+This endpoint allows a user to change their LDAP password by supplying
+the current password as "old_password" along with the new "password".
+It does not require a token, and must be enabled with the
+"password_change" configuration option.
+
+Vault binds as the user with the old password and then changes it using
+either the password modify extended operation ("exop") or, for Active
+Directory, by replacing the unicodePwd attribute ("ad").
+
+To prevent this endpoint from being used to guess passwords, repeated
+failures to bind as a user cause further attempts for that user to be
+refused for a period of time. Only a rejected old password counts as a
+failure; attempts that fail because the server could not be reached do
+not. The details of any failure are logged rather than returned.
+`
@@ -0,0 +1,67 @@
+package ldap
+
+import (
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathTestLogin(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `test-login/(?P<username>.+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username to test the login of.",
+			},
+
+			"password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Password for this user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTestLogin,
+		},
+
+		HelpSynopsis:    pathTestLoginSyn,
+		HelpDescription: pathTestLoginDesc,
+	}
+}
+
+func (b *backend) pathTestLogin(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := d.Get("username").(string)
+	password := d.Get("password").(string)
+
+	result, resp, err := b.Login(req, username, password)
+	if result == nil {
+		return resp, err
+	}
+
+	resp = &logical.Response{
+		Data: map[string]interface{}{
+			"username": result.Username,
+			"user_dn":  result.UserDN,
+			"groups":   result.Groups,
+			"policies": result.Policies,
+		},
+	}
+	if len(result.Policies) == 0 {
+		resp.AddWarning("user is not member of any authorized group; a login would be refused")
+	}
+	return resp, nil
+}
+
+const pathTestLoginSyn = `
+Check what a login would be granted without logging in.
+`
+
+const pathTestLoginDesc = `
+This endpoint binds to the LDAP server as the given user, exactly as a
+login does, and returns the normalized username, the user's DN, and the
+groups and policies a login would be granted. No token is issued.
+
+This is useful for checking the group configuration and how Active
+Directory usernames are normalized when "upndomain" is set.
+`
@@ -50,19 +50,41 @@ func (b *backend) User(s logical.Storage, n string) (*UserEntry, error) {
 	return &result, nil
 }
 
+// userName returns the name the users/ entry of the given user is stored
+// under, which is normalized in the same way as the username at login.
+func (b *backend) userName(req *logical.Request, name string) (string, error) {
+	cfg, err := b.Config(req)
+	if err != nil {
+		return "", err
+	}
+	if cfg == nil {
+		return name, nil
+	}
+	return cfg.NormalizeUsername(name), nil
+}
+
 func (b *backend) pathUserDelete(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	err := req.Storage.Delete("user/" + d.Get("name").(string))
+	name, err := b.userName(req, d.Get("name").(string))
 	if err != nil {
 		return nil, err
 	}
 
+	if err := req.Storage.Delete("user/" + name); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
 func (b *backend) pathUserRead(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	user, err := b.User(req.Storage, d.Get("name").(string))
+	name, err := b.userName(req, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := b.User(req.Storage, name)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +101,10 @@ func (b *backend) pathUserRead(
 
 func (b *backend) pathUserWrite(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	name := d.Get("name").(string)
+	name, err := b.userName(req, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
 	groups := strings.Split(d.Get("groups").(string), ",")
 	for i, g := range groups {
 		groups[i] = strings.TrimSpace(g)
@@ -110,7 +135,9 @@ Manage additional groups for users allowed to authenticate.
 const pathUserHelpDesc = `
 This endpoint allows you to create, read, update, and delete configuration
 for LDAP users that are allowed to authenticate, in particular associating
-additional groups to them.
+additional groups to them. When "upndomain" is configured, the name is
+normalized in the same way as the username at login, so that every form of
+the name refers to the same user.
 
 Deleting a user will not revoke their auth. To do this, do a revoke on "login/<username>" for
 the usernames you want revoked.
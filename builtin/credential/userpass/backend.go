@@ -2,6 +2,7 @@ package userpass
 
 import (
 	"github.com/hashicorp/vault/helper/mfa"
+	"github.com/hashicorp/vault/helper/ratelimit"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -11,7 +12,9 @@ func Factory(conf *logical.BackendConfig) (logical.Backend, error) {
 }
 
 func Backend() *framework.Backend {
-	var b backend
+	b := backend{
+		passwordLimiter: ratelimit.NewFailureLimiter(0, 0),
+	}
 	b.Backend = &framework.Backend{
 		Help: backendHelp,
 
@@ -24,11 +27,14 @@ func Backend() *framework.Backend {
 
 			Unauthenticated: []string{
 				"login/*",
+				"password/*",
 			},
 		},
 
 		Paths: append([]*framework.Path{
 			pathUsers(&b),
+			pathUserPassword(&b),
+			pathPassword(&b),
 		},
 			mfa.MFAPaths(b.Backend, pathLogin(&b))...,
 		),
@@ -41,6 +47,10 @@ func Backend() *framework.Backend {
 
 type backend struct {
 	*framework.Backend
+
+	// passwordLimiter tracks failed old password checks on the
+	// self-service password endpoint, keyed by username.
+	passwordLimiter *ratelimit.FailureLimiter
 }
 
 const backendHelp = `
@@ -50,5 +60,6 @@ are supported.
 
 The username/password combination is configured using the "users/"
 endpoints by a user with root access. Authentication is then done
-by suppying the two fields for "login".
+by suppying the two fields for "login". Users may change their own
+password with the "password/" endpoint by supplying the old one.
 `
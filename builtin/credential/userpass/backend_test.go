@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/vault/helper/ratelimit"
 	"github.com/hashicorp/vault/logical"
 	logicaltest "github.com/hashicorp/vault/logical/testing"
 	"github.com/mitchellh/mapstructure"
@@ -89,6 +90,98 @@ func TestBackend_userCrud(t *testing.T) {
 	})
 }
 
+func TestBackend_passwordChange(t *testing.T) {
+	b, err := Factory(&logical.BackendConfig{
+		Logger: nil,
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: time.Hour * 12,
+			MaxLeaseTTLVal:     time.Hour * 24,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	steps := []logicaltest.TestStep{
+		testAccStepUser(t, "web", "password", "foo"),
+		testAccStepPasswordChange(t, "web", "password", "changed", false),
+		testAccStepLogin(t, "web", "changed"),
+		testAccStepReadUser(t, "web", "foo"),
+	}
+
+	// Repeated failures to verify the old password lock the user out
+	// of the self-service endpoint, even with the right password.
+	for i := 0; i < ratelimit.DefaultMaxFailures; i++ {
+		steps = append(steps, testAccStepPasswordChange(t, "web", "wrong", "other", true))
+	}
+	steps = append(steps,
+		testAccStepPasswordChange(t, "web", "changed", "other", true),
+		testAccStepLogin(t, "web", "changed"),
+
+		// An administrative reset clears the lockout
+		testAccStepUserPassword(t, "web", "reset"),
+		testAccStepLogin(t, "web", "reset"),
+		testAccStepPasswordChange(t, "web", "reset", "other", false),
+		testAccStepLogin(t, "web", "other"),
+	)
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		Backend: b,
+		Steps:   steps,
+	})
+}
+
+func TestBackend_loginResolve(t *testing.T) {
+	b, err := Factory(&logical.BackendConfig{
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: time.Hour,
+			MaxLeaseTTLVal:     2 * time.Hour,
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	storage := &logical.InmemStorage{}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "users/web",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"password": "password",
+			"policies": "foo",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+
+	// No password is needed to resolve the login
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ResolveLoginOperation,
+		Path:      "login/Web",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := logicaltest.TestCheckAuth([]string{"foo"})(resp); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp.Auth.DisplayName != "web" {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ResolveLoginOperation,
+		Path:      "login/missing",
+		Storage:   storage,
+	})
+	if err != nil || !resp.IsError() {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+}
+
 func testUsersWrite(t *testing.T, user string, data map[string]interface{}, expectError bool) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.UpdateOperation,
@@ -179,3 +272,33 @@ func testAccStepReadUser(t *testing.T, name string, policies string) logicaltest
 		},
 	}
 }
+
+func testAccStepUserPassword(t *testing.T, name string, password string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "users/" + name + "/password",
+		Data: map[string]interface{}{
+			"password": password,
+		},
+	}
+}
+
+func testAccStepPasswordChange(
+	t *testing.T, name string, oldPassword string, password string, expectError bool) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "password/" + name,
+		Data: map[string]interface{}{
+			"old_password": oldPassword,
+			"password":     password,
+		},
+		Unauthenticated: true,
+		ErrorOk:         true,
+		Check: func(resp *logical.Response) error {
+			if resp.IsError() != expectError {
+				return fmt.Errorf("bad: %#v", resp)
+			}
+			return nil
+		},
+	}
+}
@@ -1,12 +1,10 @@
 package userpass
 
 import (
-	"crypto/subtle"
 	"strings"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func pathLogin(b *backend) *framework.Path {
@@ -25,7 +23,8 @@ func pathLogin(b *backend) *framework.Path {
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.UpdateOperation: b.pathLogin,
+			logical.UpdateOperation:       b.pathLogin,
+			logical.ResolveLoginOperation: b.pathLoginResolve,
 		},
 
 		HelpSynopsis:    pathLoginSyn,
@@ -47,19 +46,32 @@ func (b *backend) pathLogin(
 		return logical.ErrorResponse("unknown username or password"), nil
 	}
 
-	// Check for a password match. Check for a hash collision for Vault 0.2+,
-	// but handle the older legacy passwords with a constant time comparison.
-	passwordBytes := []byte(password)
-	if user.PasswordHash != nil {
-		if err := bcrypt.CompareHashAndPassword(user.PasswordHash, passwordBytes); err != nil {
-			return logical.ErrorResponse("unknown username or password"), nil
-		}
-	} else {
-		if subtle.ConstantTimeCompare([]byte(user.Password), passwordBytes) != 1 {
-			return logical.ErrorResponse("unknown username or password"), nil
-		}
+	if !user.CheckPassword(password) {
+		return logical.ErrorResponse("unknown username or password"), nil
+	}
+
+	return userLoginResponse(username, user), nil
+}
+
+// pathLoginResolve returns what a login by the user would be granted,
+// without checking the password
+func (b *backend) pathLoginResolve(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := strings.ToLower(d.Get("name").(string))
+
+	user, err := b.User(req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return logical.ErrorResponse("unknown username"), nil
 	}
 
+	return userLoginResponse(username, user), nil
+}
+
+// userLoginResponse is the response to a successful login by the user
+func userLoginResponse(username string, user *UserEntry) *logical.Response {
 	return &logical.Response{
 		Auth: &logical.Auth{
 			Policies: user.Policies,
@@ -73,7 +85,7 @@ func (b *backend) pathLogin(
 				Renewable:   user.TTL > 0,
 			},
 		},
-	}, nil
+	}
 }
 
 func (b *backend) pathLoginRenew(
@@ -96,5 +108,6 @@ Log in with a username and password.
 `
 
 const pathLoginDesc = `
-This endpoint authenticates using a username and password.
+This endpoint authenticates using a username and password. A login dry run
+of this path only needs the username.
 `
@@ -0,0 +1,166 @@
+package userpass
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func pathUserPassword(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "users/" + framework.GenericNameRegex("name") + "/password$",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username for this user.",
+			},
+
+			"password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "New password for this user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathUserPasswordUpdate,
+		},
+
+		HelpSynopsis:    pathUserPasswordHelpSyn,
+		HelpDescription: pathUserPasswordHelpDesc,
+	}
+}
+
+func pathPassword(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "password/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username of the user.",
+			},
+
+			"old_password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Current password for this user.",
+			},
+
+			"password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "New password for this user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathPasswordUpdate,
+		},
+
+		HelpSynopsis:    pathPasswordHelpSyn,
+		HelpDescription: pathPasswordHelpDesc,
+	}
+}
+
+func (b *backend) pathUserPasswordUpdate(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := strings.ToLower(d.Get("name").(string))
+	password := d.Get("password").(string)
+	if password == "" {
+		return logical.ErrorResponse("missing password"), logical.ErrInvalidRequest
+	}
+
+	user, err := b.User(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown user: %s", name)), nil
+	}
+
+	if err := b.setPassword(req.Storage, name, user, password); err != nil {
+		return nil, err
+	}
+
+	// An administrative reset clears any lockout on self-service changes
+	b.passwordLimiter.Reset(name)
+	return nil, nil
+}
+
+func (b *backend) pathPasswordUpdate(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := strings.ToLower(d.Get("name").(string))
+	oldPassword := d.Get("old_password").(string)
+	password := d.Get("password").(string)
+	if oldPassword == "" {
+		return logical.ErrorResponse("missing old_password"), logical.ErrInvalidRequest
+	}
+	if password == "" {
+		return logical.ErrorResponse("missing password"), logical.ErrInvalidRequest
+	}
+
+	// Refuse to even check the old password if there have been too many
+	// recent failures, so this endpoint can't be used to guess passwords.
+	if ok, wait := b.passwordLimiter.Allow(name); !ok {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"too many failed attempts; try again in %s", wait)), logical.ErrPermissionDenied
+	}
+
+	user, err := b.User(req.Storage, name)
+	if err != nil {
+		b.passwordLimiter.Release(name)
+		return nil, err
+	}
+	if user == nil || !user.CheckPassword(oldPassword) {
+		return logical.ErrorResponse("unknown username or password"), logical.ErrPermissionDenied
+	}
+
+	if err := b.setPassword(req.Storage, name, user, password); err != nil {
+		return nil, err
+	}
+
+	b.passwordLimiter.Reset(name)
+	return nil, nil
+}
+
+// setPassword hashes and stores a new password for the given user,
+// leaving the rest of the entry untouched.
+func (b *backend) setPassword(
+	s logical.Storage, name string, user *UserEntry, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = ""
+	user.PasswordHash = hash
+
+	entry, err := logical.StorageEntryJSON("user/"+name, user)
+	if err != nil {
+		return err
+	}
+	return s.Put(entry)
+}
+
+const pathUserPasswordHelpSyn = `
+Reset the password for an existing user.
+`
+
+const pathUserPasswordHelpDesc = `
+This endpoint allows an administrator to set a new password for an
+existing user without changing its policies or TTLs. The current
+password is not required.
+`
+
+const pathPasswordHelpSyn = `
+Change your own password.
+`
+
+const pathPasswordHelpDesc = `
+This endpoint allows a user to change their own password by supplying
+the current password as "old_password" along with the new "password".
+It does not require a token.
+
+To prevent this endpoint from being used to guess passwords, repeated
+failures to verify the old password for a user cause further attempts
+for that user to be refused for a period of time.
+`
@@ -1,6 +1,7 @@
 package userpass
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"strings"
 	"time"
@@ -152,6 +153,17 @@ type UserEntry struct {
 	MaxTTL time.Duration
 }
 
+// CheckPassword returns whether the given password matches the one stored
+// for the user. Check for a hash collision for Vault 0.2+, but handle the
+// older legacy passwords with a constant time comparison.
+func (u *UserEntry) CheckPassword(password string) bool {
+	passwordBytes := []byte(password)
+	if u.PasswordHash != nil {
+		return bcrypt.CompareHashAndPassword(u.PasswordHash, passwordBytes) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(u.Password), passwordBytes) == 1
+}
+
 const pathUserHelpSyn = `
 Manage users allowed to authenticate.
 `
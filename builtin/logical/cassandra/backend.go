@@ -5,7 +5,7 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/gocql/gocql"
+	"github.com/freimer/gocql"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -60,6 +60,10 @@ type sessionConfig struct {
 	Certificate string `json:"certificate" structs:"certificate"`
 	PrivateKey  string `json:"private_key" structs:"private_key"`
 	IssuingCA   string `json:"issuing_ca" structs:"issuing_ca"`
+
+	// Dialer settings, in seconds. Zero uses the gocql defaults.
+	ConnectTimeout  int `json:"connect_timeout" structs:"connect_timeout"`
+	SocketKeepAlive int `json:"socket_keep_alive" structs:"socket_keep_alive"`
 }
 
 // DB returns the database connection.
@@ -86,7 +90,13 @@ func (b *backend) DB(s logical.Storage) (*gocql.Session, error) {
 		return nil, err
 	}
 
-	return createSession(config, s)
+	session, err := createSession(config, s, &hostStateListener{b: b})
+	if err != nil {
+		return nil, err
+	}
+
+	b.session = session
+	return session, nil
 }
 
 // ResetDB forces a connection next time DB() is called.
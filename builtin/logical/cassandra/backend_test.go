@@ -19,6 +19,7 @@ func TestBackend_basic(t *testing.T) {
 		Backend:  b,
 		Steps: []logicaltest.TestStep{
 			testAccStepConfig(t),
+			testAccStepReadConfig(t),
 			testAccStepRole(t),
 			testAccStepReadCreds(t, "test"),
 		},
@@ -59,6 +60,33 @@ func testAccStepConfig(t *testing.T) logicaltest.TestStep {
 	}
 }
 
+func testAccStepReadConfig(t *testing.T) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "config/connection",
+		Check: func(resp *logical.Response) error {
+			var d struct {
+				HostStates map[string]interface{} `mapstructure:"host_states"`
+				HostsUp    int                    `mapstructure:"hosts_up"`
+				HostsDown  int                    `mapstructure:"hosts_down"`
+				Password   string                 `mapstructure:"password"`
+			}
+			if err := mapstructure.Decode(resp.Data, &d); err != nil {
+				return err
+			}
+
+			if d.Password != "**********" {
+				return fmt.Errorf("password not masked: %#v", resp.Data)
+			}
+			if d.HostsUp == 0 || d.HostsDown != 0 || len(d.HostStates) != d.HostsUp {
+				return fmt.Errorf("bad: %#v", resp.Data)
+			}
+
+			return nil
+		},
+	}
+}
+
 func testAccStepRole(t *testing.T) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.UpdateOperation,
@@ -55,6 +55,19 @@ backend can be directly passed into this parameter.
 If both this and "pem_bundle" are specified, this will
 take precedence.`,
 			},
+
+			"connect_timeout": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The connection timeout to use when dialing
+Cassandra hosts. If not set, the query timeout of
+600 milliseconds is used.`,
+			},
+
+			"socket_keep_alive": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The TCP keepalive period to use for connections
+to Cassandra hosts. Disabled if zero, which is the default.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -87,9 +100,26 @@ func (b *backend) pathConnectionRead(
 		config.PrivateKey = "**********"
 	}
 
-	return &logical.Response{
+	resp := &logical.Response{
 		Data: structs.New(config).Map(),
-	}, nil
+	}
+
+	// Report the state of the connection to the cluster as seen by the
+	// driver. This connects if no request has needed to yet.
+	session, err := b.DB(req.Storage)
+	if err != nil {
+		resp.Data["connection_error"] = err.Error()
+		resp.AddWarning("Unable to connect to the cluster, see connection_error")
+		return resp, nil
+	}
+	states, up, down := hostStates(session)
+	resp.Data["host_states"] = states
+	resp.Data["hosts_up"] = up
+	resp.Data["hosts_down"] = down
+	if down > 0 {
+		resp.AddWarning(fmt.Sprintf("%d of %d hosts are down", down, up+down))
+	}
+	return resp, nil
 }
 
 func (b *backend) pathConnectionWrite(
@@ -113,6 +143,16 @@ func (b *backend) pathConnectionWrite(
 		Password:    password,
 		TLS:         data.Get("tls").(bool),
 		InsecureTLS: data.Get("insecure_tls").(bool),
+
+		ConnectTimeout:  data.Get("connect_timeout").(int),
+		SocketKeepAlive: data.Get("socket_keep_alive").(int),
+	}
+
+	switch {
+	case config.ConnectTimeout < 0:
+		return logical.ErrorResponse("connect_timeout cannot be negative"), nil
+	case config.SocketKeepAlive < 0:
+		return logical.ErrorResponse("socket_keep_alive cannot be negative"), nil
 	}
 
 	if config.InsecureTLS {
@@ -156,7 +196,7 @@ func (b *backend) pathConnectionWrite(
 		config.TLS = true
 	}
 
-	session, err := createSession(config, req.Storage)
+	session, err := createSession(config, req.Storage, &hostStateListener{b: b})
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
@@ -204,6 +244,15 @@ TLS works as follows:
 
 "pem_bundle" should be a PEM-concatenated bundle of a private key + client certificate, an issuing CA certificate, or both. "pem_json" should contain the same information; for convenience, the JSON format is the same as that output by the issue command from the PKI backend.
 
+"connect_timeout" and "socket_keep_alive" control how connections to the
+Cassandra hosts are dialed. Both accept a number of seconds or a duration
+string such as "10s".
+
+Reading this path also returns the state of each host of the cluster as last
+seen by the driver in "host_states", along with the number of hosts that are
+up and down. Hosts are marked down when the cluster reports them as such or
+when no connection to them can be made.
+
 When configuring the connection information, the backend will verify its
 validity.
 `
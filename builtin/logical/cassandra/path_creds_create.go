@@ -39,7 +39,7 @@ func (b *backend) pathCredsCreateRead(
 		return nil, err
 	}
 	if role == nil {
-		return logical.ErrorResponse(fmt.Sprintf("Unknown role: %s", name)), nil
+		return framework.NotFound(fmt.Sprintf("Unknown role: %s", name))
 	}
 
 	displayName := req.DisplayName
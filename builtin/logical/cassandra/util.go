@@ -3,9 +3,13 @@ package cassandra
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/gocql/gocql"
+	"github.com/armon/go-metrics"
+	"github.com/freimer/gocql"
 	"github.com/hashicorp/vault/helper/certutil"
 	"github.com/hashicorp/vault/logical"
 )
@@ -32,13 +36,30 @@ func substQuery(tpl string, data map[string]string) string {
 	return tpl
 }
 
-func createSession(cfg *sessionConfig, s logical.Storage) (*gocql.Session, error) {
+func createSession(cfg *sessionConfig, s logical.Storage, listener gocql.HostStateListener) (*gocql.Session, error) {
 	clusterConfig := gocql.NewCluster(strings.Split(cfg.Hosts, ",")...)
+	clusterConfig.HostStateListener = listener
 	clusterConfig.Authenticator = gocql.PasswordAuthenticator{
 		Username: cfg.Username,
 		Password: cfg.Password,
 	}
 
+	if cfg.ConnectTimeout > 0 {
+		clusterConfig.ConnectTimeout = time.Duration(cfg.ConnectTimeout) * time.Second
+	}
+	if cfg.SocketKeepAlive > 0 {
+		clusterConfig.SocketKeepalive = time.Duration(cfg.SocketKeepAlive) * time.Second
+	}
+
+	// Retry statements that fail on a single host. Creating and dropping
+	// users is not idempotent, so those are only retried if they were
+	// not applied.
+	clusterConfig.RetryPolicy = &gocql.IdempotentRetryPolicy{
+		NumRetries: 3,
+		Min:        100 * time.Millisecond,
+		Max:        time.Second,
+	}
+
 	if cfg.TLS {
 		tlsConfig := &tls.Config{
 			InsecureSkipVerify: cfg.InsecureTLS,
@@ -81,10 +102,63 @@ func createSession(cfg *sessionConfig, s logical.Storage) (*gocql.Session, error
 	}
 
 	// Verify the info
-	err = session.Query(`LIST USERS`).Exec()
+	err = session.Query(`LIST USERS`).Idempotent(true).Exec()
 	if err != nil {
 		return nil, fmt.Errorf("Error validating connection info: %s", err)
 	}
 
 	return session, nil
 }
+
+// hostStateListener logs the hosts that gocql marks up or down and keeps
+// a gauge of their state, so that degraded connectivity to the cluster
+// shows up before credential requests start failing.
+type hostStateListener struct {
+	b *backend
+}
+
+func (l *hostStateListener) HostUp(host *gocql.HostInfo) {
+	emitHostState(host)
+	l.b.Logger().Printf("[INFO] cassandra: host %s is up", hostAddr(host))
+}
+
+func (l *hostStateListener) HostDown(host *gocql.HostInfo) {
+	emitHostState(host)
+	metrics.IncrCounter([]string{"cassandra", "host", "down"}, 1)
+	l.b.Logger().Printf("[WARN] cassandra: host %s is down", hostAddr(host))
+}
+
+// emitHostState sets the gauge of a host to 1 if it is up and 0 if not
+func emitHostState(host *gocql.HostInfo) {
+	var up float32
+	if host.IsUp() {
+		up = 1
+	}
+	name := strings.NewReplacer(".", "-", ":", "-").Replace(hostAddr(host))
+	metrics.SetGauge([]string{"cassandra", "host", name, "up"}, up)
+}
+
+// hostStates returns the last known state of the hosts of the session,
+// keyed by address, along with the number of hosts that are up and down
+func hostStates(session *gocql.Session) (map[string]interface{}, int, int) {
+	states := make(map[string]interface{})
+	var up, down int
+	for _, host := range session.Hosts() {
+		emitHostState(host)
+		if host.IsUp() {
+			up++
+		} else {
+			down++
+		}
+		states[hostAddr(host)] = map[string]interface{}{
+			"state":       strings.ToLower(host.State().String()),
+			"data_center": host.DataCenter(),
+			"rack":        host.Rack(),
+		}
+	}
+	return states, up, down
+}
+
+func hostAddr(host *gocql.HostInfo) string {
+	return net.JoinHostPort(host.Peer(), strconv.Itoa(host.Port()))
+}
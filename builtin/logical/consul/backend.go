@@ -1,6 +1,9 @@
 package consul
 
 import (
+	"sync"
+	"time"
+
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -20,6 +23,7 @@ func Backend() *framework.Backend {
 
 		Paths: []*framework.Path{
 			pathConfigAccess(),
+			pathConfigSweep(&b),
 			pathRoles(),
 			pathToken(&b),
 		},
@@ -27,6 +31,8 @@ func Backend() *framework.Backend {
 		Secrets: []*framework.Secret{
 			secretToken(),
 		},
+
+		PeriodicFunc: b.periodicFunc,
 	}
 
 	return b.Backend
@@ -34,4 +40,9 @@ func Backend() *framework.Backend {
 
 type backend struct {
 	*framework.Backend
+
+	// l protects the fields below, which are used by the token sweep
+	l         sync.Mutex
+	id        string
+	lastSweep time.Time
 }
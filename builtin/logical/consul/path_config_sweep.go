@@ -0,0 +1,133 @@
+package consul
+
+import (
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// DefaultSweepInterval is how often tokens are reconciled against
+	// Consul when the sweep is enabled.
+	DefaultSweepInterval = 1 * time.Hour
+
+	// DefaultSweepGracePeriod is how long past the end of its lease a
+	// token is left alone, giving the normal revocation a chance to run.
+	DefaultSweepGracePeriod = 1 * time.Hour
+)
+
+func pathConfigSweep(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/sweep",
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Whether to periodically delete orphaned Consul tokens",
+			},
+
+			"interval": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "How often to reconcile tokens against Consul",
+				Default:     int(DefaultSweepInterval.Seconds()),
+			},
+
+			"grace_period": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "How long after its lease ends a token is kept",
+				Default:     int(DefaultSweepGracePeriod.Seconds()),
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigSweepRead,
+			logical.UpdateOperation: b.pathConfigSweepWrite,
+		},
+
+		HelpSynopsis:    pathConfigSweepHelpSyn,
+		HelpDescription: pathConfigSweepHelpDesc,
+	}
+}
+
+func (b *backend) pathConfigSweepRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := sweepConfig(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":      conf.Enabled,
+			"interval":     int64(conf.Interval.Seconds()),
+			"grace_period": int64(conf.GracePeriod.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigSweepWrite(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf := &sweepConfigEntry{
+		Enabled:     data.Get("enabled").(bool),
+		Interval:    time.Duration(data.Get("interval").(int)) * time.Second,
+		GracePeriod: time.Duration(data.Get("grace_period").(int)) * time.Second,
+	}
+	if conf.Interval <= 0 {
+		return logical.ErrorResponse("interval must be greater than 0"), nil
+	}
+	if conf.GracePeriod < 0 {
+		return logical.ErrorResponse("grace_period cannot be negative"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config/sweep", conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// sweepConfig returns the sweep configuration, or the defaults with the
+// sweep disabled if it hasn't been configured.
+func sweepConfig(s logical.Storage) (*sweepConfigEntry, error) {
+	conf := &sweepConfigEntry{
+		Interval:    DefaultSweepInterval,
+		GracePeriod: DefaultSweepGracePeriod,
+	}
+
+	entry, err := s.Get("config/sweep")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return conf, nil
+	}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+type sweepConfigEntry struct {
+	Enabled     bool          `json:"enabled"`
+	Interval    time.Duration `json:"interval"`
+	GracePeriod time.Duration `json:"grace_period"`
+}
+
+const pathConfigSweepHelpSyn = `
+Configure the removal of Consul tokens whose leases are gone.
+`
+
+const pathConfigSweepHelpDesc = `
+If revoking a lease fails, for example because Consul was unreachable,
+the token may be left behind in Consul after the lease has gone. When
+the sweep is enabled, the backend periodically lists the ACL tokens in
+Consul and deletes those that it created but no longer has a lease for.
+
+Only tokens created by this mount since the sweep was added are
+considered. Tokens are kept until "grace_period" after their lease
+would have expired, so that normal revocation has a chance to run first.
+`
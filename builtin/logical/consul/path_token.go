@@ -52,11 +52,18 @@ func (b *backend) pathTokenRead(
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
+	// The name identifies this mount so the token can be found by the
+	// sweep if revoking it fails
+	id, err := b.instanceID(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate a random name for the token
-	tokenName := fmt.Sprintf("Vault %s %d", req.DisplayName, time.Now().Unix())
+	now := time.Now()
 	// Create it
 	token, _, err := c.ACL().Create(&api.ACLEntry{
-		Name:  tokenName,
+		Name:  tokenName(id, req.DisplayName, now),
 		Type:  result.TokenType,
 		Rules: result.Policy,
 	}, nil)
@@ -67,6 +74,11 @@ func (b *backend) pathTokenRead(
 	// Use the helper to create the secret
 	s := b.Secret(SecretTokenType)
 	s.DefaultDuration = result.Lease
+
+	// Record the token until its lease ends
+	if err := putTokenEntry(req.Storage, token, now.Add(s.DefaultDuration)); err != nil {
+		return nil, err
+	}
 	return s.Response(map[string]interface{}{
 		"token": token,
 	}, nil), nil
@@ -26,11 +26,27 @@ func secretToken() *framework.Secret {
 		DefaultDuration:    DefaultLeaseDuration,
 		DefaultGracePeriod: DefaultGracePeriod,
 
-		Renew:  framework.LeaseExtend(0, 0, true),
+		Renew:  secretTokenRenew,
 		Revoke: secretTokenRevoke,
 	}
 }
 
+func secretTokenRenew(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	resp, err := framework.LeaseExtend(0, 0, true)(req, d)
+	if err != nil || resp == nil || resp.IsError() {
+		return resp, err
+	}
+
+	// Keep the sweep from treating the token as orphaned
+	expires := time.Now().Add(resp.Secret.TTL)
+	if err := putTokenEntry(req.Storage, d.Get("token").(string), expires); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func secretTokenRevoke(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	c, err := client(req.Storage)
@@ -38,10 +54,15 @@ func secretTokenRevoke(
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	_, err = c.ACL().Destroy(d.Get("token").(string), nil)
+	token := d.Get("token").(string)
+	_, err = c.ACL().Destroy(token, nil)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
+	if err := req.Storage.Delete(tokenKey(token)); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
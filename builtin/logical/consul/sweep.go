@@ -0,0 +1,219 @@
+package consul
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+)
+
+// Tokens created by this backend are named "Vault <id> <display name>
+// <unix time>", where id identifies the mount so that the sweep never
+// touches tokens created by another mount or by hand.
+
+// tokenEntry records a token issued by the backend, keyed by a hash of
+// the token, for as long as its lease is expected to exist.
+type tokenEntry struct {
+	Expires time.Time `json:"expires"`
+}
+
+// instanceID returns the identifier used in the names of tokens created
+// by this mount, generating it on first use.
+func (b *backend) instanceID(s logical.Storage) (string, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if b.id != "" {
+		return b.id, nil
+	}
+
+	entry, err := s.Get("sweep/id")
+	if err != nil {
+		return "", err
+	}
+	if entry != nil {
+		b.id = string(entry.Value)
+		return b.id, nil
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+	if err := s.Put(&logical.StorageEntry{Key: "sweep/id", Value: []byte(id)}); err != nil {
+		return "", err
+	}
+	b.id = id
+	return id, nil
+}
+
+// tokenName returns the name for a new token created by this mount.
+func tokenName(id, displayName string, now time.Time) string {
+	return fmt.Sprintf("Vault %s %s %d", id, displayName, now.Unix())
+}
+
+// parseTokenName returns the creation time of a token if it was created
+// by the mount with the given id.
+func parseTokenName(id, name string) (time.Time, bool) {
+	prefix := fmt.Sprintf("Vault %s ", id)
+	if !strings.HasPrefix(name, prefix) {
+		return time.Time{}, false
+	}
+
+	idx := strings.LastIndex(name, " ")
+	created, err := strconv.ParseInt(name[idx+1:], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(created, 0), true
+}
+
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token/" + hex.EncodeToString(sum[:])
+}
+
+// putTokenEntry records that the token's lease lasts until expires.
+func putTokenEntry(s logical.Storage, token string, expires time.Time) error {
+	entry, err := logical.StorageEntryJSON(tokenKey(token), &tokenEntry{
+		Expires: expires.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.Put(entry)
+}
+
+// aclClient is the subset of the Consul ACL API used by the sweep.
+type aclClient interface {
+	List(*api.QueryOptions) ([]*api.ACLEntry, *api.QueryMeta, error)
+	Destroy(string, *api.WriteOptions) (*api.WriteMeta, error)
+}
+
+// periodicFunc deletes orphaned tokens from Consul if the sweep is enabled
+// and it has not run within the configured interval.
+func (b *backend) periodicFunc(req *logical.Request) error {
+	conf, err := sweepConfig(req.Storage)
+	if err != nil {
+		return err
+	}
+	if !conf.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	b.l.Lock()
+	due := now.Sub(b.lastSweep) >= conf.Interval
+	if due {
+		b.lastSweep = now
+	}
+	b.l.Unlock()
+	if !due {
+		return nil
+	}
+
+	c, err := client(req.Storage)
+	if err != nil {
+		return err
+	}
+	return b.sweep(req.Storage, c.ACL(), conf.GracePeriod, now)
+}
+
+// sweep destroys the tokens created by this mount that have no record,
+// or whose record expired more than grace ago, and removes expired
+// records whose tokens are already gone.
+func (b *backend) sweep(
+	s logical.Storage, acl aclClient, grace time.Duration, now time.Time) error {
+	id, err := b.instanceID(s)
+	if err != nil {
+		return err
+	}
+
+	tokens, _, err := acl.List(nil)
+	if err != nil {
+		return fmt.Errorf("error listing tokens: %s", err)
+	}
+
+	var merr error
+	seen := make(map[string]struct{})
+	for _, t := range tokens {
+		created, ok := parseTokenName(id, t.Name)
+		if !ok {
+			continue
+		}
+
+		key := tokenKey(t.ID)
+		seen[key] = struct{}{}
+
+		// Leave tokens that were just created, since the record is only
+		// written after the token exists.
+		if now.Sub(created) < grace {
+			continue
+		}
+
+		entry, err := s.Get(key)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		if entry != nil {
+			var te tokenEntry
+			if err := entry.DecodeJSON(&te); err != nil {
+				merr = multierror.Append(merr, err)
+				continue
+			}
+			if now.Before(te.Expires.Add(grace)) {
+				continue
+			}
+		}
+
+		if _, err := acl.Destroy(t.ID, nil); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf(
+				"error deleting token %q: %s", t.Name, err))
+			continue
+		}
+		if err := s.Delete(key); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	keys, err := s.List("token/")
+	if err != nil {
+		return multierror.Append(merr, err)
+	}
+	for _, k := range keys {
+		key := "token/" + strings.TrimPrefix(k, "token/")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		entry, err := s.Get(key)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+		var te tokenEntry
+		if err := entry.DecodeJSON(&te); err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		if now.Before(te.Expires.Add(grace)) {
+			continue
+		}
+		if err := s.Delete(key); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	return merr
+}
@@ -0,0 +1,93 @@
+package consul
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/vault/logical"
+)
+
+type testACL struct {
+	tokens    []*api.ACLEntry
+	destroyed []string
+}
+
+func (a *testACL) List(*api.QueryOptions) ([]*api.ACLEntry, *api.QueryMeta, error) {
+	return a.tokens, nil, nil
+}
+
+func (a *testACL) Destroy(id string, _ *api.WriteOptions) (*api.WriteMeta, error) {
+	a.destroyed = append(a.destroyed, id)
+	return nil, nil
+}
+
+func TestParseTokenName(t *testing.T) {
+	now := time.Unix(1450000000, 0)
+	name := tokenName("abc", "token-foo", now)
+
+	created, ok := parseTokenName("abc", name)
+	if !ok || !created.Equal(now) {
+		t.Fatalf("bad: %v %v", created, ok)
+	}
+	if _, ok := parseTokenName("def", name); ok {
+		t.Fatalf("bad: matched another mount")
+	}
+	if _, ok := parseTokenName("abc", "Vault token-foo 1450000000"); ok {
+		t.Fatalf("bad: matched an old token name")
+	}
+}
+
+func TestBackend_sweep(t *testing.T) {
+	b := &backend{}
+	storage := new(logical.InmemStorage)
+	id, err := b.instanceID(storage)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	now := time.Now()
+	grace := time.Hour
+	old := now.Add(-2 * grace)
+	acl := &testACL{
+		tokens: []*api.ACLEntry{
+			// Live lease
+			{ID: "live", Name: tokenName(id, "root", old)},
+			// Lease expired long ago
+			{ID: "expired", Name: tokenName(id, "root", old)},
+			// No lease at all
+			{ID: "orphan", Name: tokenName(id, "root", old)},
+			// Just created, record may not exist yet
+			{ID: "new", Name: tokenName(id, "root", now)},
+			// Not created by this mount
+			{ID: "other", Name: tokenName("other", "root", old)},
+			{ID: "anonymous", Name: "Anonymous Token"},
+		},
+	}
+	if err := putTokenEntry(storage, "live", now.Add(time.Minute)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := putTokenEntry(storage, "expired", old); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := putTokenEntry(storage, "gone", old); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := b.sweep(storage, acl, grace, now); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(acl.destroyed) != 2 || acl.destroyed[0] != "expired" || acl.destroyed[1] != "orphan" {
+		t.Fatalf("bad: %#v", acl.destroyed)
+	}
+
+	keys, err := storage.List("token/")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(keys) != 1 || !strings.HasSuffix(tokenKey("live"), keys[0]) {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
@@ -27,6 +27,7 @@ func Backend() *framework.Backend {
 				"ca",
 				"crl/pem",
 				"crl",
+				"crl/bundle",
 			},
 		},
 
@@ -39,24 +40,36 @@ func Backend() *framework.Backend {
 			pathConfigCA(&b),
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
+			pathConfigIssuance(&b),
+			pathConfigKMS(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
 			pathRotateCRL(&b),
 			pathFetchCA(&b),
+			pathCAExpiry(&b),
 			pathFetchCRL(&b),
 			pathFetchCRLViaCertPath(&b),
+			pathFetchCRLBundle(&b),
+			pathListExternalCRLs(&b),
+			pathExternalCRL(&b),
 			pathFetchValid(&b),
 			pathRevoke(&b),
+			pathListCertMetadata(&b),
+			pathSearchCertMetadata(&b),
+			pathFetchCertMetadata(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretCerts(&b),
 		},
+
+		PeriodicFunc: b.periodicFunc,
 	}
 
 	b.crlLifetime = time.Hour * 72
 	b.revokeStorageLock = &sync.Mutex{}
+	b.kmsSignerLock = &sync.Mutex{}
 
 	return b.Backend
 }
@@ -66,6 +79,10 @@ type backend struct {
 
 	crlLifetime       time.Duration
 	revokeStorageLock *sync.Mutex
+
+	// kmsSigner caches the signer for a CA key held in the KMS
+	kmsSigner     *kmsSigner
+	kmsSignerLock *sync.Mutex
 }
 
 const backendHelp = `
@@ -8,10 +8,12 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"math"
+	"math/big"
 	mathrand "math/rand"
 	"net"
 	"os"
@@ -147,6 +149,984 @@ func TestBackend_URLsCRUD(t *testing.T) {
 	logicaltest.Test(t, testCase)
 }
 
+// Tests storing metadata with issued certificates and searching on it
+func TestBackend_CertMetadata(t *testing.T) {
+	defaultLeaseTTLVal := time.Hour * 24
+	maxLeaseTTLVal := time.Hour * 24 * 30
+	b, err := Factory(&logical.BackendConfig{
+		Logger: nil,
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: defaultLeaseTTLVal,
+			MaxLeaseTTLVal:     maxLeaseTTLVal,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	var serial string
+	testCase := logicaltest.TestCase{
+		Backend: b,
+		Steps: []logicaltest.TestStep{
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "root/generate/internal",
+				Data: map[string]interface{}{
+					"common_name": "Root Cert",
+					"ttl":         "180h",
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "roles/web",
+				Data: map[string]interface{}{
+					"allowed_domains":       "example.com",
+					"allow_subdomains":      true,
+					"allowed_metadata_keys": "owner,ticket_id",
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "roles/plain",
+				Data: map[string]interface{}{
+					"allowed_domains":  "example.com",
+					"allow_subdomains": true,
+				},
+			},
+
+			// Keys not allowed by the role are rejected
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "issue/web",
+				Data: map[string]interface{}{
+					"common_name": "a.example.com",
+					"metadata": map[string]interface{}{
+						"cost_center": "42",
+					},
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					if !resp.IsError() {
+						return fmt.Errorf("expected an error response")
+					}
+					return nil
+				},
+			},
+
+			// Roles without allowed keys do not accept metadata at all
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "issue/plain",
+				Data: map[string]interface{}{
+					"common_name": "a.example.com",
+					"metadata": map[string]interface{}{
+						"owner": "ops",
+					},
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					if !resp.IsError() {
+						return fmt.Errorf("expected an error response")
+					}
+					return nil
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "issue/web",
+				Data: map[string]interface{}{
+					"common_name": "a.example.com",
+					"metadata": map[string]interface{}{
+						"owner":     "ops",
+						"ticket_id": 1234,
+					},
+				},
+				Check: func(resp *logical.Response) error {
+					serial = resp.Data["serial_number"].(string)
+					return nil
+				},
+			},
+
+			// Certificates issued without metadata are not tracked
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "issue/web",
+				Data: map[string]interface{}{
+					"common_name": "b.example.com",
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.ListOperation,
+				Path:      "cert-metadata/",
+				Check: func(resp *logical.Response) error {
+					keys := resp.Data["keys"].([]string)
+					if len(keys) != 1 || keys[0] != serial {
+						return fmt.Errorf("bad: %#v", keys)
+					}
+					return nil
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "cert-metadata/search",
+				Data: map[string]interface{}{
+					"role": "web",
+					"metadata": map[string]interface{}{
+						"owner": "ops",
+					},
+				},
+				Check: func(resp *logical.Response) error {
+					keys := resp.Data["keys"].([]string)
+					if len(keys) != 1 || keys[0] != serial {
+						return fmt.Errorf("bad: %#v", keys)
+					}
+
+					var entry certMetadataEntry
+					certs := resp.Data["certificates"].(map[string]interface{})
+					if err := mapstructure.Decode(certs[serial], &entry); err != nil {
+						return err
+					}
+					expected := map[string]string{
+						"owner":     "ops",
+						"ticket_id": "1234",
+					}
+					if entry.Role != "web" || entry.CommonName != "a.example.com" || !reflect.DeepEqual(entry.Metadata, expected) {
+						return fmt.Errorf("bad: %#v", entry)
+					}
+					return nil
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "cert-metadata/search",
+				Data: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"owner": "dev",
+					},
+				},
+				Check: func(resp *logical.Response) error {
+					keys := resp.Data["keys"].([]string)
+					if len(keys) != 0 {
+						return fmt.Errorf("bad: %#v", keys)
+					}
+					return nil
+				},
+			},
+		},
+	}
+
+	logicaltest.Test(t, testCase)
+}
+
+func TestBackend_CAExpiry(t *testing.T) {
+	defaultLeaseTTLVal := time.Hour * 24
+	maxLeaseTTLVal := time.Hour * 24 * 30
+	b, err := Factory(&logical.BackendConfig{
+		Logger: nil,
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: defaultLeaseTTLVal,
+			MaxLeaseTTLVal:     maxLeaseTTLVal,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	issueStep := func(ttl string, expectError bool, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+		return logicaltest.TestStep{
+			Operation: logical.UpdateOperation,
+			Path:      "issue/web",
+			Data: map[string]interface{}{
+				"common_name": "a.example.com",
+				"ttl":         ttl,
+			},
+			ErrorOk: expectError,
+			Check:   check,
+		}
+	}
+
+	caExpiry := time.Now().Add(10 * time.Hour)
+	testCase := logicaltest.TestCase{
+		Backend: b,
+		Steps: []logicaltest.TestStep{
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "root/generate/internal",
+				Data: map[string]interface{}{
+					"common_name": "Root Cert",
+					"ttl":         "10h",
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "roles/web",
+				Data: map[string]interface{}{
+					"allowed_domains":  "example.com",
+					"allow_subdomains": true,
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "ca/expiry",
+				Check: func(resp *logical.Response) error {
+					ttl := resp.Data["ttl"].(int64)
+					if ttl <= 0 || ttl > int64((10*time.Hour).Seconds()) {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+					if resp.Data["expired"].(bool) || !resp.Data["expiring"].(bool) {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+					return nil
+				},
+			},
+
+			// Certificates outliving the CA are refused by default
+			issueStep("20h", true, func(resp *logical.Response) error {
+				if !resp.IsError() {
+					return fmt.Errorf("expected an error response")
+				}
+				return nil
+			}),
+
+			// Certificates within the CA's lifetime only warn that the CA
+			// expires soon
+			issueStep("1h", false, func(resp *logical.Response) error {
+				if warnings := resp.Warnings(); len(warnings) != 1 {
+					return fmt.Errorf("bad: %#v", warnings)
+				}
+				return nil
+			}),
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "config/issuance",
+				Data: map[string]interface{}{
+					"ca_expiry_policy":  "truncate",
+					"ca_expiry_warning": "1h",
+				},
+			},
+
+			issueStep("20h", false, func(resp *logical.Response) error {
+				if warnings := resp.Warnings(); len(warnings) != 1 {
+					return fmt.Errorf("bad: %#v", warnings)
+				}
+				if resp.Secret.TTL > caExpiry.Sub(time.Now())+time.Minute {
+					return fmt.Errorf("bad: ttl %s", resp.Secret.TTL)
+				}
+				return nil
+			}),
+
+			issueStep("1h", false, func(resp *logical.Response) error {
+				if warnings := resp.Warnings(); len(warnings) != 0 {
+					return fmt.Errorf("bad: %#v", warnings)
+				}
+				return nil
+			}),
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "config/issuance",
+				Data: map[string]interface{}{
+					"ca_expiry_policy": "ignore",
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					if !resp.IsError() {
+						return fmt.Errorf("expected an error response")
+					}
+					return nil
+				},
+			},
+		},
+	}
+
+	logicaltest.Test(t, testCase)
+}
+
+func TestBackend_ExternalCRL(t *testing.T) {
+	defaultLeaseTTLVal := time.Hour * 24
+	maxLeaseTTLVal := time.Hour * 24 * 30
+	b, err := Factory(&logical.BackendConfig{
+		Logger: nil,
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: defaultLeaseTTLVal,
+			MaxLeaseTTLVal:     maxLeaseTTLVal,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	// An offline CA unknown to the backend, and a CRL it signed
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Offline Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offlineCA, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlBytes, err := offlineCA.CreateCRL(rand.Reader, key, []pkix.RevokedCertificate{
+		pkix.RevokedCertificate{
+			SerialNumber:   big.NewInt(42),
+			RevocationTime: time.Now(),
+		},
+	}, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlPEM := string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlBytes}))
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}))
+	olderCRLBytes, err := offlineCA.CreateCRL(rand.Reader, key, nil,
+		time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newerCRLBytes, err := offlineCA.CreateCRL(rand.Reader, key, nil,
+		time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError := func(resp *logical.Response) error {
+		if !resp.IsError() {
+			return fmt.Errorf("expected an error response")
+		}
+		return nil
+	}
+
+	testCase := logicaltest.TestCase{
+		Backend: b,
+		Steps: []logicaltest.TestStep{
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "root/generate/internal",
+				Data: map[string]interface{}{
+					"common_name": "Root Cert",
+					"ttl":         "180h",
+				},
+			},
+
+			// The signature cannot be verified without the offline CA
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "crl/external/offline",
+				Data: map[string]interface{}{
+					"crl": crlPEM,
+				},
+				ErrorOk: true,
+				Check: func(resp *logical.Response) error {
+					if !resp.IsError() {
+						return fmt.Errorf("expected an error response")
+					}
+					return nil
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "crl/external/offline",
+				Data: map[string]interface{}{
+					"crl":                base64.StdEncoding.EncodeToString(crlBytes),
+					"issuer_certificate": caPEM,
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "crl/external/offline",
+				Check: func(resp *logical.Response) error {
+					if resp.Data["crl"] != crlPEM {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+					if resp.Data["issuer"] != "CN=Offline Root" || resp.Data["revoked_count"] != 1 {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+					return nil
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.ListOperation,
+				Path:      "crl/external/",
+				Check: func(resp *logical.Response) error {
+					if !reflect.DeepEqual(resp.Data["keys"], []string{"offline"}) {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+					return nil
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "crl/bundle",
+				Check: func(resp *logical.Response) error {
+					rest := resp.Data[logical.HTTPRawBody].([]byte)
+					var crls []*pkix.CertificateList
+					for {
+						var block *pem.Block
+						block, rest = pem.Decode(rest)
+						if block == nil {
+							break
+						}
+						crl, err := x509.ParseDERCRL(block.Bytes)
+						if err != nil {
+							return err
+						}
+						crls = append(crls, crl)
+					}
+					if len(crls) != 2 {
+						return fmt.Errorf("expected 2 CRLs, got %d", len(crls))
+					}
+					if err := offlineCA.CheckCRLSignature(crls[1]); err != nil {
+						return err
+					}
+					return nil
+				},
+			},
+
+			// A CRL may only be replaced by a newer one
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "crl/external/offline",
+				Data: map[string]interface{}{
+					"crl":                base64.StdEncoding.EncodeToString(olderCRLBytes),
+					"issuer_certificate": caPEM,
+				},
+				ErrorOk: true,
+				Check:   expectError,
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "crl/external/offline",
+				Data: map[string]interface{}{
+					"crl":                crlPEM,
+					"issuer_certificate": caPEM,
+				},
+				ErrorOk: true,
+				Check:   expectError,
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "crl/external/offline",
+				Data: map[string]interface{}{
+					"crl":                base64.StdEncoding.EncodeToString(newerCRLBytes),
+					"issuer_certificate": caPEM,
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.DeleteOperation,
+				Path:      "crl/external/offline",
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "crl/external/offline",
+				Check: func(resp *logical.Response) error {
+					if resp != nil {
+						return fmt.Errorf("bad: %#v", resp)
+					}
+					return nil
+				},
+			},
+		},
+	}
+
+	logicaltest.Test(t, testCase)
+}
+
+func TestCheckCRLNewer(t *testing.T) {
+	now := time.Now()
+	newCRL := func(thisUpdate time.Time, number int64) *pkix.CertificateList {
+		crl := &pkix.CertificateList{}
+		crl.TBSCertList.ThisUpdate = thisUpdate
+		if number > 0 {
+			value, err := asn1.Marshal(big.NewInt(number))
+			if err != nil {
+				t.Fatal(err)
+			}
+			crl.TBSCertList.Extensions = []pkix.Extension{
+				pkix.Extension{Id: oidExtensionCRLNumber, Value: value},
+			}
+		}
+		return crl
+	}
+
+	current := newCRL(now, 5)
+	if err := checkCRLNewer(newCRL(now.Add(time.Minute), 6), current); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := checkCRLNewer(newCRL(now.Add(time.Minute), 0), current); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := checkCRLNewer(newCRL(now, 6), current); err == nil {
+		t.Fatalf("expected an error for the same update time")
+	}
+	if err := checkCRLNewer(newCRL(now.Add(time.Minute), 5), current); err == nil {
+		t.Fatalf("expected an error for the same CRL number")
+	}
+}
+
+// testKMSClient holds its keys in memory in place of a real KMS
+type testKMSClient struct {
+	keys           map[string]crypto.Signer
+	deleted        map[string]bool
+	publicKeyCalls int
+}
+
+func (c *testKMSClient) CreateKey(keyType string, keyBits int, description string) (string, error) {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, keyBits)
+	case "ec":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported key type %q", keyType)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	keyID := fmt.Sprintf("key-%d", len(c.keys))
+	c.keys[keyID] = key
+	return keyID, nil
+}
+
+func (c *testKMSClient) PublicKey(keyID string) (crypto.PublicKey, error) {
+	c.publicKeyCalls++
+	key, ok := c.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+	return key.Public(), nil
+}
+
+func (c *testKMSClient) Sign(keyID, keyType string, digest []byte, hash crypto.Hash) ([]byte, error) {
+	key, ok := c.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+	return key.Sign(rand.Reader, digest, hash)
+}
+
+func (c *testKMSClient) ScheduleKeyDeletion(keyID string) error {
+	if _, ok := c.keys[keyID]; !ok {
+		return fmt.Errorf("key %s not found", keyID)
+	}
+	c.deleted[keyID] = true
+	return nil
+}
+
+// testStorage keeps copies of the entries put in it, as the backend
+// reuses some entries after storing them
+type testStorage struct {
+	logical.InmemStorage
+}
+
+func (s *testStorage) Put(entry *logical.StorageEntry) error {
+	copied := *entry
+	return s.InmemStorage.Put(&copied)
+}
+
+// testKMSBackend returns a backend using an in-memory KMS, which is
+// configured with config/kms
+func testKMSBackend(t *testing.T) (logical.Backend, logical.Storage, *testKMSClient) {
+	b, err := Factory(&logical.BackendConfig{
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: time.Hour * 24,
+			MaxLeaseTTLVal:     time.Hour * 24 * 30,
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	kms := &testKMSClient{
+		keys:    map[string]crypto.Signer{},
+		deleted: map[string]bool{},
+	}
+	newKMSClient = func(*kmsConfig) (kmsClient, error) {
+		return kms, nil
+	}
+
+	storage := &testStorage{}
+	req := logical.TestRequest(t, logical.UpdateOperation, "config/kms")
+	req.Storage = storage
+	req.Data["region"] = "us-east-1"
+	if resp, err := b.HandleRequest(req); err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	return b, storage, kms
+}
+
+func TestBackend_KMSSignerCache(t *testing.T) {
+	defer func(f func(*kmsConfig) (kmsClient, error)) {
+		newKMSClient = f
+	}(newKMSClient)
+	b, storage, kms := testKMSBackend(t)
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.Storage = storage
+		req.Data = data
+		resp, err := b.HandleRequest(req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		return resp
+	}
+	issue := func() {
+		request("issue/web", map[string]interface{}{
+			"common_name": "a.example.com",
+			"ttl":         "1h",
+		})
+	}
+
+	request("root/generate/kms", map[string]interface{}{
+		"common_name": "Root Cert",
+	})
+	request("roles/web", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+	})
+
+	// Generating the root built the CRL, which cached the signer, so
+	// issuing makes no calls to the KMS for the public key
+	calls := kms.publicKeyCalls
+	check := func(expected int) {
+		if kms.publicKeyCalls != expected {
+			t.Fatalf("bad: %d public key calls, expected %d", kms.publicKeyCalls, expected)
+		}
+	}
+	issue()
+	issue()
+	check(calls)
+
+	// Changing the configuration replaces the signer
+	request("config/kms", map[string]interface{}{
+		"region": "us-west-2",
+	})
+	issue()
+	issue()
+	check(calls + 1)
+
+	// So does a new CA key, which is fetched once when it is created and
+	// once for the signer
+	request("root/generate/kms", map[string]interface{}{
+		"common_name": "Root Cert",
+	})
+	issue()
+	check(calls + 3)
+}
+
+func TestBackend_KMSKeyDeletedOnError(t *testing.T) {
+	defer func(f func(*kmsConfig) (kmsClient, error)) {
+		newKMSClient = f
+	}(newKMSClient)
+
+	for _, path := range []string{"root/generate/kms", "intermediate/generate/kms"} {
+		b, storage, kms := testKMSBackend(t)
+
+		// The key is created before the IP SANs are found to be invalid
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.Storage = storage
+		req.Data["common_name"] = "Root Cert"
+		req.Data["ip_sans"] = "not-an-ip"
+		resp, err := b.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !resp.IsError() {
+			t.Fatalf("bad: %#v", resp)
+		}
+		if len(kms.keys) != 1 || len(kms.deleted) != 1 {
+			t.Fatalf("%s: bad: keys %#v deleted %#v", path, kms.keys, kms.deleted)
+		}
+
+		// A stored key is kept
+		delete(req.Data, "ip_sans")
+		resp, err = b.HandleRequest(req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		if len(kms.keys) != 2 || len(kms.deleted) != 1 {
+			t.Fatalf("%s: bad: keys %#v deleted %#v", path, kms.keys, kms.deleted)
+		}
+	}
+}
+
+func TestBackend_KMS(t *testing.T) {
+	defaultLeaseTTLVal := time.Hour * 24
+	maxLeaseTTLVal := time.Hour * 24 * 30
+	b, err := Factory(&logical.BackendConfig{
+		Logger: nil,
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: defaultLeaseTTLVal,
+			MaxLeaseTTLVal:     maxLeaseTTLVal,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	kms := &testKMSClient{
+		keys:    map[string]crypto.Signer{},
+		deleted: map[string]bool{},
+	}
+	defer func(f func(*kmsConfig) (kmsClient, error)) {
+		newKMSClient = f
+	}(newKMSClient)
+	newKMSClient = func(*kmsConfig) (kmsClient, error) {
+		return kms, nil
+	}
+
+	parseCert := func(pemCert string) (*x509.Certificate, error) {
+		block, _ := pem.Decode([]byte(pemCert))
+		if block == nil {
+			return nil, fmt.Errorf("unable to decode PEM certificate")
+		}
+		return x509.ParseCertificate(block.Bytes)
+	}
+
+	errorStep := func(op logical.Operation, path string, data map[string]interface{}) logicaltest.TestStep {
+		return logicaltest.TestStep{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			ErrorOk:   true,
+			Check: func(resp *logical.Response) error {
+				if !resp.IsError() {
+					return fmt.Errorf("expected an error response")
+				}
+				return nil
+			},
+		}
+	}
+
+	// Checks that an issued certificate was signed with the key of the
+	// current CA certificate, which only the KMS holds
+	var caCert *x509.Certificate
+	issueStep := logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "issue/web",
+		Data: map[string]interface{}{
+			"common_name": "a.example.com",
+			"ttl":         "1h",
+		},
+		Check: func(resp *logical.Response) error {
+			cert, err := parseCert(resp.Data["certificate"].(string))
+			if err != nil {
+				return err
+			}
+			if err := cert.CheckSignatureFrom(caCert); err != nil {
+				return err
+			}
+			if resp.Data["private_key"] == nil {
+				return fmt.Errorf("bad: %#v", resp.Data)
+			}
+			return nil
+		},
+	}
+
+	setSignedData := map[string]interface{}{}
+	testCase := logicaltest.TestCase{
+		Backend: b,
+		Steps: []logicaltest.TestStep{
+			errorStep(logical.UpdateOperation, "root/generate/kms", map[string]interface{}{
+				"common_name": "Root Cert",
+			}),
+
+			errorStep(logical.UpdateOperation, "config/kms", map[string]interface{}{
+				"region":     "us-east-1",
+				"secret_key": "secret",
+			}),
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "config/kms",
+				Data: map[string]interface{}{
+					"region":     "us-east-1",
+					"access_key": "access",
+					"secret_key": "secret",
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "config/kms",
+				Check: func(resp *logical.Response) error {
+					if resp.Data["provider"] != "awskms" || resp.Data["access_key"] != "access" {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+					if _, ok := resp.Data["secret_key"]; ok {
+						return fmt.Errorf("secret key returned: %#v", resp.Data)
+					}
+					return nil
+				},
+			},
+
+			errorStep(logical.UpdateOperation, "root/generate/kms", map[string]interface{}{
+				"common_name": "Root Cert",
+				"key_bits":    1024,
+			}),
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "root/generate/kms",
+				Data: map[string]interface{}{
+					"common_name": "Root Cert",
+					"key_type":    "ec",
+					"key_bits":    256,
+				},
+				Check: func(resp *logical.Response) error {
+					if _, ok := resp.Data["private_key"]; ok {
+						return fmt.Errorf("private key returned: %#v", resp.Data)
+					}
+					keyID, _ := resp.Data["kms_key_id"].(string)
+					key, ok := kms.keys[keyID]
+					if !ok {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+
+					cert, err := parseCert(resp.Data["certificate"].(string))
+					if err != nil {
+						return err
+					}
+					equal, err := certutil.ComparePublicKeys(key.Public(), cert.PublicKey)
+					if err != nil {
+						return err
+					}
+					if !equal {
+						return fmt.Errorf("certificate does not use the KMS key")
+					}
+					caCert = cert
+					return nil
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "roles/web",
+				Data: map[string]interface{}{
+					"allowed_domains":  "example.com",
+					"allow_subdomains": true,
+				},
+			},
+
+			issueStep,
+
+			logicaltest.TestStep{
+				Operation: logical.ReadOperation,
+				Path:      "crl/rotate",
+			},
+
+			// The configuration is needed as long as the CA key is in
+			// the KMS
+			errorStep(logical.DeleteOperation, "config/kms", nil),
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "intermediate/generate/kms",
+				Data: map[string]interface{}{
+					"common_name": "Intermediate Cert",
+				},
+				Check: func(resp *logical.Response) error {
+					if _, ok := resp.Data["private_key"]; ok {
+						return fmt.Errorf("private key returned: %#v", resp.Data)
+					}
+					if _, ok := kms.keys[resp.Data["kms_key_id"].(string)]; !ok {
+						return fmt.Errorf("bad: %#v", resp.Data)
+					}
+
+					// Sign the CSR with an external root
+					block, _ := pem.Decode([]byte(resp.Data["csr"].(string)))
+					if block == nil {
+						return fmt.Errorf("unable to decode CSR")
+					}
+					csr, err := x509.ParseCertificateRequest(block.Bytes)
+					if err != nil {
+						return err
+					}
+					bundle, err := certutil.ParsePEMBundle(rsaCAKey + rsaCACert)
+					if err != nil {
+						return err
+					}
+					template := &x509.Certificate{
+						SerialNumber:          big.NewInt(mathrand.Int63()),
+						Subject:               csr.Subject,
+						NotBefore:             time.Now().Add(-30 * time.Second),
+						NotAfter:              time.Now().Add(10 * time.Hour),
+						KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+						BasicConstraintsValid: true,
+						IsCA:                  true,
+					}
+					certBytes, err := x509.CreateCertificate(rand.Reader, template, bundle.IssuingCA, csr.PublicKey, bundle.PrivateKey)
+					if err != nil {
+						return err
+					}
+					setSignedData["certificate"] = string(pem.EncodeToMemory(&pem.Block{
+						Type:  "CERTIFICATE",
+						Bytes: certBytes,
+					}))
+					caCert, err = x509.ParseCertificate(certBytes)
+					return err
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "intermediate/set-signed",
+				Data:      setSignedData,
+			},
+
+			issueStep,
+
+			// Replacing the CA with one whose key is in storage releases
+			// the KMS configuration
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "config/ca",
+				Data: map[string]interface{}{
+					"pem_bundle": rsaCAKey + rsaCACert,
+				},
+			},
+
+			logicaltest.TestStep{
+				Operation: logical.DeleteOperation,
+				Path:      "config/kms",
+			},
+		},
+	}
+
+	logicaltest.Test(t, testCase)
+}
+
 // Generates and tests steps that walk through the various possibilities
 // of role flags to ensure that they are properly restricted
 // Uses the RSA CA key
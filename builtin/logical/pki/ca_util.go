@@ -7,15 +7,17 @@ import (
 
 func (b *backend) getGenerationParams(
 	data *framework.FieldData,
-) (exported bool, format string, role *roleEntry, errorResp *logical.Response) {
+) (exported bool, useKMS bool, format string, role *roleEntry, errorResp *logical.Response) {
 	exportedStr := data.Get("exported").(string)
 	switch exportedStr {
 	case "exported":
 		exported = true
 	case "internal":
+	case "kms":
+		useKMS = true
 	default:
 		errorResp = logical.ErrorResponse(
-			`The "exported" path parameter must be "internal" or "exported"`)
+			`The "exported" path parameter must be "internal", "exported" or "kms"`)
 		return
 	}
 
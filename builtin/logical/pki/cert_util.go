@@ -1,6 +1,7 @@
 package pki
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/sha1"
 	"crypto/x509"
@@ -48,6 +49,10 @@ type creationBundle struct {
 
 	// The maximum path length to encode
 	MaxPathLength int
+
+	// The key of the new certificate, such as one held in a KMS. A new
+	// key is generated if it is nil.
+	Key crypto.Signer
 }
 
 type caInfoBundle struct {
@@ -112,7 +117,7 @@ func validateKeyTypeLength(keyType string, keyBits int) *logical.Response {
 
 // Fetches the CA info. Unlike other certificates, the CA info is stored
 // in the backend as a CertBundle, because we are storing its private key
-func fetchCAInfo(req *logical.Request) (*caInfoBundle, error) {
+func fetchCAInfo(b *backend, req *logical.Request) (*caInfoBundle, error) {
 	bundleEntry, err := req.Storage.Get("config/ca_bundle")
 	if err != nil {
 		return nil, certutil.InternalError{Err: fmt.Sprintf("unable to fetch local CA certificate/key: %v", err)}
@@ -135,6 +140,23 @@ func fetchCAInfo(req *logical.Request) (*caInfoBundle, error) {
 		return nil, certutil.InternalError{Err: "stored CA information not able to be parsed"}
 	}
 
+	// The CA key may be held in a KMS, in which case only a reference to
+	// it is stored
+	if parsedBundle.PrivateKey == nil {
+		kmsKey, err := fetchKMSKey(req.Storage)
+		if err != nil {
+			return nil, certutil.InternalError{Err: fmt.Sprintf("unable to fetch KMS key reference: %v", err)}
+		}
+		if kmsKey == nil {
+			return nil, certutil.InternalError{Err: "stored CA information has no private key"}
+		}
+		signer, err := b.kmsSignerFor(req.Storage, kmsKey)
+		if err != nil {
+			return nil, err
+		}
+		parsedBundle.SetParsedPrivateKey(signer, certutil.PrivateKeyType(kmsKey.KeyType), nil)
+	}
+
 	caInfo := &caInfoBundle{*parsedBundle, nil}
 
 	entries, err := getURLs(req)
@@ -154,7 +176,8 @@ func fetchCAInfo(req *logical.Request) (*caInfoBundle, error) {
 }
 
 // Allows fetching certificates from the backend; it handles the slightly
-// separate pathing for CA, CRL, and revoked certificates.
+// separate pathing for CA, CRL, and revoked certificates. A nil entry is
+// returned if the certificate does not exist.
 func fetchCertBySerial(req *logical.Request, prefix, serial string) (*logical.StorageEntry, error) {
 	var path string
 
@@ -170,8 +193,11 @@ func fetchCertBySerial(req *logical.Request, prefix, serial string) (*logical.St
 	}
 
 	certEntry, err := req.Storage.Get(path)
-	if err != nil || certEntry == nil {
-		return nil, certutil.InternalError{Err: fmt.Sprintf("certificate with serial number %s not found", serial)}
+	if err != nil {
+		return nil, certutil.InternalError{Err: fmt.Sprintf("error fetching certificate %s: %s", serial, err)}
+	}
+	if certEntry == nil {
+		return nil, nil
 	}
 
 	if certEntry.Value == nil || len(certEntry.Value) == 0 {
@@ -345,6 +371,7 @@ func generateCert(b *backend,
 	role *roleEntry,
 	signingBundle *caInfoBundle,
 	isCA bool,
+	key crypto.Signer,
 	req *logical.Request,
 	data *framework.FieldData) (*certutil.ParsedCertBundle, error) {
 
@@ -352,6 +379,7 @@ func generateCert(b *backend,
 	if err != nil {
 		return nil, err
 	}
+	creationBundle.Key = key
 
 	if isCA {
 		creationBundle.IsCA = isCA
@@ -392,6 +420,7 @@ func generateCert(b *backend,
 func generateIntermediateCSR(b *backend,
 	role *roleEntry,
 	signingBundle *caInfoBundle,
+	key crypto.Signer,
 	req *logical.Request,
 	data *framework.FieldData) (*certutil.ParsedCSRBundle, error) {
 
@@ -399,6 +428,7 @@ func generateIntermediateCSR(b *backend,
 	if err != nil {
 		return nil, err
 	}
+	creationBundle.Key = key
 
 	parsedBundle, err := createCSR(creationBundle)
 	if err != nil {
@@ -596,11 +626,26 @@ func generateCreationBundle(b *backend,
 		}
 
 		// If it's not self-signed, verify that the issued certificate won't be
-		// valid past the lifetime of the CA certificate
-		if signingBundle != nil &&
-			time.Now().Add(ttl).After(signingBundle.Certificate.NotAfter) {
-			return nil, certutil.UserError{Err: fmt.Sprintf(
-				"cannot satisfy request, as TTL is beyond the expiration of the CA certificate")}
+		// valid past the lifetime of the CA certificate, or shorten it if
+		// the backend is configured to
+		if signingBundle != nil {
+			caRemaining := signingBundle.Certificate.NotAfter.Sub(time.Now())
+			if caRemaining <= 0 {
+				return nil, certutil.UserError{Err: fmt.Sprintf(
+					"cannot satisfy request, as the CA certificate has expired")}
+			}
+			if ttl > caRemaining {
+				issuance, err := b.Issuance(req.Storage)
+				if err != nil {
+					return nil, certutil.InternalError{Err: fmt.Sprintf(
+						"unable to fetch issuance configuration: %v", err)}
+				}
+				if issuance.CAExpiryPolicy != caExpiryPolicyTruncate {
+					return nil, certutil.UserError{Err: fmt.Sprintf(
+						"cannot satisfy request, as TTL is beyond the expiration of the CA certificate")}
+				}
+				ttl = caRemaining
+			}
 		}
 	}
 
@@ -710,7 +755,9 @@ func createCertificate(creationInfo *creationBundle) (*certutil.ParsedCertBundle
 		return nil, err
 	}
 
-	if err := certutil.GeneratePrivateKey(creationInfo.KeyType,
+	if creationInfo.Key != nil {
+		result.SetParsedPrivateKey(creationInfo.Key, certutil.PrivateKeyType(creationInfo.KeyType), nil)
+	} else if err := certutil.GeneratePrivateKey(creationInfo.KeyType,
 		creationInfo.KeyBits,
 		result); err != nil {
 		return nil, err
@@ -807,7 +854,9 @@ func createCSR(creationInfo *creationBundle) (*certutil.ParsedCSRBundle, error)
 	var err error
 	result := &certutil.ParsedCSRBundle{}
 
-	if err := certutil.GeneratePrivateKey(creationInfo.KeyType,
+	if creationInfo.Key != nil {
+		result.SetParsedPrivateKey(creationInfo.Key, certutil.PrivateKeyType(creationInfo.KeyType), nil)
+	} else if err := certutil.GeneratePrivateKey(creationInfo.KeyType,
 		creationInfo.KeyBits,
 		result); err != nil {
 		return nil, err
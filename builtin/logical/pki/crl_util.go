@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/vault/helper/certutil"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
 )
 
 type revocationInfo struct {
@@ -56,6 +57,9 @@ func revokeCert(b *backend, req *logical.Request, serial string) (*logical.Respo
 		case certutil.InternalError:
 			return nil, err
 		}
+		if certEntry == nil {
+			return framework.NotFound(fmt.Sprintf("certificate with serial number %s not found", serial))
+		}
 
 		cert, err := x509.ParseCertificate(certEntry.Value)
 		if err != nil {
@@ -157,7 +161,7 @@ func buildCRL(b *backend, req *logical.Request) error {
 		})
 	}
 
-	signingBundle, caErr := fetchCAInfo(req)
+	signingBundle, caErr := fetchCAInfo(b, req)
 	switch caErr.(type) {
 	case certutil.UserError:
 		return certutil.UserError{Err: fmt.Sprintf("Could not fetch the CA certificate: %s", caErr)}
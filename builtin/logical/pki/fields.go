@@ -57,6 +57,14 @@ default TTL is used, in that order. Cannot
 be later than the role max TTL.`,
 	}
 
+	fields["metadata"] = &framework.FieldSchema{
+		Type: framework.TypeMap,
+		Description: `Metadata to store with the issued certificate,
+such as the owner of the host or a ticket ID.
+Only keys allowed by the role's
+allowed_metadata_keys may be given.`,
+	}
+
 	return fields
 }
 
@@ -101,10 +109,12 @@ generating a CSR for an intermediate CA.`,
 func addCAKeyGenerationFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
 	fields["exported"] = &framework.FieldSchema{
 		Type: framework.TypeString,
-		Description: `Must be "internal" or "exported". If set to
+		Description: `Must be "internal", "exported" or "kms". If set to
 "exported", the generated private key will be
 returned. This is your *only* chance to retrieve
-the private key!`,
+the private key! If set to "kms", the key is
+generated in and never leaves the KMS configured
+with "config/kms".`,
 	}
 
 	fields["key_bits"] = &framework.FieldSchema{
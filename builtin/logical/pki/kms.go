@@ -0,0 +1,354 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// kmsConfig holds the information needed to reach the KMS that CA keys
+// generated with the "kms" type are created in
+type kmsConfig struct {
+	Provider  string `json:"provider" mapstructure:"provider" structs:"provider"`
+	Region    string `json:"region" mapstructure:"region" structs:"region"`
+	AccessKey string `json:"access_key" mapstructure:"access_key" structs:"access_key"`
+	SecretKey string `json:"secret_key" mapstructure:"secret_key" structs:"secret_key"`
+	Endpoint  string `json:"endpoint" mapstructure:"endpoint" structs:"endpoint"`
+}
+
+// kmsKeyEntry references the CA key held in the KMS. It is the only
+// information about the key kept in storage.
+type kmsKeyEntry struct {
+	Provider string `json:"provider" mapstructure:"provider" structs:"provider"`
+	KeyID    string `json:"key_id" mapstructure:"key_id" structs:"key_id"`
+	KeyType  string `json:"key_type" mapstructure:"key_type" structs:"key_type"`
+	KeyBits  int    `json:"key_bits" mapstructure:"key_bits" structs:"key_bits"`
+}
+
+// kmsClient is implemented by each supported KMS
+type kmsClient interface {
+	// CreateKey creates a signing key of the given type and size and
+	// returns its ID
+	CreateKey(keyType string, keyBits int, description string) (string, error)
+
+	// PublicKey returns the public part of the key
+	PublicKey(keyID string) (crypto.PublicKey, error)
+
+	// Sign signs the digest, which was computed with the given hash
+	Sign(keyID, keyType string, digest []byte, hash crypto.Hash) ([]byte, error)
+
+	// ScheduleKeyDeletion deletes the key after the shortest waiting
+	// period the KMS allows
+	ScheduleKeyDeletion(keyID string) error
+}
+
+// kmsSigner is a crypto.Signer whose private key never leaves the KMS
+type kmsSigner struct {
+	client    kmsClient
+	config    kmsConfig
+	key       *kmsKeyEntry
+	publicKey crypto.PublicKey
+}
+
+func (s *kmsSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *kmsSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, fmt.Errorf("RSA-PSS signatures are not supported with KMS keys")
+	}
+	return s.client.Sign(s.key.KeyID, s.key.KeyType, digest, opts.HashFunc())
+}
+
+// newKMSClient is a variable so that tests can use a client that does not
+// need a real KMS
+var newKMSClient = func(config *kmsConfig) (kmsClient, error) {
+	switch config.Provider {
+	case "awskms":
+		return newAWSKMSClient(config), nil
+	default:
+		return nil, fmt.Errorf("unsupported KMS provider %q", config.Provider)
+	}
+}
+
+func pathConfigKMS(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/kms",
+		Fields: map[string]*framework.FieldSchema{
+			"provider": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "awskms",
+				Description: `The KMS to create CA keys in; "awskms" is the only valid value.`,
+			},
+
+			"region": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The region of the KMS",
+			},
+
+			"access_key": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Access key to authenticate to the KMS with. If not
+set, the credentials of the environment are used.`,
+			},
+
+			"secret_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Secret key matching the access key",
+			},
+
+			"endpoint": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Endpoint of the KMS, such as a VPC endpoint. If not
+set, the public endpoint of the region is used.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathKMSRead,
+			logical.UpdateOperation: b.pathKMSWrite,
+			logical.DeleteOperation: b.pathKMSDelete,
+		},
+
+		HelpSynopsis:    pathConfigKMSHelpSyn,
+		HelpDescription: pathConfigKMSHelpDesc,
+	}
+}
+
+func fetchKMSConfig(s logical.Storage) (*kmsConfig, error) {
+	entry, err := s.Get("config/kms")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result kmsConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathKMSRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := fetchKMSConfig(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"provider":   config.Provider,
+			"region":     config.Region,
+			"access_key": config.AccessKey,
+			"endpoint":   config.Endpoint,
+		},
+	}, nil
+}
+
+func (b *backend) pathKMSWrite(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config := &kmsConfig{
+		Provider:  data.Get("provider").(string),
+		Region:    data.Get("region").(string),
+		AccessKey: data.Get("access_key").(string),
+		SecretKey: data.Get("secret_key").(string),
+		Endpoint:  data.Get("endpoint").(string),
+	}
+
+	switch {
+	case config.Provider != "awskms":
+		return logical.ErrorResponse(`"provider" must be "awskms"`), nil
+	case config.Region == "":
+		return logical.ErrorResponse(`"region" must be set`), nil
+	case (config.AccessKey == "") != (config.SecretKey == ""):
+		return logical.ErrorResponse(`"access_key" and "secret_key" must be set together`), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config/kms", config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathKMSDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, err := fetchKMSKey(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		return logical.ErrorResponse("the CA key of this backend is held in the KMS; replace the CA first"), nil
+	}
+
+	if err := req.Storage.Delete("config/kms"); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func fetchKMSKey(s logical.Storage) (*kmsKeyEntry, error) {
+	entry, err := s.Get("config/ca_kms_key")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result kmsKeyEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// storeKMSKey records the key as the CA key, or removes the record of
+// a previous KMS key if key is nil, such as when a CA key generated in
+// software replaces it
+func storeKMSKey(s logical.Storage, key *kmsKeyEntry) error {
+	if key == nil {
+		return s.Delete("config/ca_kms_key")
+	}
+
+	entry, err := logical.StorageEntryJSON("config/ca_kms_key", key)
+	if err != nil {
+		return err
+	}
+	return s.Put(entry)
+}
+
+// kmsSignerFor returns a signer using the given KMS key. The signer is
+// cached so that the KMS is not asked for the public key on every
+// issuance; it is replaced when the CA key or the KMS configuration
+// changes.
+func (b *backend) kmsSignerFor(s logical.Storage, key *kmsKeyEntry) (*kmsSigner, error) {
+	config, err := fetchKMSConfig(s)
+	if err != nil {
+		return nil, certutil.InternalError{Err: fmt.Sprintf("unable to fetch KMS configuration: %v", err)}
+	}
+	if config == nil {
+		return nil, certutil.UserError{Err: "the KMS must be configured with config/kms first"}
+	}
+	if config.Provider != key.Provider {
+		return nil, certutil.UserError{Err: fmt.Sprintf(
+			"the CA key is held in %q but the configured KMS is %q", key.Provider, config.Provider)}
+	}
+
+	b.kmsSignerLock.Lock()
+	defer b.kmsSignerLock.Unlock()
+
+	if cached := b.kmsSigner; cached != nil && cached.key.KeyID == key.KeyID && cached.config == *config {
+		return cached, nil
+	}
+	b.kmsSigner = nil
+
+	client, err := newKMSClient(config)
+	if err != nil {
+		return nil, certutil.UserError{Err: err.Error()}
+	}
+
+	publicKey, err := client.PublicKey(key.KeyID)
+	if err != nil {
+		return nil, certutil.InternalError{Err: fmt.Sprintf("unable to fetch public key %s from the KMS: %v", key.KeyID, err)}
+	}
+
+	b.kmsSigner = &kmsSigner{
+		client:    client,
+		config:    *config,
+		key:       key,
+		publicKey: publicKey,
+	}
+	return b.kmsSigner, nil
+}
+
+// createKMSKey creates a new key in the configured KMS for a CA
+// certificate. The key is not recorded as the CA key.
+func createKMSKey(s logical.Storage, role *roleEntry, commonName string) (*kmsSigner, error) {
+	switch {
+	case role.KeyType == "rsa" && (role.KeyBits == 2048 || role.KeyBits == 3072 || role.KeyBits == 4096):
+	case role.KeyType == "ec" && role.KeyBits == 256:
+	default:
+		return nil, certutil.UserError{Err: fmt.Sprintf(
+			"unsupported key type and size for KMS keys: %s %d; RSA keys must be 2048, 3072 or 4096 bits and EC keys 256 bits",
+			role.KeyType, role.KeyBits)}
+	}
+
+	config, err := fetchKMSConfig(s)
+	if err != nil {
+		return nil, certutil.InternalError{Err: fmt.Sprintf("unable to fetch KMS configuration: %v", err)}
+	}
+	if config == nil {
+		return nil, certutil.UserError{Err: "the KMS must be configured with config/kms first"}
+	}
+
+	client, err := newKMSClient(config)
+	if err != nil {
+		return nil, certutil.UserError{Err: err.Error()}
+	}
+
+	keyID, err := client.CreateKey(role.KeyType, role.KeyBits, fmt.Sprintf("Vault PKI CA key for %s", commonName))
+	if err != nil {
+		return nil, certutil.InternalError{Err: fmt.Sprintf("unable to create key in the KMS: %v", err)}
+	}
+
+	publicKey, err := client.PublicKey(keyID)
+	if err != nil {
+		return nil, certutil.InternalError{Err: fmt.Sprintf("unable to fetch public key %s from the KMS: %v", keyID, err)}
+	}
+
+	return &kmsSigner{
+		client: client,
+		config: *config,
+		key: &kmsKeyEntry{
+			Provider: config.Provider,
+			KeyID:    keyID,
+			KeyType:  role.KeyType,
+			KeyBits:  role.KeyBits,
+		},
+		publicKey: publicKey,
+	}, nil
+}
+
+// deleteKMSKey schedules the deletion of a key created for a CA that was
+// not stored after all. Errors are only logged, so that the error that
+// stopped the CA from being stored is the one returned.
+func (b *backend) deleteKMSKey(signer *kmsSigner) {
+	if err := signer.client.ScheduleKeyDeletion(signer.key.KeyID); err != nil {
+		b.Logger().Printf("[ERR] pki: unable to schedule deletion of unused KMS key %s: %v", signer.key.KeyID, err)
+	}
+}
+
+const pathConfigKMSHelpSyn = `
+Configure the KMS that CA keys can be generated in.
+`
+
+const pathConfigKMSHelpDesc = `
+CA keys generated with the "root/generate/kms" and "intermediate/generate/kms"
+endpoints are created in and never leave the KMS configured here; only a
+reference to the key is stored by this backend, and every certificate and
+CRL is signed by the KMS.
+
+"awskms" is the only supported provider. If "access_key" and "secret_key"
+are not set, credentials are taken from the environment, the shared
+credentials file or the EC2 instance role. The secret key cannot be read
+back.
+
+The configuration cannot be deleted while the CA key is held in the KMS.
+`
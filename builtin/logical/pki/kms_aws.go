@@ -0,0 +1,215 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+	"github.com/aws/aws-sdk-go/private/signer/v4"
+)
+
+// awsKMS talks to AWS KMS. The vendored SDK predates KMS support for
+// asymmetric keys, so the few operations needed are defined here on top
+// of its JSON RPC client.
+type awsKMS struct {
+	client *client.Client
+}
+
+func newAWSKMSClient(config *kmsConfig) *awsKMS {
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.StaticProvider{Value: credentials.Value{
+			AccessKeyID:     config.AccessKey,
+			SecretAccessKey: config.SecretKey,
+		}},
+		&credentials.EnvProvider{},
+		&credentials.SharedCredentialsProvider{Filename: "", Profile: ""},
+		&ec2rolecreds.EC2RoleProvider{Client: ec2metadata.New(session.New())},
+	})
+
+	awsConf := aws.NewConfig().
+		WithCredentials(creds).
+		WithRegion(config.Region)
+	if config.Endpoint != "" {
+		awsConf = awsConf.WithEndpoint(config.Endpoint)
+	}
+
+	c := session.New(awsConf).ClientConfig("kms")
+	kmsClient := client.New(
+		*c.Config,
+		metadata.ClientInfo{
+			ServiceName:   "kms",
+			SigningRegion: c.SigningRegion,
+			Endpoint:      c.Endpoint,
+			APIVersion:    "2014-11-01",
+			JSONVersion:   "1.1",
+			TargetPrefix:  "TrentService",
+		},
+		c.Handlers,
+	)
+	kmsClient.Handlers.Sign.PushBack(v4.Sign)
+	kmsClient.Handlers.Build.PushBack(jsonrpc.Build)
+	kmsClient.Handlers.Unmarshal.PushBack(jsonrpc.Unmarshal)
+	kmsClient.Handlers.UnmarshalMeta.PushBack(jsonrpc.UnmarshalMeta)
+	kmsClient.Handlers.UnmarshalError.PushBack(jsonrpc.UnmarshalError)
+
+	return &awsKMS{client: kmsClient}
+}
+
+func (k *awsKMS) call(name string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return k.client.NewRequest(op, input, output).Send()
+}
+
+func (k *awsKMS) CreateKey(keyType string, keyBits int, description string) (string, error) {
+	var keySpec string
+	switch keyType {
+	case "rsa":
+		keySpec = "RSA_" + strconv.Itoa(keyBits)
+	case "ec":
+		keySpec = "ECC_NIST_P" + strconv.Itoa(keyBits)
+	default:
+		return "", fmt.Errorf("unsupported key type %q", keyType)
+	}
+
+	input := &awsKMSCreateKeyInput{
+		Description: aws.String(description),
+		KeySpec:     aws.String(keySpec),
+		KeyUsage:    aws.String("SIGN_VERIFY"),
+	}
+	output := &awsKMSCreateKeyOutput{}
+	if err := k.call("CreateKey", input, output); err != nil {
+		return "", err
+	}
+	if output.KeyMetadata == nil || output.KeyMetadata.KeyId == nil {
+		return "", fmt.Errorf("no key ID returned")
+	}
+	return *output.KeyMetadata.KeyId, nil
+}
+
+func (k *awsKMS) PublicKey(keyID string) (crypto.PublicKey, error) {
+	input := &awsKMSGetPublicKeyInput{
+		KeyId: aws.String(keyID),
+	}
+	output := &awsKMSGetPublicKeyOutput{}
+	if err := k.call("GetPublicKey", input, output); err != nil {
+		return nil, err
+	}
+	return x509.ParsePKIXPublicKey(output.PublicKey)
+}
+
+func (k *awsKMS) Sign(keyID, keyType string, digest []byte, hash crypto.Hash) ([]byte, error) {
+	var algorithm string
+	switch keyType {
+	case "rsa":
+		algorithm = "RSASSA_PKCS1_V1_5_"
+	case "ec":
+		algorithm = "ECDSA_"
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+	switch hash {
+	case crypto.SHA256:
+		algorithm += "SHA_256"
+	case crypto.SHA384:
+		algorithm += "SHA_384"
+	case crypto.SHA512:
+		algorithm += "SHA_512"
+	default:
+		return nil, fmt.Errorf("unsupported hash function %v", hash)
+	}
+
+	input := &awsKMSSignInput{
+		KeyId:            aws.String(keyID),
+		Message:          digest,
+		MessageType:      aws.String("DIGEST"),
+		SigningAlgorithm: aws.String(algorithm),
+	}
+	output := &awsKMSSignOutput{}
+	if err := k.call("Sign", input, output); err != nil {
+		return nil, err
+	}
+	return output.Signature, nil
+}
+
+func (k *awsKMS) ScheduleKeyDeletion(keyID string) error {
+	input := &awsKMSScheduleKeyDeletionInput{
+		KeyId: aws.String(keyID),
+		// The shortest waiting period KMS accepts
+		PendingWindowInDays: aws.Int64(7),
+	}
+	return k.call("ScheduleKeyDeletion", input, &awsKMSScheduleKeyDeletionOutput{})
+}
+
+type awsKMSCreateKeyInput struct {
+	_ struct{} `type:"structure"`
+
+	Description *string `type:"string"`
+	KeySpec     *string `type:"string"`
+	KeyUsage    *string `type:"string"`
+}
+
+type awsKMSCreateKeyOutput struct {
+	_ struct{} `type:"structure"`
+
+	KeyMetadata *awsKMSKeyMetadata `type:"structure"`
+}
+
+type awsKMSKeyMetadata struct {
+	_ struct{} `type:"structure"`
+
+	KeyId *string `type:"string"`
+}
+
+type awsKMSGetPublicKeyInput struct {
+	_ struct{} `type:"structure"`
+
+	KeyId *string `type:"string"`
+}
+
+type awsKMSGetPublicKeyOutput struct {
+	_ struct{} `type:"structure"`
+
+	PublicKey []byte `type:"blob"`
+}
+
+type awsKMSSignInput struct {
+	_ struct{} `type:"structure"`
+
+	KeyId            *string `type:"string"`
+	Message          []byte  `type:"blob"`
+	MessageType      *string `type:"string"`
+	SigningAlgorithm *string `type:"string"`
+}
+
+type awsKMSSignOutput struct {
+	_ struct{} `type:"structure"`
+
+	Signature []byte `type:"blob"`
+}
+
+type awsKMSScheduleKeyDeletionInput struct {
+	_ struct{} `type:"structure"`
+
+	KeyId               *string `type:"string"`
+	PendingWindowInDays *int64  `type:"integer"`
+}
+
+type awsKMSScheduleKeyDeletionOutput struct {
+	_ struct{} `type:"structure"`
+
+	KeyId *string `type:"string"`
+}
@@ -0,0 +1,122 @@
+package pki
+
+import (
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathCAExpiry(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ca/expiry",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCAExpiryRead,
+		},
+
+		HelpSynopsis:    pathCAExpiryHelpSyn,
+		HelpDescription: pathCAExpiryHelpDesc,
+	}
+}
+
+func (b *backend) pathCAExpiryRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	signingBundle, caErr := fetchCAInfo(b, req)
+	switch caErr.(type) {
+	case certutil.UserError:
+		return framework.NotFound(caErr.Error())
+	case certutil.InternalError:
+		return nil, caErr
+	}
+
+	config, err := b.Issuance(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	warning, err := time.ParseDuration(config.CAExpiryWarning)
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter := signingBundle.Certificate.NotAfter
+	remaining := notAfter.Sub(time.Now())
+	emitCAExpiry(req, remaining)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"expiration": notAfter.UTC().Format(time.RFC3339),
+			"ttl":        int64(remaining.Seconds()),
+			"expired":    remaining <= 0,
+			"expiring":   remaining < warning,
+		},
+	}, nil
+}
+
+// emitCAExpiry reports the number of seconds until the CA certificate of
+// the mount expires, which is negative once it has expired.
+func emitCAExpiry(req *logical.Request, remaining time.Duration) {
+	mount := strings.Replace(strings.Trim(req.MountPoint, "/"), "/", "-", -1)
+	if mount == "" {
+		mount = "unknown"
+	}
+	metrics.SetGauge([]string{"pki", "ca", "ttl", mount}, float32(remaining.Seconds()))
+}
+
+// periodicFunc keeps the CA expiry gauge up to date even if nothing is
+// being issued.
+func (b *backend) periodicFunc(req *logical.Request) error {
+	signingBundle, err := fetchCAInfo(b, req)
+	switch err.(type) {
+	case certutil.UserError:
+		// No CA has been configured yet
+		return nil
+	case certutil.InternalError:
+		return err
+	}
+
+	emitCAExpiry(req, signingBundle.Certificate.NotAfter.Sub(time.Now()))
+	return nil
+}
+
+// caExpiryWarnings returns the warnings to add to a response issuing a
+// certificate that expires at notAfter.
+func (b *backend) caExpiryWarnings(
+	req *logical.Request, signingBundle *caInfoBundle, notAfter time.Time) ([]string, error) {
+	config, err := b.Issuance(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	warning, err := time.ParseDuration(config.CAExpiryWarning)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	caNotAfter := signingBundle.Certificate.NotAfter
+	if !notAfter.Before(caNotAfter) {
+		warnings = append(warnings,
+			"The expiration of the certificate was truncated to that of the CA certificate")
+	}
+	if caNotAfter.Sub(time.Now()) < warning {
+		warnings = append(warnings, "The CA certificate expires at "+
+			caNotAfter.UTC().Format(time.RFC3339))
+	}
+	return warnings, nil
+}
+
+const pathCAExpiryHelpSyn = `
+Report when the CA certificate expires.
+`
+
+const pathCAExpiryHelpDesc = `
+This endpoint returns the expiration of the CA certificate, the number
+of seconds until then as "ttl", and whether it has expired or expires
+within the "ca_expiry_warning" set at "config/issuance".
+
+The number of seconds until expiration is also reported by the
+"pki.ca.ttl.<mount>" gauge, which is updated periodically.
+`
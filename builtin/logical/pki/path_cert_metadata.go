@@ -0,0 +1,264 @@
+package pki
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/mitchellh/mapstructure"
+)
+
+// certMetadataEntry is stored alongside an issued certificate and holds
+// caller-supplied metadata used for inventory and ownership tracking
+type certMetadataEntry struct {
+	SerialNumber string            `json:"serial_number" structs:"serial_number" mapstructure:"serial_number"`
+	Role         string            `json:"role" structs:"role" mapstructure:"role"`
+	CommonName   string            `json:"common_name" structs:"common_name" mapstructure:"common_name"`
+	IssueTime    time.Time         `json:"issue_time" structs:"issue_time,omitnested" mapstructure:"issue_time"`
+	Expiration   time.Time         `json:"expiration" structs:"expiration,omitnested" mapstructure:"expiration"`
+	Metadata     map[string]string `json:"metadata" structs:"metadata" mapstructure:"metadata"`
+}
+
+func pathListCertMetadata(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "cert-metadata/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathCertMetadataList,
+		},
+
+		HelpSynopsis:    pathCertMetadataHelpSyn,
+		HelpDescription: pathCertMetadataHelpDesc,
+	}
+}
+
+func pathFetchCertMetadata(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `cert-metadata/(?P<serial>[0-9A-Fa-f-:]+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"serial": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Certificate serial number, in colon- or
+hyphen-separated octal`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCertMetadataRead,
+		},
+
+		HelpSynopsis:    pathCertMetadataHelpSyn,
+		HelpDescription: pathCertMetadataHelpDesc,
+	}
+}
+
+func pathSearchCertMetadata(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "cert-metadata/search",
+		Fields: map[string]*framework.FieldSchema{
+			"role": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "If set, only certificates issued by this role match",
+			},
+
+			"common_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "If set, only certificates with this common name match",
+			},
+
+			"metadata": &framework.FieldSchema{
+				Type: framework.TypeMap,
+				Description: `Metadata key/value pairs that must all be
+present on a certificate for it to match`,
+			},
+
+			"include_expired": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Default:     false,
+				Description: "If set, expired certificates are included in the results",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCertMetadataSearch,
+		},
+
+		HelpSynopsis:    pathCertMetadataSearchHelpSyn,
+		HelpDescription: pathCertMetadataSearchHelpDesc,
+	}
+}
+
+// parseCertMetadata validates the metadata supplied with an issue or sign
+// request against the keys the role allows
+func parseCertMetadata(role *roleEntry, data *framework.FieldData) (map[string]string, error) {
+	raw, ok := data.GetOk("metadata")
+	if !ok {
+		return nil, nil
+	}
+
+	var metadata map[string]string
+	if err := mapstructure.WeakDecode(raw, &metadata); err != nil {
+		return nil, fmt.Errorf("could not parse metadata: %s", err)
+	}
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+
+	allowed := strings.Split(role.AllowedMetadataKeys, ",")
+	for k := range metadata {
+		found := false
+		for _, a := range allowed {
+			a = strings.TrimSpace(a)
+			if a == "*" || (a != "" && a == k) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("metadata key '%s' is not allowed by this role", k)
+		}
+	}
+
+	return metadata, nil
+}
+
+func fetchCertMetadata(s logical.Storage, serial string) (*certMetadataEntry, error) {
+	entry, err := s.Get("cert-metadata/" + normalizeSerial(serial))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result certMetadataEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathCertMetadataList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List("cert-metadata/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathCertMetadataRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entry, err := fetchCertMetadata(req.Storage, data.Get("serial").(string))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: structs.New(entry).Map(),
+	}, nil
+}
+
+func (b *backend) pathCertMetadataSearch(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	role := data.Get("role").(string)
+	commonName := data.Get("common_name").(string)
+	includeExpired := data.Get("include_expired").(bool)
+
+	var filter map[string]string
+	if raw, ok := data.GetOk("metadata"); ok {
+		if err := mapstructure.WeakDecode(raw, &filter); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf(
+				"could not parse metadata filter: %s", err)), nil
+		}
+	}
+
+	serials, err := req.Storage.List("cert-metadata/")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(serials)
+
+	now := time.Now()
+	keys := []string{}
+	certs := map[string]interface{}{}
+	for _, serial := range serials {
+		entry, err := fetchCertMetadata(req.Storage, serial)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		if role != "" && entry.Role != role {
+			continue
+		}
+		if commonName != "" && entry.CommonName != commonName {
+			continue
+		}
+		if !includeExpired && now.After(entry.Expiration) {
+			continue
+		}
+
+		matches := true
+		for k, v := range filter {
+			if entry.Metadata[k] != v {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		keys = append(keys, serial)
+		certs[serial] = structs.New(entry).Map()
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"keys":         keys,
+			"certificates": certs,
+		},
+	}, nil
+}
+
+// normalizeSerial converts a hyphen-separated serial into the lower-case,
+// colon-separated form used in storage keys
+func normalizeSerial(serial string) string {
+	return strings.Replace(strings.ToLower(serial), "-", ":", -1)
+}
+
+const pathCertMetadataHelpSyn = `
+Fetch the metadata stored with issued certificates.
+`
+
+const pathCertMetadataHelpDesc = `
+Certificates issued or signed with a "metadata" map, as allowed by the
+role's "allowed_metadata_keys", have that metadata stored along with the
+role, common name and expiration of the certificate.
+
+Listing this path returns the serial numbers of certificates that have
+metadata stored; reading "cert-metadata/<serial>" returns the metadata
+for that certificate.
+`
+
+const pathCertMetadataSearchHelpSyn = `
+Search issued certificates by role, common name or metadata.
+`
+
+const pathCertMetadataSearchHelpDesc = `
+This path returns the serial numbers and metadata of certificates matching
+all of the given filters. Metadata filters match on exact key/value pairs.
+Expired certificates are excluded unless "include_expired" is set.
+`
@@ -87,6 +87,10 @@ func (b *backend) pathCAWrite(
 	if err != nil {
 		return nil, err
 	}
+	err = storeKMSKey(req.Storage, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	// For ease of later use, also store just the certificate at a known
 	// location, plus a fresh CRL
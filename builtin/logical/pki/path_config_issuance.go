@@ -0,0 +1,138 @@
+package pki
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// caExpiryPolicyError refuses to issue certificates that would be
+	// valid past the expiration of the CA certificate
+	caExpiryPolicyError = "error"
+
+	// caExpiryPolicyTruncate issues such certificates with their
+	// expiration cut back to that of the CA certificate, with a warning
+	caExpiryPolicyTruncate = "truncate"
+)
+
+// issuanceConfig holds the checks applied to the CA when issuing
+type issuanceConfig struct {
+	CAExpiryPolicy  string `json:"ca_expiry_policy" mapstructure:"ca_expiry_policy" structs:"ca_expiry_policy"`
+	CAExpiryWarning string `json:"ca_expiry_warning" mapstructure:"ca_expiry_warning" structs:"ca_expiry_warning"`
+}
+
+func pathConfigIssuance(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuance",
+		Fields: map[string]*framework.FieldSchema{
+			"ca_expiry_policy": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `What to do when a certificate would be valid
+past the expiration of the CA certificate: "error" to refuse to issue
+it, or "truncate" to issue it with the CA's expiration and a warning.
+Defaults to "error".`,
+				Default: caExpiryPolicyError,
+			},
+
+			"ca_expiry_warning": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Responses from issuing endpoints carry a
+warning when the CA certificate expires within this amount of time;
+defaults to 720 hours`,
+				Default: "720h",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathIssuanceRead,
+			logical.UpdateOperation: b.pathIssuanceWrite,
+		},
+
+		HelpSynopsis:    pathConfigIssuanceHelpSyn,
+		HelpDescription: pathConfigIssuanceHelpDesc,
+	}
+}
+
+// Issuance returns the issuance configuration, using the defaults if none
+// has been written
+func (b *backend) Issuance(s logical.Storage) (*issuanceConfig, error) {
+	result := &issuanceConfig{
+		CAExpiryPolicy:  caExpiryPolicyError,
+		CAExpiryWarning: "720h",
+	}
+
+	entry, err := s.Get("config/issuance")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return result, nil
+	}
+
+	if err := entry.DecodeJSON(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (b *backend) pathIssuanceRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.Issuance(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"ca_expiry_policy":  config.CAExpiryPolicy,
+			"ca_expiry_warning": config.CAExpiryWarning,
+		},
+	}, nil
+}
+
+func (b *backend) pathIssuanceWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config := &issuanceConfig{
+		CAExpiryPolicy:  d.Get("ca_expiry_policy").(string),
+		CAExpiryWarning: d.Get("ca_expiry_warning").(string),
+	}
+
+	switch config.CAExpiryPolicy {
+	case caExpiryPolicyError, caExpiryPolicyTruncate:
+	default:
+		return logical.ErrorResponse(fmt.Sprintf(
+			"Unknown ca_expiry_policy %q; must be %q or %q",
+			config.CAExpiryPolicy, caExpiryPolicyError, caExpiryPolicyTruncate)), nil
+	}
+
+	if _, err := time.ParseDuration(config.CAExpiryWarning); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("Given ca_expiry_warning could not be decoded: %s", err)), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config/issuance", config)
+	if err != nil {
+		return nil, err
+	}
+	err = req.Storage.Put(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+const pathConfigIssuanceHelpSyn = `
+Configure the checks made against the CA certificate when issuing.
+`
+
+const pathConfigIssuanceHelpDesc = `
+This endpoint controls what happens when a requested certificate would
+be valid past the expiration of the CA certificate, and how far ahead
+of the CA's expiration issuing endpoints start warning about it.
+
+Certificates are never issued once the CA certificate has expired.
+`
@@ -0,0 +1,373 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// oidExtensionCRLNumber is the OID of the CRL number extension
+var oidExtensionCRLNumber = asn1.ObjectIdentifier{2, 5, 29, 20}
+
+// externalCRLEntry is a CRL imported from another CA, such as an offline
+// root, along with the certificate its signature was verified with
+type externalCRLEntry struct {
+	CRLBytes    []byte `json:"crl_bytes" mapstructure:"crl_bytes" structs:"crl_bytes"`
+	IssuerBytes []byte `json:"issuer_bytes" mapstructure:"issuer_bytes" structs:"issuer_bytes"`
+}
+
+func pathListExternalCRLs(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "crl/external/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathExternalCRLList,
+		},
+
+		HelpSynopsis:    pathExternalCRLHelpSyn,
+		HelpDescription: pathExternalCRLHelpDesc,
+	}
+}
+
+func pathExternalCRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "crl/external/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the imported CRL",
+			},
+
+			"crl": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The CRL to import, PEM encoded or base64
+encoded DER`,
+			},
+
+			"issuer_certificate": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM encoded certificate of the CA that signed
+the CRL. Not needed if it is the CA of this backend or the CA that issued
+it.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathExternalCRLRead,
+			logical.UpdateOperation: b.pathExternalCRLWrite,
+			logical.DeleteOperation: b.pathExternalCRLDelete,
+		},
+
+		HelpSynopsis:    pathExternalCRLHelpSyn,
+		HelpDescription: pathExternalCRLHelpDesc,
+	}
+}
+
+// Returns the CRL of this backend and every imported CRL as PEM
+func pathFetchCRLBundle(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "crl/bundle",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchCRLBundle,
+		},
+
+		HelpSynopsis:    pathFetchCRLBundleHelpSyn,
+		HelpDescription: pathFetchCRLBundleHelpDesc,
+	}
+}
+
+func fetchExternalCRL(s logical.Storage, name string) (*externalCRLEntry, error) {
+	entry, err := s.Get("crl-external/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result externalCRLEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathExternalCRLList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List("crl-external/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathExternalCRLRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entry, err := fetchExternalCRL(req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	crl, err := x509.ParseDERCRL(entry.CRLBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse stored CRL: %v", err)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"crl": string(pem.EncodeToMemory(&pem.Block{
+				Type:  "X509 CRL",
+				Bytes: entry.CRLBytes,
+			})),
+			"issuer":        crlIssuerName(crl),
+			"this_update":   crl.TBSCertList.ThisUpdate.UTC().Format(time.RFC3339),
+			"next_update":   crl.TBSCertList.NextUpdate.UTC().Format(time.RFC3339),
+			"revoked_count": len(crl.TBSCertList.RevokedCertificates),
+		},
+	}
+	if crl.HasExpired(time.Now()) {
+		resp.AddWarning("The CRL is past its next update time; import a newer one")
+	}
+	return resp, nil
+}
+
+func (b *backend) pathExternalCRLWrite(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	crlBytes, err := decodeCRLInput(data.Get("crl").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	crl, err := x509.ParseDERCRL(crlBytes)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("unable to parse CRL: %v", err)), nil
+	}
+
+	// Gather the certificates the CRL may be signed by
+	var candidates []*x509.Certificate
+	if issuerPEM := data.Get("issuer_certificate").(string); issuerPEM != "" {
+		block, _ := pem.Decode([]byte(issuerPEM))
+		if block == nil {
+			return logical.ErrorResponse("issuer_certificate is not PEM encoded"), nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("unable to parse issuer_certificate: %v", err)), nil
+		}
+		candidates = append(candidates, cert)
+	}
+	signingBundle, caErr := fetchCAInfo(b, req)
+	switch caErr.(type) {
+	case certutil.UserError:
+		if len(candidates) == 0 {
+			return logical.ErrorResponse(fmt.Sprintf(
+				"issuer_certificate must be given when no CA is configured: %s", caErr)), nil
+		}
+	case certutil.InternalError:
+		return nil, caErr
+	default:
+		candidates = append(candidates, signingBundle.Certificate)
+		if signingBundle.IssuingCA != nil {
+			candidates = append(candidates, signingBundle.IssuingCA)
+		}
+	}
+
+	var issuer *x509.Certificate
+	for _, cert := range candidates {
+		if cert.CheckCRLSignature(crl) == nil {
+			issuer = cert
+			break
+		}
+	}
+	if issuer == nil {
+		return logical.ErrorResponse("the CRL signature could not be verified against a known CA certificate"), nil
+	}
+
+	// Refuse to replace a CRL with an older one, which would undo
+	// revocations
+	existing, err := fetchExternalCRL(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		current, err := x509.ParseDERCRL(existing.CRLBytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse stored CRL: %v", err)
+		}
+		if err := checkCRLNewer(crl, current); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON("crl-external/"+name, &externalCRLEntry{
+		CRLBytes:    crlBytes,
+		IssuerBytes: issuer.Raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	if crl.HasExpired(time.Now()) {
+		resp := &logical.Response{}
+		resp.AddWarning("The CRL is past its next update time; import a newer one")
+		return resp, nil
+	}
+	return nil, nil
+}
+
+func (b *backend) pathExternalCRLDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	err := req.Storage.Delete("crl-external/" + data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathFetchCRLBundle(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var buf bytes.Buffer
+
+	// Errors are logged rather than returned, as the raw response cannot
+	// carry them, and the other CRLs are still of use
+	crlEntry, err := req.Storage.Get("crl")
+	if err != nil {
+		b.Logger().Printf("[ERR] pki: unable to fetch CRL: %v", err)
+	}
+	if crlEntry != nil && len(crlEntry.Value) > 0 {
+		pem.Encode(&buf, &pem.Block{Type: "X509 CRL", Bytes: crlEntry.Value})
+	}
+
+	names, err := req.Storage.List("crl-external/")
+	if err != nil {
+		b.Logger().Printf("[ERR] pki: unable to list imported CRLs: %v", err)
+	}
+	for _, name := range names {
+		entry, err := fetchExternalCRL(req.Storage, name)
+		if err != nil {
+			b.Logger().Printf("[ERR] pki: unable to fetch imported CRL %s: %v", name, err)
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+		pem.Encode(&buf, &pem.Block{Type: "X509 CRL", Bytes: entry.CRLBytes})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/x-pem-file",
+			logical.HTTPRawBody:     buf.Bytes(),
+			logical.HTTPStatusCode:  200,
+		},
+	}, nil
+}
+
+// decodeCRLInput accepts a PEM encoded or base64 encoded DER CRL
+func decodeCRLInput(input string) ([]byte, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("the \"crl\" parameter is empty")
+	}
+
+	if block, _ := pem.Decode([]byte(input)); block != nil {
+		if block.Type != "X509 CRL" {
+			return nil, fmt.Errorf("expected a PEM block of type X509 CRL, got %s", block.Type)
+		}
+		return block.Bytes, nil
+	}
+
+	der, err := base64.StdEncoding.DecodeString(input)
+	if err != nil {
+		return nil, fmt.Errorf("the CRL is neither PEM nor base64 encoded DER")
+	}
+	return der, nil
+}
+
+// checkCRLNewer returns an error unless the CRL was issued after the
+// current one, going by their update times and their CRL numbers if both
+// have one
+func checkCRLNewer(crl, current *pkix.CertificateList) error {
+	if !crl.TBSCertList.ThisUpdate.After(current.TBSCertList.ThisUpdate) {
+		return fmt.Errorf("the CRL was not issued after the one already imported, at %s",
+			current.TBSCertList.ThisUpdate.UTC().Format(time.RFC3339))
+	}
+	number, currentNumber := crlNumber(crl), crlNumber(current)
+	if number != nil && currentNumber != nil && number.Cmp(currentNumber) <= 0 {
+		return fmt.Errorf("the CRL number %s is not greater than that of the one already imported, %s",
+			number, currentNumber)
+	}
+	return nil
+}
+
+// crlNumber returns the CRL number extension of the CRL, or nil if it has
+// none
+func crlNumber(crl *pkix.CertificateList) *big.Int {
+	for _, ext := range crl.TBSCertList.Extensions {
+		if !ext.Id.Equal(oidExtensionCRLNumber) {
+			continue
+		}
+		number := new(big.Int)
+		if _, err := asn1.Unmarshal(ext.Value, &number); err == nil {
+			return number
+		}
+	}
+	return nil
+}
+
+// crlIssuerName returns the issuer of the CRL in a readable form
+func crlIssuerName(crl *pkix.CertificateList) string {
+	var name pkix.Name
+	name.FillFromRDNSequence(&crl.TBSCertList.Issuer)
+	return name.String()
+}
+
+const pathExternalCRLHelpSyn = `
+Import CRLs issued by other CAs to serve them from this backend.
+`
+
+const pathExternalCRLHelpDesc = `
+CRLs written here are served by "crl/bundle" along with the CRL of this
+backend, so that tooling can fetch revocation data for a whole CA hierarchy,
+such as one with an offline root, from a single URL.
+
+The signature of a CRL is verified before it is stored, against the given
+"issuer_certificate", the CA certificate of this backend, or the CA that
+issued it. Imported CRLs are replaced by writing a newer CRL under the same
+name. A CRL that was not issued after the one it replaces, by its update
+time or CRL number, is refused.
+`
+
+const pathFetchCRLBundleHelpSyn = `
+Fetch the CRL of this backend and every imported CRL.
+`
+
+const pathFetchCRLBundleHelpDesc = `
+This endpoint returns the CRL of this backend followed by the CRLs imported
+at "crl/external/", PEM encoded and concatenated. It does not require a token.
+
+The bundle is meant for tooling that reads PEM CRLs. It is not suitable as a
+CRL distribution point in certificates, as clients expect a single DER encoded
+CRL there; use "crl" for the CRL of this backend.
+`
@@ -114,7 +114,7 @@ func (b *backend) pathFetchRead(req *logical.Request, data *framework.FieldData)
 		goto reply
 	}
 
-	_, funcErr = fetchCAInfo(req)
+	_, funcErr = fetchCAInfo(b, req)
 	switch funcErr.(type) {
 	case certutil.UserError:
 		response = logical.ErrorResponse(fmt.Sprintf("%s", funcErr))
@@ -133,6 +133,10 @@ func (b *backend) pathFetchRead(req *logical.Request, data *framework.FieldData)
 		retErr = funcErr
 		goto reply
 	}
+	if certEntry == nil {
+		response, retErr = framework.NotFound(fmt.Sprintf("certificate with serial number %s not found", serial))
+		goto reply
+	}
 
 	certificate = certEntry.Value
 
@@ -157,6 +161,7 @@ reply:
 		}
 		retErr = nil
 		response.Data[logical.HTTPStatusCode] = 200
+	case response.IsError():
 	case retErr != nil:
 		response = nil
 	default:
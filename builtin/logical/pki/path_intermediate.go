@@ -1,6 +1,7 @@
 package pki
 
 import (
+	"crypto"
 	"encoding/base64"
 	"fmt"
 
@@ -56,13 +57,36 @@ func (b *backend) pathGenerateIntermediate(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	var err error
 
-	exported, format, role, errorResp := b.getGenerationParams(data)
+	exported, useKMS, format, role, errorResp := b.getGenerationParams(data)
 	if errorResp != nil {
 		return errorResp, nil
 	}
 
+	// A key created in the KMS is only of use once it is stored as the CA
+	// key, so its deletion is scheduled if anything fails before that
+	var key crypto.Signer
+	var kmsKey *kmsKeyEntry
+	var kmsKeyStored bool
+	if useKMS {
+		signer, err := createKMSKey(req.Storage, role, data.Get("common_name").(string))
+		if err != nil {
+			switch err.(type) {
+			case certutil.UserError:
+				return logical.ErrorResponse(err.Error()), nil
+			default:
+				return nil, err
+			}
+		}
+		key, kmsKey = signer, signer.key
+		defer func() {
+			if !kmsKeyStored {
+				b.deleteKMSKey(signer)
+			}
+		}()
+	}
+
 	var resp *logical.Response
-	parsedBundle, err := generateIntermediateCSR(b, role, nil, req, data)
+	parsedBundle, err := generateIntermediateCSR(b, role, nil, key, req, data)
 	if err != nil {
 		switch err.(type) {
 		case certutil.UserError:
@@ -96,9 +120,16 @@ func (b *backend) pathGenerateIntermediate(
 		}
 	}
 
+	if kmsKey != nil {
+		resp.Data["kms_key_id"] = kmsKey.KeyID
+	}
+
 	cb := &certutil.CertBundle{}
 	cb.PrivateKey = csrb.PrivateKey
 	cb.PrivateKeyType = csrb.PrivateKeyType
+	if kmsKey != nil {
+		cb.PrivateKeyType = certutil.PrivateKeyType(kmsKey.KeyType)
+	}
 
 	entry, err := logical.StorageEntryJSON("config/ca_bundle", cb)
 	if err != nil {
@@ -108,6 +139,11 @@ func (b *backend) pathGenerateIntermediate(
 	if err != nil {
 		return nil, err
 	}
+	err = storeKMSKey(req.Storage, kmsKey)
+	if err != nil {
+		return nil, err
+	}
+	kmsKeyStored = true
 
 	return resp, nil
 }
@@ -157,16 +193,37 @@ func (b *backend) pathSetSignedIntermediate(
 		return nil, err
 	}
 
-	if len(cb.PrivateKey) == 0 || cb.PrivateKeyType == "" {
-		return logical.ErrorResponse("could not find an existing privat key"), nil
-	}
-
-	parsedCB, err := cb.ToParsedCertBundle()
+	kmsKey, err := fetchKMSKey(req.Storage)
 	if err != nil {
 		return nil, err
 	}
-	if parsedCB.PrivateKey == nil {
-		return nil, fmt.Errorf("saved key could not be parsed successfully")
+
+	var parsedCB *certutil.ParsedCertBundle
+	if kmsKey != nil && len(cb.PrivateKey) == 0 {
+		// The key was generated in the KMS
+		signer, err := b.kmsSignerFor(req.Storage, kmsKey)
+		if err != nil {
+			switch err.(type) {
+			case certutil.UserError:
+				return logical.ErrorResponse(err.Error()), nil
+			default:
+				return nil, err
+			}
+		}
+		parsedCB = &certutil.ParsedCertBundle{}
+		parsedCB.SetParsedPrivateKey(signer, certutil.PrivateKeyType(kmsKey.KeyType), nil)
+	} else {
+		if len(cb.PrivateKey) == 0 || cb.PrivateKeyType == "" {
+			return logical.ErrorResponse("could not find an existing privat key"), nil
+		}
+
+		parsedCB, err = cb.ToParsedCertBundle()
+		if err != nil {
+			return nil, err
+		}
+		if parsedCB.PrivateKey == nil {
+			return nil, fmt.Errorf("saved key could not be parsed successfully")
+		}
 	}
 
 	equal, err := certutil.ComparePublicKeys(parsedCB.PrivateKey.Public(), inputBundle.Certificate.PublicKey)
@@ -87,10 +87,10 @@ func (b *backend) pathIssue(
 		return nil, err
 	}
 	if role == nil {
-		return logical.ErrorResponse(fmt.Sprintf("Unknown role: %s", roleName)), nil
+		return framework.NotFound(fmt.Sprintf("Unknown role: %s", roleName))
 	}
 
-	return b.pathIssueSignCert(req, data, role, false, false)
+	return b.pathIssueSignCert(req, data, roleName, role, false, false)
 }
 
 // pathSign issues a certificate from a submitted CSR, subject to role
@@ -105,10 +105,10 @@ func (b *backend) pathSign(
 		return nil, err
 	}
 	if role == nil {
-		return logical.ErrorResponse(fmt.Sprintf("Unknown role: %s", roleName)), nil
+		return framework.NotFound(fmt.Sprintf("Unknown role: %s", roleName))
 	}
 
-	return b.pathIssueSignCert(req, data, role, true, false)
+	return b.pathIssueSignCert(req, data, roleName, role, true, false)
 }
 
 // pathSignVerbatim issues a certificate from a submitted CSR, *not* subject to
@@ -123,21 +123,28 @@ func (b *backend) pathSignVerbatim(
 		AllowAnyName:     true,
 		AllowIPSANs:      true,
 		EnforceHostnames: false,
+
+		AllowedMetadataKeys: "*",
 	}
 
-	return b.pathIssueSignCert(req, data, role, true, true)
+	return b.pathIssueSignCert(req, data, "", role, true, true)
 }
 
 func (b *backend) pathIssueSignCert(
-	req *logical.Request, data *framework.FieldData, role *roleEntry, useCSR, useCSRValues bool) (*logical.Response, error) {
+	req *logical.Request, data *framework.FieldData, roleName string, role *roleEntry, useCSR, useCSRValues bool) (*logical.Response, error) {
 	format := getFormat(data)
 	if format == "" {
 		return logical.ErrorResponse(
 			`The "format" path parameter must be "pem" or "der"`), nil
 	}
 
+	metadata, err := parseCertMetadata(role, data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(req)
+	signingBundle, caErr := fetchCAInfo(b, req)
 	switch caErr.(type) {
 	case certutil.UserError:
 		return nil, certutil.UserError{Err: fmt.Sprintf(
@@ -148,11 +155,10 @@ func (b *backend) pathIssueSignCert(
 	}
 
 	var parsedBundle *certutil.ParsedCertBundle
-	var err error
 	if useCSR {
 		parsedBundle, err = signCert(b, role, signingBundle, false, useCSRValues, req, data)
 	} else {
-		parsedBundle, err = generateCert(b, role, signingBundle, false, req, data)
+		parsedBundle, err = generateCert(b, role, signingBundle, false, nil, req, data)
 	}
 	if err != nil {
 		switch err.(type) {
@@ -193,6 +199,14 @@ func (b *backend) pathIssueSignCert(
 
 	resp.Secret.TTL = parsedBundle.Certificate.NotAfter.Sub(time.Now())
 
+	warnings, err := b.caExpiryWarnings(req, signingBundle, parsedBundle.Certificate.NotAfter)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+
 	err = req.Storage.Put(&logical.StorageEntry{
 		Key:   "certs/" + cb.SerialNumber,
 		Value: parsedBundle.CertificateBytes,
@@ -201,6 +215,23 @@ func (b *backend) pathIssueSignCert(
 		return nil, fmt.Errorf("Unable to store certificate locally")
 	}
 
+	if metadata != nil {
+		entry, err := logical.StorageEntryJSON("cert-metadata/"+cb.SerialNumber, &certMetadataEntry{
+			SerialNumber: cb.SerialNumber,
+			Role:         roleName,
+			CommonName:   parsedBundle.Certificate.Subject.CommonName,
+			IssueTime:    parsedBundle.Certificate.NotBefore,
+			Expiration:   parsedBundle.Certificate.NotAfter,
+			Metadata:     metadata,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Storage.Put(entry); err != nil {
+			return nil, fmt.Errorf("Unable to store certificate metadata locally")
+		}
+	}
+
 	return resp, nil
 }
 
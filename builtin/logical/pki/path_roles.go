@@ -142,6 +142,15 @@ the common name in the CSR will be used. This
 does *not* include any requested Subject Alternative
 Names. Defaults to true.`,
 			},
+
+			"allowed_metadata_keys": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "",
+				Description: `A comma-separated list of metadata keys that
+callers may attach to certificates issued by this
+role, e.g. "owner,ticket_id". "*" allows any key.
+If empty, metadata may not be supplied.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -283,6 +292,7 @@ func (b *backend) pathRoleCreate(
 		KeyType:             data.Get("key_type").(string),
 		KeyBits:             data.Get("key_bits").(int),
 		UseCSRCommonName:    data.Get("use_csr_common_name").(bool),
+		AllowedMetadataKeys: data.Get("allowed_metadata_keys").(string),
 	}
 
 	var maxTTL time.Duration
@@ -358,6 +368,7 @@ type roleEntry struct {
 	UseCSRCommonName      bool   `json:"use_csr_common_name" structs:"use_csr_common_name" mapstructure:"use_csr_common_name"`
 	KeyType               string `json:"key_type" structs:"key_type" mapstructure:"key_type"`
 	KeyBits               int    `json:"key_bits" structs:"key_bits" mapstructure:"key_bits"`
+	AllowedMetadataKeys   string `json:"allowed_metadata_keys" structs:"allowed_metadata_keys" mapstructure:"allowed_metadata_keys"`
 	MaxPathLength         *int   `json:",omitempty" structs:",omitempty"`
 }
 
@@ -1,6 +1,7 @@
 package pki
 
 import (
+	"crypto"
 	"encoding/base64"
 	"fmt"
 	"time"
@@ -71,7 +72,7 @@ func (b *backend) pathCAGenerateRoot(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	var err error
 
-	exported, format, role, errorResp := b.getGenerationParams(data)
+	exported, useKMS, format, role, errorResp := b.getGenerationParams(data)
 	if errorResp != nil {
 		return errorResp, nil
 	}
@@ -82,7 +83,30 @@ func (b *backend) pathCAGenerateRoot(
 		role.MaxPathLength = &maxPathLength
 	}
 
-	parsedBundle, err := generateCert(b, role, nil, true, req, data)
+	// A key created in the KMS is only of use once it is stored as the CA
+	// key, so its deletion is scheduled if anything fails before that
+	var key crypto.Signer
+	var kmsKey *kmsKeyEntry
+	var kmsKeyStored bool
+	if useKMS {
+		signer, err := createKMSKey(req.Storage, role, data.Get("common_name").(string))
+		if err != nil {
+			switch err.(type) {
+			case certutil.UserError:
+				return logical.ErrorResponse(err.Error()), nil
+			default:
+				return nil, err
+			}
+		}
+		key, kmsKey = signer, signer.key
+		defer func() {
+			if !kmsKeyStored {
+				b.deleteKMSKey(signer)
+			}
+		}()
+	}
+
+	parsedBundle, err := generateCert(b, role, nil, true, key, req, data)
 	if err != nil {
 		switch err.(type) {
 		case certutil.UserError:
@@ -123,6 +147,10 @@ func (b *backend) pathCAGenerateRoot(
 		}
 	}
 
+	if kmsKey != nil {
+		resp.Data["kms_key_id"] = kmsKey.KeyID
+	}
+
 	resp.Secret.TTL = parsedBundle.Certificate.NotAfter.Sub(time.Now())
 
 	// Store it as the CA bundle
@@ -134,6 +162,11 @@ func (b *backend) pathCAGenerateRoot(
 	if err != nil {
 		return nil, err
 	}
+	err = storeKMSKey(req.Storage, kmsKey)
+	if err != nil {
+		return nil, err
+	}
+	kmsKeyStored = true
 
 	// Also store it as just the certificate identified by serial number, so it
 	// can be revoked
@@ -191,7 +224,7 @@ func (b *backend) pathCASignIntermediate(
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(req)
+	signingBundle, caErr := fetchCAInfo(b, req)
 	switch caErr.(type) {
 	case certutil.UserError:
 		return nil, certutil.UserError{Err: fmt.Sprintf(
@@ -61,20 +61,15 @@ func (b *backend) DB(s logical.Storage) (*sql.DB, error) {
 	}
 
 	// Otherwise, attempt to make connection
-	entry, err := s.Get("config/connection")
+	connConfig, err := b.ConnectionConfig(s)
 	if err != nil {
 		return nil, err
 	}
-	if entry == nil {
+	if connConfig == nil {
 		return nil,
 			fmt.Errorf("configure the DB connection with config/connection first")
 	}
 
-	var connConfig connectionConfig
-	if err := entry.DecodeJSON(&connConfig); err != nil {
-		return nil, err
-	}
-
 	conn := connConfig.ConnectionString
 	if len(conn) == 0 {
 		conn = connConfig.ConnectionURL
@@ -102,6 +97,24 @@ func (b *backend) DB(s logical.Storage) (*sql.DB, error) {
 	return b.db, nil
 }
 
+// ConnectionConfig returns the connection configuration, or nil if the
+// connection has not been configured
+func (b *backend) ConnectionConfig(s logical.Storage) (*connectionConfig, error) {
+	entry, err := s.Get("config/connection")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var connConfig connectionConfig
+	if err := entry.DecodeJSON(&connConfig); err != nil {
+		return nil, err
+	}
+	return &connConfig, nil
+}
+
 // ResetDB forces a connection next time DB() is called.
 func (b *backend) ResetDB() {
 	b.lock.Lock()
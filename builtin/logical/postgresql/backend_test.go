@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"testing"
 
@@ -44,6 +45,33 @@ func TestBackend_roleCrud(t *testing.T) {
 	})
 }
 
+func TestBackend_revokeNonTableGrants(t *testing.T) {
+	b, _ := Factory(logical.TestBackendConfig())
+
+	logicaltest.Test(t, logicaltest.TestCase{
+		PreCheck: func() { testAccPreCheck(t) },
+		Backend:  b,
+		Steps: []logicaltest.TestStep{
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "config/connection",
+				Data: map[string]interface{}{
+					"value":                        os.Getenv("PG_URL"),
+					"terminate_sessions_on_revoke": true,
+				},
+			},
+			logicaltest.TestStep{
+				Operation: logical.UpdateOperation,
+				Path:      "roles/web",
+				Data: map[string]interface{}{
+					"sql": testRoleNonTableGrants,
+				},
+			},
+			testAccStepRevokeCreds(t, b, "web"),
+		},
+	})
+}
+
 func testAccPreCheck(t *testing.T) {
 	if v := os.Getenv("PG_URL"); v == "" {
 		t.Fatal("PG_URL must be set for acceptance tests")
@@ -158,6 +186,90 @@ func testAccStepReadCreds(t *testing.T, b logical.Backend, name string) logicalt
 	}
 }
 
+func testAccStepRevokeCreds(t *testing.T, b logical.Backend, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "creds/" + name,
+		Check: func(resp *logical.Response) error {
+			var d struct {
+				Username string `mapstructure:"username"`
+				Password string `mapstructure:"password"`
+			}
+			if err := mapstructure.Decode(resp.Data, &d); err != nil {
+				return err
+			}
+
+			u, err := url.Parse(os.Getenv("PG_URL"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			adminConn, err := pq.ParseURL(u.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			admin, err := sql.Open("postgres", adminConn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer admin.Close()
+
+			// Open a session as the user, which should be terminated
+			u.User = url.UserPassword(d.Username, d.Password)
+			userConn, err := pq.ParseURL(u.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			user, err := sql.Open("postgres", userConn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer user.Close()
+			user.SetMaxOpenConns(1)
+			if err := user.Ping(); err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err = b.HandleRequest(&logical.Request{
+				Operation: logical.RevokeOperation,
+				Secret: &logical.Secret{
+					InternalData: map[string]interface{}{
+						"secret_type": "creds",
+						"username":    d.Username,
+					},
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if resp != nil && resp.IsError() {
+				return fmt.Errorf("Error on resp: %#v", *resp)
+			}
+
+			var exists bool
+			err = admin.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1);",
+				d.Username).Scan(&exists)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists {
+				t.Fatalf("role %s should have been dropped", d.Username)
+			}
+
+			var sessions int
+			err = admin.QueryRow("SELECT count(*) FROM pg_stat_activity WHERE usename = $1;",
+				d.Username).Scan(&sessions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sessions != 0 {
+				t.Fatalf("bad: %d sessions remain", sessions)
+			}
+
+			return nil
+		},
+	}
+}
+
 func testAccStepReadRole(t *testing.T, name string, sql string) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.ReadOperation,
@@ -194,3 +306,15 @@ CREATE ROLE "{{name}}" WITH
   VALID UNTIL '{{expiration}}';
 GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO "{{name}}";
 `
+
+const testRoleNonTableGrants = `
+CREATE ROLE "{{name}}" WITH
+  LOGIN
+  PASSWORD '{{password}}'
+  VALID UNTIL '{{expiration}}';
+GRANT USAGE ON SCHEMA public TO "{{name}}";
+GRANT ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public TO "{{name}}";
+GRANT EXECUTE ON ALL FUNCTIONS IN SCHEMA public TO "{{name}}";
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO "{{name}}";
+ALTER DEFAULT PRIVILEGES GRANT USAGE ON SEQUENCES TO "{{name}}";
+`
@@ -39,7 +39,7 @@ func (b *backend) pathRoleCreateRead(
 		return nil, err
 	}
 	if role == nil {
-		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", name)), nil
+		return framework.NotFound(fmt.Sprintf("unknown role: %s", name))
 	}
 
 	// Determine if we have a lease
@@ -1,9 +1,11 @@
 package postgresql
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	"github.com/lib/pq"
@@ -101,70 +103,228 @@ func (b *backend) secretCredsRevoke(
 		return nil, err
 	}
 
+	connConfig, err := b.ConnectionConfig(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Errors along the way are collected rather than returned right away,
+	// as we want to remove as much access as possible, and the role is
+	// dropped in any case
+	var merr *multierror.Error
+
+	// Lock the user out and end its sessions first if requested, as
+	// dropping the role does not end sessions that are already open
+	if connConfig != nil && connConfig.TerminateSessionsOnRevoke {
+		if err := terminateSessions(db, username); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf(
+				"could not terminate sessions: %v", err))
+		}
+	}
+
 	// Query for permissions; we need to revoke permissions before we can drop
 	// the role
 	// This isn't done in a transaction because even if we fail along the way,
 	// we want to remove as much access as possible
-	stmt, err := db.Prepare(fmt.Sprintf(
-		"SELECT DISTINCT table_schema FROM information_schema.role_column_grants WHERE grantee='%s';",
-		username))
+	revocationStmts, err := schemaRevocationStmts(db, username)
 	if err != nil {
-		return nil, err
+		merr = multierror.Append(merr, fmt.Errorf(
+			"could not generate revocation statements for all schemas: %v", err))
 	}
-	defer stmt.Close()
-
-	rows, err := stmt.Query()
+	defaultStmts, err := defaultPrivilegesRevocationStmts(db, username)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var revocationStmts []string
-	for rows.Next() {
-		var schema string
-		err = rows.Scan(&schema)
-		if err != nil {
-			// keep going; remove as many permissions as possible right now
-			continue
-		}
-		revocationStmts = append(revocationStmts, fmt.Sprintf(
-			"REVOKE ALL PRIVILEGES ON ALL TABLES IN SCHEMA %s FROM %s;",
-			schema, pq.QuoteIdentifier(username)))
+		merr = multierror.Append(merr, fmt.Errorf(
+			"could not generate revocation statements for all default privileges: %v", err))
 	}
+	revocationStmts = append(revocationStmts, defaultStmts...)
 
 	// again, here, we do not stop on error, as we want to remove as
 	// many permissions as possible right now
-	var lastStmtError error
 	for _, query := range revocationStmts {
 		stmt, err := db.Prepare(query)
 		if err != nil {
-			lastStmtError = err
+			merr = multierror.Append(merr, err)
 			continue
 		}
 		_, err = stmt.Exec()
+		stmt.Close()
 		if err != nil {
-			lastStmtError = err
+			merr = multierror.Append(merr, err)
 		}
 	}
 
-	// can't drop if not all privileges are revoked
-	if rows.Err() != nil {
-		return logical.ErrorResponse(fmt.Sprintf("could not generate revocation statements for all rows: %v", rows.Err())), nil
-	}
-	if lastStmtError != nil {
-		return logical.ErrorResponse(fmt.Sprintf("could not perform all revocation statements: %v", lastStmtError)), nil
+	// Drop this user. This fails if any privileges are left, which the
+	// errors above then explain.
+	if err := dropRole(db, username); err != nil {
+		merr = multierror.Append(merr, fmt.Errorf(
+			"could not drop role: %v", err))
 	}
 
-	// Drop this user
-	stmt, err = db.Prepare(fmt.Sprintf(
+	return nil, merr.ErrorOrNil()
+}
+
+// dropRole drops the user if it exists
+func dropRole(db *sql.DB, username string) error {
+	stmt, err := db.Prepare(fmt.Sprintf(
 		"DROP ROLE IF EXISTS %s;", pq.QuoteIdentifier(username)))
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec()
+	return err
+}
+
+// terminateSessions keeps the user from logging in again and ends its open
+// sessions. It does nothing if the user no longer exists.
+func terminateSessions(db *sql.DB, username string) error {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1);",
+		username).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	stmt, err := db.Prepare(fmt.Sprintf(
+		"ALTER ROLE %s NOLOGIN;", pq.QuoteIdentifier(username)))
+	if err != nil {
+		return err
 	}
 	defer stmt.Close()
 	if _, err := stmt.Exec(); err != nil {
+		return err
+	}
+
+	stmt, err = db.Prepare(terminateSessionsSQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(username)
+	return err
+}
+
+// schemaRevocationStmts returns the statements revoking the privileges of
+// the user on tables, sequences, functions and the schemas themselves, for
+// every schema in which the user has been granted any of them
+func schemaRevocationStmts(db *sql.DB, username string) ([]string, error) {
+	stmt, err := db.Prepare(grantedSchemasSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revocationStmts []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		for _, objects := range []string{
+			"ALL TABLES IN SCHEMA",
+			"ALL SEQUENCES IN SCHEMA",
+			"ALL FUNCTIONS IN SCHEMA",
+			"SCHEMA",
+		} {
+			revocationStmts = append(revocationStmts, fmt.Sprintf(
+				"REVOKE ALL PRIVILEGES ON %s %s FROM %s;",
+				objects, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(username)))
+		}
+	}
+	return revocationStmts, rows.Err()
+}
+
+// defaultPrivilegesRevocationStmts returns the statements revoking the
+// default privileges granted to the user, in the schema they were granted
+// in if any, as these also keep the role from being dropped
+func defaultPrivilegesRevocationStmts(db *sql.DB, username string) ([]string, error) {
+	stmt, err := db.Prepare(defaultPrivilegesSQL)
+	if err != nil {
 		return nil, err
 	}
+	defer stmt.Close()
 
-	return nil, nil
+	rows, err := stmt.Query(username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revocationStmts []string
+	for rows.Next() {
+		var owner, objType string
+		var schema sql.NullString
+		if err := rows.Scan(&owner, &schema, &objType); err != nil {
+			return nil, err
+		}
+		objects, ok := defaultACLObjectTypes[objType]
+		if !ok {
+			return nil, fmt.Errorf("unknown default privileges object type %q", objType)
+		}
+
+		inSchema := ""
+		if schema.Valid {
+			inSchema = " IN SCHEMA " + pq.QuoteIdentifier(schema.String)
+		}
+		revocationStmts = append(revocationStmts, fmt.Sprintf(
+			"ALTER DEFAULT PRIVILEGES FOR ROLE %s%s REVOKE ALL PRIVILEGES ON %s FROM %s;",
+			pq.QuoteIdentifier(owner), inSchema, objects, pq.QuoteIdentifier(username)))
+	}
+	return revocationStmts, rows.Err()
 }
+
+// defaultACLObjectTypes maps the object types of pg_default_acl to the
+// object kinds of ALTER DEFAULT PRIVILEGES
+var defaultACLObjectTypes = map[string]string{
+	"r": "TABLES",
+	"S": "SEQUENCES",
+	"f": "FUNCTIONS",
+	"T": "TYPES",
+	"n": "SCHEMAS",
+}
+
+// grantedSchemasSQL finds the schemas in which the user has been granted
+// privileges on a relation, column, function or the schema itself
+const grantedSchemasSQL = `
+SELECT DISTINCT nspname FROM (
+	SELECT n.nspname, (aclexplode(c.relacl)).grantee AS grantee
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+	UNION ALL
+	SELECT n.nspname, (aclexplode(a.attacl)).grantee AS grantee
+		FROM pg_attribute a JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+	UNION ALL
+	SELECT n.nspname, (aclexplode(p.proacl)).grantee AS grantee
+		FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+	UNION ALL
+	SELECT n.nspname, (aclexplode(n.nspacl)).grantee AS grantee
+		FROM pg_namespace n
+) AS grants
+WHERE grantee = (SELECT oid FROM pg_roles WHERE rolname = $1);
+`
+
+// defaultPrivilegesSQL finds the default privileges granted to the user,
+// with the role they apply to and the schema, which is null if they apply
+// to the whole database
+const defaultPrivilegesSQL = `
+SELECT DISTINCT owner, nspname, objtype FROM (
+	SELECT pg_get_userbyid(d.defaclrole) AS owner, n.nspname,
+		d.defaclobjtype::text AS objtype, (aclexplode(d.defaclacl)).grantee AS grantee
+		FROM pg_default_acl d LEFT JOIN pg_namespace n ON n.oid = d.defaclnamespace
+) AS grants
+WHERE grantee = (SELECT oid FROM pg_roles WHERE rolname = $1);
+`
+
+// terminateSessionsSQL ends every session of the user
+const terminateSessionsSQL = `
+SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE usename = $1;
+`
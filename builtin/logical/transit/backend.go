@@ -15,6 +15,7 @@ func Backend() *framework.Backend {
 		PathsSpecial: &logical.Paths{
 			Root: []string{
 				"keys/*",
+				"deleted/*",
 			},
 		},
 
@@ -28,9 +29,14 @@ func Backend() *framework.Backend {
 			pathEncrypt(),
 			pathDecrypt(),
 			pathDatakey(),
+			pathListDeleted(),
+			pathRestore(),
+			pathDeleted(),
 		},
 
 		Secrets: []*framework.Secret{},
+
+		PeriodicFunc: purgeDeletedPolicies,
 	}
 
 	return b.Backend
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 	logicaltest "github.com/hashicorp/vault/logical/testing"
@@ -28,6 +29,7 @@ func TestBackend_basic(t *testing.T) {
 			testAccStepDeleteNotDisabledPolicy(t, "test"),
 			testAccStepEnableDeletion(t, "test"),
 			testAccStepDeletePolicy(t, "test"),
+			testAccStepPurgePolicy(t, "test"),
 			testAccStepWritePolicy(t, "test", false),
 			testAccStepEnableDeletion(t, "test"),
 			testAccStepDisableDeletion(t, "test"),
@@ -39,6 +41,77 @@ func TestBackend_basic(t *testing.T) {
 	})
 }
 
+func TestBackend_readConfig(t *testing.T) {
+	var configJSON string
+	logicaltest.Test(t, logicaltest.TestCase{
+		Backend: Backend(),
+		Steps: []logicaltest.TestStep{
+			testAccStepWritePolicy(t, "test", false),
+			testAccStepReadConfig(t, "test", func(resp *logical.Response) error {
+				if resp.Data["latest_version"] != 1 || resp.Data["deletion_allowed"] != false {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if resp.Data["kdf_mode"] != "" {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				configJSON = resp.Data["config_json"].(string)
+				return nil
+			}),
+			testAccStepReadConfig(t, "test", func(resp *logical.Response) error {
+				if resp.Data["config_json"] != configJSON {
+					return fmt.Errorf("config_json changed: %s", resp.Data["config_json"])
+				}
+				return nil
+			}),
+
+			// Rotating the key is not a change of its configuration
+			testAccStepRotate(t, "test"),
+			testAccStepReadConfig(t, "test", func(resp *logical.Response) error {
+				if resp.Data["latest_version"] != 2 {
+					return fmt.Errorf("bad: %#v", resp.Data)
+				}
+				if resp.Data["config_json"] != configJSON {
+					return fmt.Errorf("config_json changed: %s", resp.Data["config_json"])
+				}
+				return nil
+			}),
+
+			testAccStepEnableDeletion(t, "test"),
+			testAccStepReadConfig(t, "test", func(resp *logical.Response) error {
+				if resp.Data["config_json"] == configJSON {
+					return fmt.Errorf("config_json did not change")
+				}
+				ops := resp.Data["allowed_operations"].([]string)
+				if ops[len(ops)-1] != "delete" {
+					return fmt.Errorf("bad: %#v", ops)
+				}
+				return nil
+			}),
+		},
+	})
+}
+
+func TestBackend_restore(t *testing.T) {
+	decryptData := make(map[string]interface{})
+	logicaltest.Test(t, logicaltest.TestCase{
+		Backend: Backend(),
+		Steps: []logicaltest.TestStep{
+			testAccStepWritePolicy(t, "test", false),
+			testAccStepEncrypt(t, "test", testPlaintext, decryptData),
+			testAccStepEnableDeletion(t, "test"),
+			testAccStepDeletePolicy(t, "test"),
+			testAccStepReadPolicy(t, "test", true, false),
+			testAccStepReadDeletedPolicy(t, "test"),
+			testAccStepWritePolicyExpectFailure(t, "test"),
+			testAccStepRestorePolicy(t, "test"),
+			testAccStepDecrypt(t, "test", testPlaintext, decryptData),
+			testAccStepDeletePolicy(t, "test"),
+			testAccStepPurgePolicy(t, "test"),
+			testAccStepWritePolicy(t, "test", false),
+		},
+	})
+}
+
 func TestBackend_datakey(t *testing.T) {
 	dataKeyInfo := make(map[string]interface{})
 	logicaltest.Test(t, logicaltest.TestCase{
@@ -190,6 +263,55 @@ func testAccStepDeletePolicy(t *testing.T, name string) logicaltest.TestStep {
 	}
 }
 
+func testAccStepPurgePolicy(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.DeleteOperation,
+		Path:      "deleted/" + name,
+	}
+}
+
+func testAccStepRestorePolicy(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "deleted/" + name + "/restore",
+	}
+}
+
+func testAccStepReadDeletedPolicy(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "deleted/" + name,
+		Check: func(resp *logical.Response) error {
+			if resp == nil {
+				return fmt.Errorf("missing response")
+			}
+			deleted, ok := resp.Data["deletion_time"].(time.Time)
+			if !ok {
+				return fmt.Errorf("bad: %#v", resp.Data)
+			}
+			purge, ok := resp.Data["purge_time"].(time.Time)
+			if !ok || purge.Sub(deleted) != DefaultRestoreWindow {
+				return fmt.Errorf("bad: %#v", resp.Data)
+			}
+			return nil
+		},
+	}
+}
+
+func testAccStepWritePolicyExpectFailure(t *testing.T, name string) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/" + name,
+		ErrorOk:   true,
+		Check: func(resp *logical.Response) error {
+			if resp == nil || !resp.IsError() {
+				return fmt.Errorf("expected error but did not get one")
+			}
+			return nil
+		},
+	}
+}
+
 func testAccStepDeleteNotDisabledPolicy(t *testing.T, name string) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.DeleteOperation,
@@ -207,6 +329,14 @@ func testAccStepDeleteNotDisabledPolicy(t *testing.T, name string) logicaltest.T
 	}
 }
 
+func testAccStepReadConfig(t *testing.T, name string, check logicaltest.TestCheckFunc) logicaltest.TestStep {
+	return logicaltest.TestStep{
+		Operation: logical.ReadOperation,
+		Path:      "keys/" + name + "/config",
+		Check:     check,
+	}
+}
+
 func testAccStepReadPolicy(t *testing.T, name string, expectNone, derived bool) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.ReadOperation,
@@ -1,7 +1,9 @@
 package transit
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -26,9 +28,16 @@ to be decrypted.`,
 				Type:        framework.TypeBool,
 				Description: "Whether to allow deletion of the key",
 			},
+
+			"restore_window": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `How long the key can be restored for after
+it is deleted. Defaults to 7 days.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   pathConfigRead,
 			logical.UpdateOperation: pathConfigWrite,
 		},
 
@@ -37,6 +46,55 @@ to be decrypted.`,
 	}
 }
 
+func pathConfigRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	policy, err := getPolicy(req, name)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, nil
+	}
+
+	allowedOperations := []string{"encrypt", "decrypt", "rewrap", "datakey", "rotate"}
+	if policy.DeletionAllowed {
+		allowedOperations = append(allowedOperations, "delete")
+	}
+
+	// Every field is always present, unlike when reading the key, so that
+	// configurations can be compared as a whole. The latest version is
+	// left out, as it changes on every rotation.
+	config := map[string]interface{}{
+		"name":                   policy.Name,
+		"cipher_mode":            policy.CipherMode,
+		"derived":                policy.Derived,
+		"kdf_mode":               policy.KDFMode,
+		"min_decryption_version": policy.MinDecryptionVersion,
+		"deletion_allowed":       policy.DeletionAllowed,
+		"restore_window":         int64(policy.restoreWindow().Seconds()),
+		"allowed_operations":     allowedOperations,
+	}
+
+	// Maps are marshaled with sorted keys, so this is canonical
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"config_json":    string(configJSON),
+			"latest_version": len(policy.Keys),
+		},
+	}
+	for k, v := range config {
+		resp.Data[k] = v
+	}
+	return resp, nil
+}
+
 func pathConfigWrite(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	name := d.Get("name").(string)
@@ -70,6 +128,18 @@ func pathConfigWrite(
 		}
 	}
 
+	restoreWindowRaw, ok := d.GetOk("restore_window")
+	if ok {
+		restoreWindow := time.Duration(restoreWindowRaw.(int)) * time.Second
+		if restoreWindow <= 0 {
+			return logical.ErrorResponse("restore_window must be positive"), logical.ErrInvalidRequest
+		}
+		if restoreWindow != policy.RestoreWindow {
+			policy.RestoreWindow = restoreWindow
+			persistNeeded = true
+		}
+	}
+
 	if !persistNeeded {
 		return nil, nil
 	}
@@ -82,5 +152,12 @@ const pathConfigHelpSyn = `Configure a named encryption key`
 const pathConfigHelpDesc = `
 This path is used to configure the named key. Currently, this
 supports adjusting the minimum version of the key allowed to
-be used for decryption via the min_decryption_version paramter.
+be used for decryption via the min_decryption_version paramter,
+whether the key may be deleted via deletion_allowed, and how long
+a deleted key can be restored for via restore_window.
+
+Reading this path returns the full configuration of the key, without
+any key material, along with the same configuration as canonical JSON
+in config_json, which can be compared to detect drift. The latest key
+version is left out of config_json, as rotation changes it.
 `
@@ -0,0 +1,253 @@
+package transit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// DefaultRestoreWindow is how long a deleted key can be restored for
+	// if the key does not configure its own window.
+	DefaultRestoreWindow = 7 * 24 * time.Hour
+)
+
+// deletedPolicy is a key that has been deleted but not yet purged. The
+// policy is kept as it was serialized so that a restore is exact.
+type deletedPolicy struct {
+	Policy       []byte    `json:"policy"`
+	DeletionTime time.Time `json:"deletion_time"`
+	PurgeTime    time.Time `json:"purge_time"`
+}
+
+func pathListDeleted() *framework.Path {
+	return &framework.Path{
+		Pattern: "deleted/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: pathDeletedList,
+		},
+
+		HelpSynopsis:    pathDeletedHelpSyn,
+		HelpDescription: pathDeletedHelpDesc,
+	}
+}
+
+func pathDeleted() *framework.Path {
+	return &framework.Path{
+		Pattern: "deleted/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the deleted key",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   pathDeletedRead,
+			logical.DeleteOperation: pathDeletedPurge,
+		},
+
+		HelpSynopsis:    pathDeletedHelpSyn,
+		HelpDescription: pathDeletedHelpDesc,
+	}
+}
+
+func pathRestore() *framework.Path {
+	return &framework.Path{
+		Pattern: "deleted/" + framework.GenericNameRegex("name") + "/restore",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the deleted key",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: pathRestoreWrite,
+		},
+
+		HelpSynopsis:    pathRestoreHelpSyn,
+		HelpDescription: pathRestoreHelpDesc,
+	}
+}
+
+func pathDeletedList(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	keys, err := req.Storage.List("deleted/")
+	if err != nil {
+		return nil, err
+	}
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, "deleted/")
+	}
+	return logical.ListResponse(keys), nil
+}
+
+func pathDeletedRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	dp, err := getDeletedPolicy(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if dp == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":          name,
+			"deletion_time": dp.DeletionTime,
+			"purge_time":    dp.PurgeTime,
+		},
+	}, nil
+}
+
+func pathDeletedPurge(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	dp, err := getDeletedPolicy(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if dp == nil {
+		return framework.NotFound(fmt.Sprintf("no deleted key %s", name))
+	}
+
+	return nil, req.Storage.Delete("deleted/" + name)
+}
+
+func pathRestoreWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	dp, err := getDeletedPolicy(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if dp == nil || time.Now().After(dp.PurgeTime) {
+		return framework.NotFound(fmt.Sprintf("no deleted key %s", name))
+	}
+
+	existing, err := getPolicy(req, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return framework.InvalidRequest(fmt.Sprintf("a key named %s already exists", name))
+	}
+
+	// Restore the key before removing the deleted copy so that a failure
+	// in between can't lose it
+	err = req.Storage.Put(&logical.StorageEntry{
+		Key:   "policy/" + name,
+		Value: dp.Policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, req.Storage.Delete("deleted/" + name)
+}
+
+// softDeletePolicy moves a key out of use, keeping it so that it can be
+// restored until its restore window has passed.
+func softDeletePolicy(storage logical.Storage, p *Policy) error {
+	buf, err := p.Serialize()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	entry, err := logical.StorageEntryJSON("deleted/"+p.Name, &deletedPolicy{
+		Policy:       buf,
+		DeletionTime: now,
+		PurgeTime:    now.Add(p.restoreWindow()),
+	})
+	if err != nil {
+		return err
+	}
+	if err := storage.Put(entry); err != nil {
+		return err
+	}
+
+	return storage.Delete("policy/" + p.Name)
+}
+
+// restoreWindow returns how long the key can be restored after deletion.
+func (p *Policy) restoreWindow() time.Duration {
+	if p.RestoreWindow == 0 {
+		return DefaultRestoreWindow
+	}
+	return p.RestoreWindow
+}
+
+func getDeletedPolicy(storage logical.Storage, name string) (*deletedPolicy, error) {
+	entry, err := storage.Get("deleted/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var dp deletedPolicy
+	if err := entry.DecodeJSON(&dp); err != nil {
+		return nil, err
+	}
+	return &dp, nil
+}
+
+// purgeDeletedPolicies removes deleted keys whose restore window has
+// passed. It is run periodically.
+func purgeDeletedPolicies(req *logical.Request) error {
+	keys, err := req.Storage.List("deleted/")
+	if err != nil {
+		return err
+	}
+
+	var merr error
+	now := time.Now()
+	for _, k := range keys {
+		name := strings.TrimPrefix(k, "deleted/")
+		dp, err := getDeletedPolicy(req.Storage, name)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		if dp == nil || now.Before(dp.PurgeTime) {
+			continue
+		}
+		if err := req.Storage.Delete("deleted/" + name); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+	return merr
+}
+
+const pathDeletedHelpSyn = `Manage deleted encryption keys`
+
+const pathDeletedHelpDesc = `
+When a key is deleted it is not destroyed straight away. Instead it is
+moved here and can be restored until its restore window, which is set
+with the "restore_window" key config value, has passed. After that the
+key is purged and anything encrypted with it can no longer be decrypted.
+
+Reading a deleted key returns when it was deleted and when it will be
+purged. Deleting it purges it immediately.
+`
+
+const pathRestoreHelpSyn = `Restore a deleted encryption key`
+
+const pathRestoreHelpDesc = `
+This path restores a deleted key, with all of its versions and
+configuration, as long as it has not been purged and no new key with
+the same name has been created.
+`
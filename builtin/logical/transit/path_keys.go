@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/hashicorp/vault/helper/certutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -50,6 +51,9 @@ func pathPolicyWrite(
 
 	// Generate the policy
 	_, err = generatePolicy(req.Storage, name, derived)
+	if _, ok := err.(certutil.UserError); ok {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
 	return nil, err
 }
 
@@ -73,6 +77,7 @@ func pathPolicyRead(
 			"derived":                p.Derived,
 			"deletion_allowed":       p.DeletionAllowed,
 			"min_decryption_version": p.MinDecryptionVersion,
+			"restore_window":         int64(p.restoreWindow().Seconds()),
 		},
 	}
 	if p.Derived {
@@ -104,7 +109,9 @@ func pathPolicyDelete(
 		return logical.ErrorResponse(fmt.Sprintf("'allow_deletion' config value is not set")), logical.ErrInvalidRequest
 	}
 
-	err = req.Storage.Delete("policy/" + name)
+	// The key is kept until its restore window has passed, so that
+	// deleting it by mistake doesn't make ciphertext undecryptable
+	err = softDeletePolicy(req.Storage, p)
 	if err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("error deleting policy %s: %s", name, err)), err
 	}
@@ -117,4 +124,8 @@ const pathPolicyHelpDesc = `
 This path is used to manage the named keys that are available.
 Doing a write with no value against a new named key will create
 it using a randomly generated key.
+
+Deleting a key requires the "deletion_allowed" config value to be set.
+Deleted keys can be restored from "deleted/<name>/restore" until their
+restore window has passed, after which they are purged.
 `
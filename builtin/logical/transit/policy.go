@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -74,6 +75,10 @@ type Policy struct {
 
 	// Whether the key is allowed to be deleted
 	DeletionAllowed bool `json:"deletion_allowed"`
+
+	// How long the key can be restored for after it is deleted. If zero,
+	// DefaultRestoreWindow is used.
+	RestoreWindow time.Duration `json:"restore_window"`
 }
 
 func (p *Policy) Persist(storage logical.Storage, name string) error {
@@ -340,6 +345,16 @@ func getPolicy(req *logical.Request, name string) (*Policy, error) {
 // generatePolicy is used to create a new named policy with
 // a randomly generated key
 func generatePolicy(storage logical.Storage, name string, derived bool) (*Policy, error) {
+	// Don't reuse the name of a deleted key while it can be restored
+	deleted, err := getDeletedPolicy(storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if deleted != nil {
+		return nil, certutil.UserError{Err: fmt.Sprintf(
+			"key %s was deleted and must be restored or purged before the name is reused", name)}
+	}
+
 	// Create the policy object
 	p := &Policy{
 		Name:       name,
@@ -350,7 +365,7 @@ func generatePolicy(storage logical.Storage, name string, derived bool) (*Policy
 		p.KDFMode = kdfMode
 	}
 
-	err := p.rotate(storage)
+	err = p.rotate(storage)
 	if err != nil {
 		return nil, err
 	}
@@ -176,12 +176,31 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"copy": func() (cli.Command, error) {
+			return &command.CopyCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"move": func() (cli.Command, error) {
+			return &command.CopyCommand{
+				Meta: meta,
+				Move: true,
+			}, nil
+		},
+
 		"rekey": func() (cli.Command, error) {
 			return &command.RekeyCommand{
 				Meta: meta,
 			}, nil
 		},
 
+		"generate-recovery-token": func() (cli.Command, error) {
+			return &command.GenerateRecoveryTokenCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"renew": func() (cli.Command, error) {
 			return &command.RenewCommand{
 				Meta: meta,
@@ -242,12 +261,30 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"snapshot-save": func() (cli.Command, error) {
+			return &command.SnapshotSaveCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"snapshot-restore": func() (cli.Command, error) {
+			return &command.SnapshotRestoreCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"unmount": func() (cli.Command, error) {
 			return &command.UnmountCommand{
 				Meta: meta,
 			}, nil
 		},
 
+		"print token": func() (cli.Command, error) {
+			return &command.PrintTokenCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"token-create": func() (cli.Command, error) {
 			return &command.TokenCreateCommand{
 				Meta: meta,
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"sort"
 	"strings"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/token"
 	"github.com/hashicorp/vault/helper/kv-builder"
 	"github.com/hashicorp/vault/helper/password"
 	"github.com/mitchellh/mapstructure"
@@ -33,13 +35,16 @@ type AuthCommand struct {
 }
 
 func (c *AuthCommand) Run(args []string) int {
-	var method string
+	var method, field, format, tokenHelperPath string
 	var methods, methodHelp, noVerify bool
 	flags := c.Meta.FlagSet("auth", FlagSetDefault)
 	flags.BoolVar(&methods, "methods", false, "")
 	flags.BoolVar(&methodHelp, "method-help", false, "")
 	flags.BoolVar(&noVerify, "no-verify", false, "")
 	flags.StringVar(&method, "method", "", "method")
+	flags.StringVar(&field, "field", "", "")
+	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&tokenHelperPath, "token-helper", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -49,9 +54,27 @@ func (c *AuthCommand) Run(args []string) int {
 		return c.listMethods()
 	}
 
+	switch {
+	case format != "table" && format != "env":
+		c.Ui.Error(fmt.Sprintf("Invalid output format: %s", format))
+		return 1
+	case field != "" && format != "table":
+		c.Ui.Error("The -field and -format flags cannot be combined")
+		return 1
+	case field != "" && noVerify && field != "token":
+		c.Ui.Error(fmt.Sprintf(
+			"Field %s is only available when the token is verified", field))
+		return 1
+	}
+
+	// In the machine-readable output modes nothing but the value may be
+	// written to stdout, so that the output can be captured or evaluated
+	// by a shell.
+	quiet := field != "" || format == "env"
+
 	args = flags.Args()
 
-	tokenHelper, err := c.TokenHelper()
+	tokenHelper, err := c.authTokenHelper(tokenHelperPath)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error initializing token helper: %s\n\n"+
@@ -117,11 +140,15 @@ func (c *AuthCommand) Run(args []string) int {
 
 	// Warn if the VAULT_TOKEN environment variable is set, as that will take
 	// precedence
-	if os.Getenv("VAULT_TOKEN") != "" {
-		c.Ui.Output("==> WARNING: VAULT_TOKEN environment variable set!\n")
-		c.Ui.Output("  The environment variable takes precedence over the value")
-		c.Ui.Output("  set by the auth command. Either update the value of the")
-		c.Ui.Output("  environment variable or unset it to use the new token.\n")
+	if os.Getenv("VAULT_TOKEN") != "" && format != "env" {
+		warn := c.Ui.Output
+		if quiet {
+			warn = c.Ui.Error
+		}
+		warn("==> WARNING: VAULT_TOKEN environment variable set!\n")
+		warn("  The environment variable takes precedence over the value")
+		warn("  set by the auth command. Either update the value of the")
+		warn("  environment variable or unset it to use the new token.\n")
 	}
 
 	var vars map[string]string
@@ -163,18 +190,21 @@ func (c *AuthCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Build the client again so it can read the token we just wrote
-	client, err = c.Client()
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf(
-			"Error initializing client to verify the token: %s", err))
-		return 1
-	}
+	// Use the new token for verification, which may not be the one the
+	// client would load if a different token helper was given.
+	client.SetToken(token)
 
 	if noVerify {
-		c.Ui.Output(fmt.Sprintf(
-			"Authenticated - no token verification has been performed.",
-		))
+		switch {
+		case field != "":
+			c.Ui.Output(token)
+		case format == "env":
+			c.Ui.Output(tokenEnvOutput(token))
+		default:
+			c.Ui.Output(fmt.Sprintf(
+				"Authenticated - no token verification has been performed.",
+			))
+		}
 
 		return 0
 	}
@@ -201,11 +231,32 @@ func (c *AuthCommand) Run(args []string) int {
 		policies = append(policies, v.(string))
 	}
 
+	fields := map[string]string{
+		"token":          fmt.Sprintf("%s", secret.Data["id"]),
+		"token_duration": fmt.Sprintf("%d", int(secret.Data["ttl"].(float64))),
+		"token_policies": fmt.Sprintf("[%s]", strings.Join(policies, ", ")),
+	}
+
+	switch {
+	case field != "":
+		val, ok := fields[field]
+		if !ok {
+			c.Ui.Error(fmt.Sprintf(
+				"Field %s not present in authentication output", field))
+			return 1
+		}
+		c.Ui.Output(val)
+		return 0
+	case format == "env":
+		c.Ui.Output(tokenEnvOutput(fields["token"]))
+		return 0
+	}
+
 	output := "Successfully authenticated!"
-	output += fmt.Sprintf("\ntoken: %s", secret.Data["id"])
-	output += fmt.Sprintf("\ntoken_duration: %d", int(secret.Data["ttl"].(float64)))
+	output += fmt.Sprintf("\ntoken: %s", fields["token"])
+	output += fmt.Sprintf("\ntoken_duration: %s", fields["token_duration"])
 	if len(policies) > 0 {
-		output += fmt.Sprintf("\ntoken_policies: [%s]", strings.Join(policies, ", "))
+		output += fmt.Sprintf("\ntoken_policies: %s", fields["token_policies"])
 	}
 
 	c.Ui.Output(output)
@@ -213,6 +264,29 @@ func (c *AuthCommand) Run(args []string) int {
 	return 0
 }
 
+// authTokenHelper returns the token helper the new token is stored with,
+// which is the external helper at path if given, or the configured one.
+func (c *AuthCommand) authTokenHelper(path string) (token.TokenHelper, error) {
+	if path == "" {
+		return c.TokenHelper()
+	}
+
+	path, err := token.ExternalTokenHelperPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &token.ExternalTokenHelper{BinaryPath: path}, nil
+}
+
+// tokenEnvOutput returns a shell command that sets VAULT_TOKEN to the given
+// token, for use with eval.
+func tokenEnvOutput(token string) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("set VAULT_TOKEN=%s", token)
+	}
+	return fmt.Sprintf("export VAULT_TOKEN='%s'", token)
+}
+
 func (c *AuthCommand) listMethods() int {
 	client, err := c.Client()
 	if err != nil {
@@ -288,6 +362,20 @@ Auth Options:
   -no-verify        Do not verify the token after creation; avoids a use count
                     decrement.
 
+  -field=name       If included, only the raw value of the given field of the
+                    output is printed: one of "token", "token_duration" or
+                    "token_policies". Only "token" is available together with
+                    -no-verify. This is useful in scripts, for example:
+
+                      export VAULT_TOKEN=$(vault auth -field=token ...)
+
+  -format=table     The format for output. By default this is a human-readable
+                    summary. If "env", a shell command setting VAULT_TOKEN is
+                    printed, to be used as: eval $(vault auth -format=env ...)
+
+  -token-helper=path  Store the token with the external token helper at the
+                    given path instead of the one configured for the CLI.
+
 `
 	return strings.TrimSpace(helpText)
 }
@@ -175,6 +175,62 @@ func TestAuth_method(t *testing.T) {
 	}
 }
 
+func TestAuth_field(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	testAuthInit(t)
+
+	ui := new(cli.MockUi)
+	c := &AuthCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"-field", "token",
+		token,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	if output := ui.OutputWriter.String(); output != token+"\n" {
+		t.Fatalf("bad: %#v", output)
+	}
+}
+
+func TestAuth_formatEnv(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	testAuthInit(t)
+
+	ui := new(cli.MockUi)
+	c := &AuthCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"-format", "env",
+		token,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	if output := ui.OutputWriter.String(); output != tokenEnvOutput(token)+"\n" {
+		t.Fatalf("bad: %#v", output)
+	}
+}
+
 func testAuthInit(t *testing.T) {
 	td, err := ioutil.TempDir("", "vault")
 	if err != nil {
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CopyCommand is a Command that copies or moves secrets within the Vault.
+type CopyCommand struct {
+	Meta
+
+	// Move deletes the source secrets once they have been copied
+	Move bool
+}
+
+func (c *CopyCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet(c.name(), FlagSetDefault)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 2 {
+		c.Ui.Error(fmt.Sprintf("%s expects two arguments", c.name()))
+		flags.Usage()
+		return 1
+	}
+
+	source, destination := args[0], args[1]
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	var copied []string
+	if c.Move {
+		copied, err = client.Sys().Move(source, destination)
+	} else {
+		copied, err = client.Sys().Copy(source, destination)
+	}
+
+	verb, gerund := "Copied", "copying"
+	if c.Move {
+		verb, gerund = "Moved", "moving"
+	}
+	if !strings.HasSuffix(source, "/") {
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error %s '%s' to '%s': %s", gerund, source, destination, err))
+			return 1
+		}
+		c.Ui.Output(fmt.Sprintf("Success! %s '%s' to '%s'", verb, source, destination))
+		return 0
+	}
+
+	// List what was done even on error, since a move has already deleted
+	// these secrets from the source
+	for _, key := range copied {
+		c.Ui.Output(fmt.Sprintf("%s '%s%s' to '%s%s'", verb, source, key, destination, key))
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error %s '%s' to '%s' after %d secrets: %s", gerund, source, destination, len(copied), err))
+		return 1
+	}
+	c.Ui.Output(fmt.Sprintf("Success! %s %d secrets", verb, len(copied)))
+	return 0
+}
+
+func (c *CopyCommand) name() string {
+	if c.Move {
+		return "move"
+	}
+	return "copy"
+}
+
+func (c *CopyCommand) Synopsis() string {
+	if c.Move {
+		return "Move secrets between paths or mounts"
+	}
+	return "Copy secrets between paths or mounts"
+}
+
+func (c *CopyCommand) Help() string {
+	helpText := `
+Usage: vault copy [options] source destination
+       vault move [options] source destination
+
+  Copy or move secrets between paths, within a mount or between mounts.
+
+  The secret at the source path is written to the destination path. If
+  both paths end in "/", every secret under the source is copied to the
+  same relative path under the destination. Move deletes each source
+  secret once it has been copied.
+
+  The copy is made by the Vault server, so the secrets are never sent to
+  this client. Only generic and cubbyhole mounts are supported, and the
+  token must be allowed to read (and list, to copy a tree of secrets)
+  the source, to write the destination and, for a move, to delete the
+  source.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
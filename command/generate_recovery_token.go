@@ -0,0 +1,242 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/password"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+)
+
+// GenerateRecoveryTokenCommand is a Command that generates a recovery
+// token from a quorum of unseal keys.
+type GenerateRecoveryTokenCommand struct {
+	Meta
+
+	// Key can be used to pre-seed the key. If it is set, it will not
+	// be asked with the `password` helper.
+	Key string
+
+	// The nonce for the generation request to send along
+	Nonce string
+}
+
+func (c *GenerateRecoveryTokenCommand) Run(args []string) int {
+	var init, cancel, status bool
+	var nonce string
+	var pgpKey pgpkeys.PubKeyFilesFlag
+	flags := c.Meta.FlagSet("generate-recovery-token", FlagSetDefault)
+	flags.BoolVar(&init, "init", false, "")
+	flags.BoolVar(&cancel, "cancel", false, "")
+	flags.BoolVar(&status, "status", false, "")
+	flags.StringVar(&nonce, "nonce", "", "")
+	flags.Var(&pgpKey, "pgp-key", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if nonce != "" {
+		c.Nonce = nonce
+	}
+
+	if len(pgpKey) > 1 {
+		c.Ui.Error("Only one PGP key can be given with '-pgp-key'")
+		return 1
+	}
+	var pgpKeyValue string
+	if len(pgpKey) == 1 {
+		pgpKeyValue = pgpKey[0]
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	// Check if we are running doing any restricted variants
+	switch {
+	case init:
+		return c.initGeneration(client, pgpKeyValue)
+	case cancel:
+		return c.cancelGeneration(client)
+	case status:
+		return c.generationStatus(client)
+	}
+
+	// Check if the generation is started
+	genStatus, err := client.Sys().RecoveryTokenStatus()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading recovery token generation status: %s", err))
+		return 1
+	}
+
+	// Start the generation if not started
+	if !genStatus.Started {
+		genStatus, err = client.Sys().RecoveryTokenInit(pgpKeyValue)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error initializing recovery token generation: %s", err))
+			return 1
+		}
+		c.Nonce = genStatus.Nonce
+	}
+
+	// Get the unseal key
+	args = flags.Args()
+	key := c.Key
+	if len(args) > 0 {
+		key = args[0]
+	}
+	if key == "" {
+		c.Nonce = genStatus.Nonce
+		fmt.Printf("Recovery token operation nonce: %s\n", genStatus.Nonce)
+		fmt.Printf("Key (will be hidden): ")
+		key, err = password.Read(os.Stdin)
+		fmt.Printf("\n")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error attempting to ask for password. The raw error message\n"+
+					"is shown below, but the most common reason for this error is\n"+
+					"that you attempted to pipe a value into the command or you're\n"+
+					"executing `vault generate-recovery-token` from outside of a\n"+
+					"terminal.\n\n"+
+					"You should use `vault generate-recovery-token` from a terminal\n"+
+					"for maximum security. If this isn't an option, the unseal key\n"+
+					"can be passed in using the first parameter.\n\n"+
+					"Raw error: %s", err))
+			return 1
+		}
+	}
+
+	// Provide the key, this may potentially complete the generation
+	result, err := client.Sys().RecoveryTokenUpdate(strings.TrimSpace(key), c.Nonce)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error attempting recovery token update: %s", err))
+		return 1
+	}
+
+	// If we are not complete, then dump the status
+	if !result.Complete {
+		return c.generationStatus(client)
+	}
+
+	if result.PGPFingerprint != "" {
+		c.Ui.Output(fmt.Sprintf(
+			"Encoded recovery token (PGP key fingerprint %s): %s",
+			result.PGPFingerprint, result.EncodedToken))
+	} else {
+		c.Ui.Output(fmt.Sprintf("Recovery token: %s", result.EncodedToken))
+	}
+
+	c.Ui.Output(fmt.Sprintf(
+		"\n"+
+			"The recovery token is valid for %d seconds and cannot be renewed.\n"+
+			"It may only be used with the raw storage and storage snapshot\n"+
+			"endpoints. Revoke it with 'vault token-revoke' when done.",
+		result.TTL))
+
+	return 0
+}
+
+// initGeneration is used to start the generation process
+func (c *GenerateRecoveryTokenCommand) initGeneration(client *api.Client, pgpKey string) int {
+	if _, err := client.Sys().RecoveryTokenInit(pgpKey); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing recovery token generation: %s", err))
+		return 1
+	}
+
+	// Provide the current status
+	return c.generationStatus(client)
+}
+
+// cancelGeneration is used to abort the generation process
+func (c *GenerateRecoveryTokenCommand) cancelGeneration(client *api.Client) int {
+	err := client.Sys().RecoveryTokenCancel()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to cancel recovery token generation: %s", err))
+		return 1
+	}
+	c.Ui.Output("Recovery token generation canceled.")
+	return 0
+}
+
+// generationStatus is used just to fetch and dump the status
+func (c *GenerateRecoveryTokenCommand) generationStatus(client *api.Client) int {
+	// Check the status
+	status, err := client.Sys().RecoveryTokenStatus()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading recovery token generation status: %s", err))
+		return 1
+	}
+
+	// Dump the status
+	statString := fmt.Sprintf(
+		"Nonce: %s\n"+
+			"Started: %v\n"+
+			"Progress: %d\n"+
+			"Required Keys: %d",
+		status.Nonce,
+		status.Started,
+		status.Progress,
+		status.Required,
+	)
+	if status.PGPFingerprint != "" {
+		statString = fmt.Sprintf("%s\nPGP Key Fingerprint: %s", statString, status.PGPFingerprint)
+	}
+	c.Ui.Output(statString)
+	return 0
+}
+
+func (c *GenerateRecoveryTokenCommand) Synopsis() string {
+	return "Generates a token scoped to recovery operations"
+}
+
+func (c *GenerateRecoveryTokenCommand) Help() string {
+	helpText := `
+Usage: vault generate-recovery-token [options] [key]
+
+  Generates a recovery token from a threshold of the unseal keys. This
+  avoids generating a full root token for routine disaster recovery
+  drills.
+
+  A recovery token is valid for 30 minutes, cannot be renewed, and is
+  only authorized for the raw storage endpoints ("sys/raw") and the
+  storage snapshot endpoint ("sys/storage/snapshot"), along with looking
+  up and revoking itself.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Recovery Token Options:
+
+  -init                   Initialize the generation. This can only be done
+                          if no generation is already in progress.
+
+  -cancel                 Reset the generation by throwing away the keys
+                          provided so far.
+
+  -status                 Prints the status of the current generation. This
+                          can be used to see the status without attempting
+                          to provide an unseal key.
+
+  -nonce=abcd             The nonce provided at initialization time. This
+                          same nonce value must be provided with each unseal
+                          key. If the unseal key is not being passed in via
+                          the command line the nonce parameter is not
+                          required, and will instead be displayed with the
+                          key prompt.
+
+  -pgp-key                If provided, must be a file on disk containing a
+                          binary- or base64-format public PGP key, or a
+                          Keybase username specified as "keybase:<username>".
+                          The recovery token will be encrypted with it and
+                          hex-encoded, so that the operator providing the
+                          last key does not see it.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestGenerateRecoveryToken(t *testing.T) {
+	core, key, _ := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	c := &GenerateRecoveryTokenCommand{
+		Key: hex.EncodeToString(key),
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	args := []string{"-address", addr}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Recovery token: ") {
+		t.Fatalf("bad: %s", ui.OutputWriter.String())
+	}
+
+	config, err := core.RecoveryTokenConfiguration()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if config != nil {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestGenerateRecoveryToken_cancel(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	c := &GenerateRecoveryTokenCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	args := []string{"-address", addr, "-init"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	args = []string{"-address", addr, "-cancel"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	config, err := core.RecoveryTokenConfiguration()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if config != nil {
+		t.Fatalf("bad: %#v", config)
+	}
+}
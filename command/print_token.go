@@ -0,0 +1,61 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrintTokenCommand is a Command that prints the token the CLI would use
+type PrintTokenCommand struct {
+	Meta
+}
+
+func (c *PrintTokenCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("print token", FlagSetDefault)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	token := client.Token()
+	if token == "" {
+		c.Ui.Error("No token is set")
+		return 1
+	}
+
+	c.Ui.Output(token)
+	return 0
+}
+
+func (c *PrintTokenCommand) Synopsis() string {
+	return "Prints the token used by the CLI"
+}
+
+func (c *PrintTokenCommand) Help() string {
+	helpText := `
+Usage: vault print token [options]
+
+  Prints the token that the CLI would use for requests, without contacting
+  the Vault server. This is the token set in the VAULT_TOKEN environment
+  variable, or otherwise the token stored by the token helper after
+  "vault auth".
+
+  Only the token is printed, which makes this useful for passing the token
+  to other tools:
+
+      curl -H "X-Vault-Token: $(vault print token)" ...
+
+  The exit code is 1 if no token is set.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
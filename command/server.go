@@ -131,6 +131,9 @@ func (c *ServerCommand) Run(args []string) int {
 		DisableMlock:       config.DisableMlock,
 		MaxLeaseTTL:        config.MaxLeaseTTL,
 		DefaultLeaseTTL:    config.DefaultLeaseTTL,
+		ReplayWindow:       config.ReplayProtectionWindow,
+		EnableRaw:          config.EnableRawEndpoint,
+		MaxTTLCeiling:      config.MaxTTLCeiling,
 	}
 
 	// Initialize the separate HA physical backend, if it exists
@@ -240,6 +243,7 @@ func (c *ServerCommand) Run(args []string) int {
 
 	// Initialize the listeners
 	lns := make([]net.Listener, 0, len(config.Listeners))
+	maxInFlight := make([]int, 0, len(config.Listeners))
 	for i, lnConfig := range config.Listeners {
 		ln, props, err := server.NewListener(lnConfig.Type, lnConfig.Config)
 		if err != nil {
@@ -249,6 +253,17 @@ func (c *ServerCommand) Run(args []string) int {
 			return 1
 		}
 
+		max, err := server.ListenerMaxInFlight(lnConfig.Config)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+		if max > 0 {
+			props["max_inflight_requests"] = strconv.Itoa(max)
+		}
+
 		// Store the listener props for output later
 		key := fmt.Sprintf("listener %d", i+1)
 		propsList := make([]string, 0, len(props))
@@ -262,16 +277,20 @@ func (c *ServerCommand) Run(args []string) int {
 			"%s (%s)", lnConfig.Type, strings.Join(propsList, ", "))
 
 		lns = append(lns, ln)
+		maxInFlight = append(maxInFlight, max)
 	}
 
 	if verifyOnly {
 		return 0
 	}
 
-	// Initialize the HTTP server
-	server := &http.Server{}
-	server.Handler = vaulthttp.Handler(core)
-	for _, ln := range lns {
+	// Initialize the HTTP servers, one per listener so that each can
+	// limit the requests in flight
+	handler := vaulthttp.Handler(core)
+	for i, ln := range lns {
+		server := &http.Server{
+			Handler: vaulthttp.InFlightLimitHandler(handler, maxInFlight[i]),
+		}
 		go server.Serve(ln)
 	}
 
@@ -22,12 +22,20 @@ type Config struct {
 	DisableCache bool `hcl:"disable_cache"`
 	DisableMlock bool `hcl:"disable_mlock"`
 
+	EnableRawEndpoint bool `hcl:"raw_storage_endpoint"`
+
 	Telemetry *Telemetry `hcl:"telemetry"`
 
 	MaxLeaseTTL        time.Duration `hcl:"-"`
 	MaxLeaseTTLRaw     string        `hcl:"max_lease_ttl"`
 	DefaultLeaseTTL    time.Duration `hcl:"-"`
 	DefaultLeaseTTLRaw string        `hcl:"default_lease_ttl"`
+
+	ReplayProtectionWindow    time.Duration `hcl:"-"`
+	ReplayProtectionWindowRaw string        `hcl:"replay_protection_window"`
+
+	MaxTTLCeiling    time.Duration `hcl:"-"`
+	MaxTTLCeilingRaw string        `hcl:"max_ttl_ceiling"`
 }
 
 // DevConfig is a Config that is used for dev mode of Vault.
@@ -120,6 +128,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.DisableMlock = c2.DisableMlock
 	}
 
+	result.EnableRawEndpoint = c.EnableRawEndpoint
+	if c2.EnableRawEndpoint {
+		result.EnableRawEndpoint = c2.EnableRawEndpoint
+	}
+
 	// merge these integers via a MAX operation
 	result.MaxLeaseTTL = c.MaxLeaseTTL
 	if c2.MaxLeaseTTL > result.MaxLeaseTTL {
@@ -131,6 +144,17 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.DefaultLeaseTTL = c2.DefaultLeaseTTL
 	}
 
+	result.ReplayProtectionWindow = c.ReplayProtectionWindow
+	if c2.ReplayProtectionWindow > result.ReplayProtectionWindow {
+		result.ReplayProtectionWindow = c2.ReplayProtectionWindow
+	}
+
+	// merge the ceiling via a MIN operation, ignoring unset values
+	result.MaxTTLCeiling = c.MaxTTLCeiling
+	if c2.MaxTTLCeiling > 0 && (result.MaxTTLCeiling == 0 || c2.MaxTTLCeiling < result.MaxTTLCeiling) {
+		result.MaxTTLCeiling = c2.MaxTTLCeiling
+	}
+
 	return result
 }
 
@@ -179,6 +203,16 @@ func LoadConfigFile(path string) (*Config, error) {
 			return nil, err
 		}
 	}
+	if result.ReplayProtectionWindowRaw != "" {
+		if result.ReplayProtectionWindow, err = time.ParseDuration(result.ReplayProtectionWindowRaw); err != nil {
+			return nil, err
+		}
+	}
+	if result.MaxTTLCeilingRaw != "" {
+		if result.MaxTTLCeiling, err = time.ParseDuration(result.MaxTTLCeilingRaw); err != nil {
+			return nil, err
+		}
+	}
 
 	if objs := obj.Get("listener", false); objs != nil {
 		result.Listeners, err = loadListeners(objs)
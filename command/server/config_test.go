@@ -47,10 +47,18 @@ func TestLoadConfigFile(t *testing.T) {
 		DisableCache: true,
 		DisableMlock: true,
 
+		EnableRawEndpoint: true,
+
 		MaxLeaseTTL:        10 * time.Hour,
 		MaxLeaseTTLRaw:     "10h",
 		DefaultLeaseTTL:    10 * time.Hour,
 		DefaultLeaseTTLRaw: "10h",
+
+		ReplayProtectionWindow:    30 * time.Second,
+		ReplayProtectionWindowRaw: "30s",
+
+		MaxTTLCeiling:    8760 * time.Hour,
+		MaxTTLCeilingRaw: "8760h",
 	}
 	if !reflect.DeepEqual(config, expected) {
 		t.Fatalf("bad: %#v", config)
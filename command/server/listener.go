@@ -36,6 +36,24 @@ func NewListener(t string, config map[string]string) (net.Listener, map[string]s
 	return f(config)
 }
 
+// ListenerMaxInFlight returns the "max_inflight_requests" setting of a
+// listener, which is zero if unset.
+func ListenerMaxInFlight(config map[string]string) (int, error) {
+	v, ok := config["max_inflight_requests"]
+	if !ok {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for 'max_inflight_requests': %v", err)
+	}
+	if max < 0 {
+		return 0, fmt.Errorf("'max_inflight_requests' must not be negative")
+	}
+	return max, nil
+}
+
 func listenerWrapTLS(
 	ln net.Listener,
 	props map[string]string,
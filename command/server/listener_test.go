@@ -46,3 +46,21 @@ func testListenerImpl(t *testing.T, ln net.Listener, connFn testListenerConnFn)
 		t.Fatalf("bad: %v", buf.String())
 	}
 }
+
+func TestListenerMaxInFlight(t *testing.T) {
+	max, err := ListenerMaxInFlight(map[string]string{})
+	if err != nil || max != 0 {
+		t.Fatalf("bad: %d %v", max, err)
+	}
+
+	max, err = ListenerMaxInFlight(map[string]string{"max_inflight_requests": "128"})
+	if err != nil || max != 128 {
+		t.Fatalf("bad: %d %v", max, err)
+	}
+
+	for _, v := range []string{"foo", "-1"} {
+		if _, err := ListenerMaxInFlight(map[string]string{"max_inflight_requests": v}); err == nil {
+			t.Fatalf("%s: expected error", v)
+		}
+	}
+}
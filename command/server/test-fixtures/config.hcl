@@ -1,5 +1,6 @@
 disable_cache = true
 disable_mlock = true
+raw_storage_endpoint = true
 statsd_addr = "bar"
 statsite_addr = "foo"
 
@@ -19,3 +20,5 @@ ha_backend "consul" {
 
 max_lease_ttl = "10h"
 default_lease_ttl = "10h"
+replay_protection_window = "30s"
+max_ttl_ceiling = "8760h"
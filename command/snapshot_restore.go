@@ -0,0 +1,76 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SnapshotRestoreCommand is a Command that restores a snapshot of the
+// storage
+type SnapshotRestoreCommand struct {
+	Meta
+}
+
+func (c *SnapshotRestoreCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("snapshot-restore", FlagSetDefault)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		c.Ui.Error("\nsnapshot-restore expects one argument: the snapshot file")
+		return 1
+	}
+	path := args[0]
+
+	file, err := os.Open(path)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error opening snapshot file: %s", err))
+		return 1
+	}
+	defer file.Close()
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	if err := client.Sys().SnapshotRestore(file); err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error restoring snapshot: %s", err))
+		return 2
+	}
+
+	c.Ui.Output(fmt.Sprintf(
+		"Snapshot restored from %s. The Vault is now sealed and must be\n"+
+			"unsealed with the keys in use when the snapshot was taken.", path))
+	return 0
+}
+
+func (c *SnapshotRestoreCommand) Synopsis() string {
+	return "Restores a snapshot of the storage backend"
+}
+
+func (c *SnapshotRestoreCommand) Help() string {
+	helpText := `
+Usage: vault snapshot-restore [options] path
+
+  Replaces the contents of the storage backend with a snapshot taken
+  with snapshot-save.
+
+  The snapshot is verified before anything is written. Once restored,
+  the Vault is sealed and must be unsealed with the unseal keys in use
+  when the snapshot was taken. A root token is required.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
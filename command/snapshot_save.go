@@ -0,0 +1,86 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SnapshotSaveCommand is a Command that saves a snapshot of the storage
+type SnapshotSaveCommand struct {
+	Meta
+}
+
+func (c *SnapshotSaveCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("snapshot-save", FlagSetDefault)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		c.Ui.Error("\nsnapshot-save expects one argument: the file to write")
+		return 1
+	}
+	path := args[0]
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	// Write to a temporary file first so that a failed snapshot never
+	// replaces a good one
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error creating snapshot file: %s", err))
+		return 1
+	}
+	defer os.Remove(tmp.Name())
+
+	err = client.Sys().SnapshotSave(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error saving snapshot: %s", err))
+		return 2
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing snapshot file: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Snapshot saved to %s", path))
+	return 0
+}
+
+func (c *SnapshotSaveCommand) Synopsis() string {
+	return "Saves a snapshot of the storage backend"
+}
+
+func (c *SnapshotSaveCommand) Help() string {
+	helpText := `
+Usage: vault snapshot-save [options] path
+
+  Saves a consistent snapshot of the storage backend to a file.
+
+  Requests are paused while the snapshot is taken. Data behind the
+  barrier stays encrypted in the snapshot, so it can only be used with
+  the unseal keys in use when it was taken. A root token is required.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
@@ -1,47 +1,82 @@
 package command
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/hashicorp/vault/api"
 )
 
+// Exit codes returned by the status command, allowing scripts to branch
+// on the state of the Vault without parsing the output.
+const (
+	statusExitUnsealed      = 0
+	statusExitSealed        = 1
+	statusExitError         = 2
+	statusExitUninitialized = 3
+)
+
 // StatusCommand is a Command that outputs the status of whether
 // Vault is sealed or not as well as HA information.
 type StatusCommand struct {
 	Meta
 }
 
+// statusOutput is the structure written when -format=json is given.
+type statusOutput struct {
+	Initialized    bool   `json:"initialized"`
+	Sealed         bool   `json:"sealed"`
+	KeyShares      int    `json:"key_shares"`
+	KeyThreshold   int    `json:"key_threshold"`
+	UnsealProgress int    `json:"unseal_progress"`
+	HAEnabled      bool   `json:"ha_enabled"`
+	HAMode         string `json:"ha_mode,omitempty"`
+	LeaderAddress  string `json:"leader_address,omitempty"`
+}
+
 func (c *StatusCommand) Run(args []string) int {
+	var format string
 	flags := c.Meta.FlagSet("status", FlagSetDefault)
+	flags.StringVar(&format, "format", "table", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
-		return 1
+		return statusExitError
+	}
+
+	if format != "table" && format != "json" {
+		c.Ui.Error(fmt.Sprintf("Invalid output format: %s", format))
+		return statusExitError
 	}
 
 	client, err := c.Client()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error initializing client: %s", err))
-		return 2
+		return statusExitError
+	}
+
+	initialized, err := client.Sys().InitStatus()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error checking initialization status: %s", err))
+		return statusExitError
+	}
+	if !initialized {
+		if format == "json" {
+			return c.outputJSON(&statusOutput{}, statusExitUninitialized)
+		}
+		c.Ui.Output("Vault is not initialized")
+		return statusExitUninitialized
 	}
 
 	sealStatus, err := client.Sys().SealStatus()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error checking seal status: %s", err))
-		return 2
+		return statusExitError
 	}
-	c.Ui.Output(fmt.Sprintf(
-		"Sealed: %v\n"+
-			"Key Shares: %d\n"+
-			"Key Threshold: %d\n"+
-			"Unseal Progress: %d",
-		sealStatus.Sealed,
-		sealStatus.N,
-		sealStatus.T,
-		sealStatus.Progress))
 
 	// Mask the 'Vault is sealed' error, since this means HA is enabled,
 	// but that we cannot query for the leader since we are sealed.
@@ -53,34 +88,81 @@ func (c *StatusCommand) Run(args []string) int {
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error checking leader status: %s", err))
-		return 2
+		return statusExitError
 	}
 
-	// Output if HA is enabled
-	c.Ui.Output("")
-	c.Ui.Output(fmt.Sprintf("High-Availability Enabled: %v", leaderStatus.HAEnabled))
+	code := statusExitUnsealed
+	if sealStatus.Sealed {
+		code = statusExitSealed
+	}
+
+	out := &statusOutput{
+		Initialized:    true,
+		Sealed:         sealStatus.Sealed,
+		KeyShares:      sealStatus.N,
+		KeyThreshold:   sealStatus.T,
+		UnsealProgress: sealStatus.Progress,
+		HAEnabled:      leaderStatus.HAEnabled,
+	}
 	if leaderStatus.HAEnabled {
-		if sealStatus.Sealed {
-			c.Ui.Output("\tMode: sealed")
-		} else {
-			mode := "standby"
-			if leaderStatus.IsSelf {
-				mode = "active"
-			}
-			c.Ui.Output(fmt.Sprintf("\tMode: %s", mode))
+		switch {
+		case sealStatus.Sealed:
+			out.HAMode = "sealed"
+		case leaderStatus.IsSelf:
+			out.HAMode = "active"
+		default:
+			out.HAMode = "standby"
+		}
+		if !sealStatus.Sealed {
+			out.LeaderAddress = leaderStatus.LeaderAddress
+		}
+	}
+
+	if format == "json" {
+		return c.outputJSON(out, code)
+	}
+
+	c.Ui.Output(fmt.Sprintf(
+		"Sealed: %v\n"+
+			"Key Shares: %d\n"+
+			"Key Threshold: %d\n"+
+			"Unseal Progress: %d",
+		out.Sealed,
+		out.KeyShares,
+		out.KeyThreshold,
+		out.UnsealProgress))
 
-			if leaderStatus.LeaderAddress == "" {
-				leaderStatus.LeaderAddress = "<none>"
+	// Output if HA is enabled
+	c.Ui.Output("")
+	c.Ui.Output(fmt.Sprintf("High-Availability Enabled: %v", out.HAEnabled))
+	if out.HAEnabled {
+		c.Ui.Output(fmt.Sprintf("\tMode: %s", out.HAMode))
+		if !out.Sealed {
+			leader := out.LeaderAddress
+			if leader == "" {
+				leader = "<none>"
 			}
-			c.Ui.Output(fmt.Sprintf("\tLeader: %s", leaderStatus.LeaderAddress))
+			c.Ui.Output(fmt.Sprintf("\tLeader: %s", leader))
 		}
 	}
 
-	if sealStatus.Sealed {
-		return 1
-	} else {
-		return 0
+	return code
+}
+
+// outputJSON writes the status as indented JSON, returning the given exit
+// code on success.
+func (c *StatusCommand) outputJSON(out *statusOutput, code int) int {
+	b, err := json.Marshal(out)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error formatting status: %s", err))
+		return statusExitError
 	}
+
+	var buf bytes.Buffer
+	json.Indent(&buf, b, "", "\t")
+	c.Ui.Output(buf.String())
+	return code
 }
 
 func (c *StatusCommand) Synopsis() string {
@@ -94,10 +176,22 @@ Usage: vault status [options]
   Outputs the state of the Vault, sealed or unsealed and if HA is enabled.
 
   This command outputs whether or not the Vault is sealed. The exit
-  code also reflects the seal status (0 unsealed, 1 sealed, 2+ error).
+  code also reflects the status of the Vault:
+
+    0 - unsealed
+    1 - sealed
+    2 - error checking status
+    3 - not initialized
 
 General Options:
 
-  ` + generalOptionsUsage()
+  ` + generalOptionsUsage() + `
+
+Status Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json.
+
+`
 	return strings.TrimSpace(helpText)
 }
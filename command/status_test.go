@@ -1,6 +1,8 @@
 package command
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/vault/http"
@@ -34,3 +36,70 @@ func TestStatus(t *testing.T) {
 		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
 }
+
+func TestStatus_uninitialized(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &StatusCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	core := vault.TestCore(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	args := []string{"-address", addr}
+	if code := c.Run(args); code != statusExitUninitialized {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+}
+
+func TestStatus_json(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &StatusCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+
+	core := vault.TestCore(t)
+	key, _ := vault.TestCoreInit(t, core)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	args := []string{"-address", addr, "-format", "json"}
+	if code := c.Run(args); code != statusExitSealed {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var out statusOutput
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &out); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := statusOutput{
+		Initialized:  true,
+		Sealed:       true,
+		KeyShares:    1,
+		KeyThreshold: 1,
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	if _, err := core.Unseal(key); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui.OutputWriter.Reset()
+	if code := c.Run(args); code != statusExitUnsealed {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	out = statusOutput{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &out); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !out.Initialized || out.Sealed {
+		t.Fatalf("bad: %#v", out)
+	}
+}
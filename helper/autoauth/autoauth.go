@@ -0,0 +1,75 @@
+// Package autoauth contains reusable methods for automatically
+// authenticating to Vault and persisting the resulting token. It is
+// meant to be shared by long-running helpers as well as applications
+// that want to authenticate in-process.
+package autoauth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// AuthMethod is the interface that any auto-auth method must implement.
+// Authenticate returns the path that should be written to in order to log
+// in, along with the data to write to it.
+type AuthMethod interface {
+	Authenticate(*api.Client) (string, map[string]interface{}, error)
+}
+
+// LoginNotifier is implemented by methods that need to know when a login
+// with the data they returned has succeeded, such as to remove a
+// credential that should only be used once.
+type LoginNotifier interface {
+	LoginSucceeded() error
+}
+
+// Sink is the interface for a destination that tokens retrieved by an
+// AuthMethod are written to.
+type Sink interface {
+	WriteToken(string) error
+}
+
+// Login authenticates using the given method, sets the resulting token on
+// the client and writes it to each of the given sinks. The secret returned
+// by the login endpoint is returned so that callers can schedule renewal.
+func Login(c *api.Client, m AuthMethod, sinks ...Sink) (*api.Secret, error) {
+	path, data, err := m.Authenticate(c)
+	if err != nil {
+		return nil, fmt.Errorf("error building login request: %s", err)
+	}
+
+	secret, err := c.Logical().Write(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("empty response from credential provider")
+	}
+
+	c.SetToken(secret.Auth.ClientToken)
+
+	if n, ok := m.(LoginNotifier); ok {
+		if err := n.LoginSucceeded(); err != nil {
+			return secret, err
+		}
+	}
+
+	for _, s := range sinks {
+		if err := s.WriteToken(secret.Auth.ClientToken); err != nil {
+			return secret, fmt.Errorf("error writing token to sink: %s", err)
+		}
+	}
+
+	return secret, nil
+}
+
+// loginPath returns the login path for the given mount, falling back to
+// the provided default mount if none is set.
+func loginPath(mount, def string) string {
+	if mount == "" {
+		mount = def
+	}
+	return fmt.Sprintf("auth/%s/login", strings.Trim(mount, "/"))
+}
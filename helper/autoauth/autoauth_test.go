@@ -0,0 +1,252 @@
+package autoauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/hashicorp/vault/api"
+)
+
+func testClient(t *testing.T, handler http.HandlerFunc) (*api.Client, func()) {
+	ts := httptest.NewServer(handler)
+
+	config := api.DefaultConfig()
+	config.Address = ts.URL
+	client, err := api.NewClient(config)
+	if err != nil {
+		ts.Close()
+		t.Fatalf("err: %s", err)
+	}
+	client.ClearToken()
+
+	return client, ts.Close
+}
+
+func testTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "autoauth")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return dir
+}
+
+func TestLogin_appId(t *testing.T) {
+	dir := testTempDir(t)
+	defer os.RemoveAll(dir)
+
+	appIdFile := filepath.Join(dir, "app_id")
+	userIdFile := filepath.Join(dir, "user_id")
+	if err := ioutil.WriteFile(appIdFile, []byte("foo\n"), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := ioutil.WriteFile(userIdFile, []byte("bar"), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The handler runs in another goroutine, so it records its error
+	// rather than failing the test itself
+	var gotPath string
+	var gotData map[string]interface{}
+	var decodeErr error
+	fail := true
+	client, closer := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		decodeErr = json.NewDecoder(r.Body).Decode(&gotData)
+		w.Header().Set("Content-Type", "application/json")
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"errors":["temporarily unavailable"]}`))
+			return
+		}
+		w.Write([]byte(`{"auth":{"client_token":"tok"}}`))
+	})
+	defer closer()
+
+	sinkPath := filepath.Join(dir, "token")
+	method := &AppIDMethod{
+		AppIDFile:        appIdFile,
+		UserIDFile:       userIdFile,
+		RemoveUserIDFile: true,
+	}
+
+	// A failed login keeps the user ID file so that it can be retried
+	if _, err := Login(client, method, &FileSink{Path: sinkPath}); err == nil {
+		t.Fatal("expected error")
+	}
+	if decodeErr != nil {
+		t.Fatalf("err: %s", decodeErr)
+	}
+	if _, err := os.Stat(userIdFile); err != nil {
+		t.Fatalf("user ID file should have been kept: %v", err)
+	}
+
+	fail = false
+	secret, err := Login(client, method, &FileSink{Path: sinkPath})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if decodeErr != nil {
+		t.Fatalf("err: %s", decodeErr)
+	}
+
+	if gotPath != "/v1/auth/app-id/login" {
+		t.Fatalf("bad: %s", gotPath)
+	}
+	if gotData["app_id"] != "foo" || gotData["user_id"] != "bar" {
+		t.Fatalf("bad: %#v", gotData)
+	}
+	if secret.Auth.ClientToken != "tok" || client.Token() != "tok" {
+		t.Fatalf("bad: %#v", secret.Auth)
+	}
+
+	token, err := ioutil.ReadFile(sinkPath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(token) != "tok" {
+		t.Fatalf("bad: %s", token)
+	}
+
+	if _, err := os.Stat(userIdFile); !os.IsNotExist(err) {
+		t.Fatalf("user ID file should have been removed: %v", err)
+	}
+}
+
+func TestLogin_noAuth(t *testing.T) {
+	client, closer := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"foo":"bar"}}`))
+	})
+	defer closer()
+
+	dir := testTempDir(t)
+	defer os.RemoveAll(dir)
+	jwtFile := filepath.Join(dir, "jwt")
+	if err := ioutil.WriteFile(jwtFile, []byte("jwt"), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	method := &KubernetesMethod{Role: "web", TokenPath: jwtFile}
+	if _, err := Login(client, method); err == nil {
+		t.Fatal("expected error")
+	}
+	if client.Token() != "" {
+		t.Fatalf("bad: %s", client.Token())
+	}
+}
+
+func TestKubernetesMethod(t *testing.T) {
+	dir := testTempDir(t)
+	defer os.RemoveAll(dir)
+
+	jwtFile := filepath.Join(dir, "jwt")
+	if err := ioutil.WriteFile(jwtFile, []byte("jwt\n"), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	method := &KubernetesMethod{Mount: "/k8s/", Role: "web", TokenPath: jwtFile}
+	path, data, err := method.Authenticate(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if path != "auth/k8s/login" {
+		t.Fatalf("bad: %s", path)
+	}
+	if data["jwt"] != "jwt" || data["role"] != "web" {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	method = &KubernetesMethod{TokenPath: jwtFile}
+	if _, _, err := method.Authenticate(nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestAWSMethod(t *testing.T) {
+	method := &AWSMethod{
+		Role:           "dev",
+		ServerIDHeader: "vault.example.com",
+		Credentials:    credentials.NewStaticCredentials("AKID", "SECRET", ""),
+	}
+	path, data, err := method.Authenticate(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if path != "auth/aws/login" {
+		t.Fatalf("bad: %s", path)
+	}
+	if data["role"] != "dev" || data["iam_http_request_method"] != "POST" {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	decode := func(key string) []byte {
+		raw, err := base64.StdEncoding.DecodeString(data[key].(string))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return raw
+	}
+
+	if string(decode("iam_request_url")) != "https://sts.amazonaws.com/" {
+		t.Fatalf("bad: %s", decode("iam_request_url"))
+	}
+	if string(decode("iam_request_body")) != awsSTSRequestBody {
+		t.Fatalf("bad: %s", decode("iam_request_body"))
+	}
+
+	var headers http.Header
+	if err := json.Unmarshal(decode("iam_request_headers"), &headers); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if headers.Get(awsIAMServerIDHeader) != "vault.example.com" {
+		t.Fatalf("bad: %#v", headers)
+	}
+	auth := headers.Get("Authorization")
+	if !strings.Contains(auth, "Credential=AKID/") || !strings.Contains(auth, strings.ToLower(awsIAMServerIDHeader)) {
+		t.Fatalf("bad: %s", auth)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := testTempDir(t)
+	defer os.RemoveAll(dir)
+
+	sink := &FileSink{Path: filepath.Join(dir, "token"), Mode: 0600}
+	for _, token := range []string{"foo", "bar"} {
+		if err := sink.WriteToken(token); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		actual, err := ioutil.ReadFile(sink.Path)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if string(actual) != token {
+			t.Fatalf("bad: %s", actual)
+		}
+	}
+
+	info, err := os.Stat(sink.Path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("bad: %v", info.Mode())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("temporary files left behind: %d", len(files))
+	}
+}
@@ -0,0 +1,76 @@
+package autoauth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// AppIDMethod authenticates against the app-id credential backend using
+// an app ID and user ID read from files. This allows the two halves of the
+// credential to be delivered out-of-band by separate provisioning systems.
+type AppIDMethod struct {
+	// Mount is the mount point of the app-id backend. Defaults to "app-id".
+	Mount string
+
+	// AppIDFile is the path to a file containing the app ID.
+	AppIDFile string
+
+	// UserIDFile is the path to a file containing the user ID.
+	UserIDFile string
+
+	// RemoveUserIDFile removes the user ID file once a login with it has
+	// succeeded, so that it cannot be reused by another process on the
+	// same machine. It is kept if the login fails, so it can be retried.
+	RemoveUserIDFile bool
+}
+
+func (m *AppIDMethod) Authenticate(c *api.Client) (string, map[string]interface{}, error) {
+	if m.AppIDFile == "" || m.UserIDFile == "" {
+		return "", nil, fmt.Errorf("'app_id_file' and 'user_id_file' must be specified")
+	}
+
+	appId, err := readCredentialFile(m.AppIDFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading app ID file: %s", err)
+	}
+	userId, err := readCredentialFile(m.UserIDFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading user ID file: %s", err)
+	}
+
+	return loginPath(m.Mount, "app-id"), map[string]interface{}{
+		"app_id":  appId,
+		"user_id": userId,
+	}, nil
+}
+
+// LoginSucceeded removes the user ID file if RemoveUserIDFile is set.
+func (m *AppIDMethod) LoginSucceeded() error {
+	if !m.RemoveUserIDFile {
+		return nil
+	}
+	if err := os.Remove(m.UserIDFile); err != nil {
+		return fmt.Errorf("error removing user ID file: %s", err)
+	}
+	return nil
+}
+
+// readCredentialFile reads a credential from the given path, trimming any
+// surrounding whitespace. An empty file is an error.
+func readCredentialFile(path string) (string, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSpace(string(raw))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,118 @@
+package autoauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/private/signer/v4"
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	awsSTSEndpoint       = "https://sts.amazonaws.com"
+	awsSTSRequestBody    = "Action=GetCallerIdentity&Version=2011-06-15"
+	awsIAMServerIDHeader = "X-Vault-AWS-IAM-Server-ID"
+)
+
+// AWSMethod authenticates using AWS IAM credentials. Rather than sending
+// the credentials themselves, a signed sts:GetCallerIdentity request is
+// sent to Vault, which can replay it against AWS to verify the caller's
+// identity.
+type AWSMethod struct {
+	// Mount is the mount point of the backend. Defaults to "aws".
+	Mount string
+
+	// Role is the role to log in against.
+	Role string
+
+	// Region is the region the STS request is signed for. Defaults to
+	// "us-east-1", matching the global STS endpoint.
+	Region string
+
+	// ServerIDHeader, if set, is included in the signed request as the
+	// X-Vault-AWS-IAM-Server-ID header to prevent replay against other
+	// Vault servers.
+	ServerIDHeader string
+
+	// Credentials are the AWS credentials used to sign the request. If
+	// nil, credentials are sourced from the environment, the shared
+	// credentials file, or the EC2 instance role, in that order.
+	Credentials *credentials.Credentials
+}
+
+func (m *AWSMethod) Authenticate(c *api.Client) (string, map[string]interface{}, error) {
+	creds := m.Credentials
+	if creds == nil {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvProvider{},
+			&credentials.SharedCredentialsProvider{Filename: "", Profile: ""},
+			&ec2rolecreds.EC2RoleProvider{Client: ec2metadata.New(session.New())},
+		})
+	}
+
+	region := m.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	req := request.New(
+		aws.Config{
+			Credentials: creds,
+			Region:      aws.String(region),
+		},
+		metadata.ClientInfo{
+			ServiceName:   "sts",
+			SigningRegion: region,
+			Endpoint:      awsSTSEndpoint,
+			APIVersion:    "2011-06-15",
+		},
+		request.Handlers{},
+		nil,
+		&request.Operation{
+			Name:       "GetCallerIdentity",
+			HTTPMethod: "POST",
+			HTTPPath:   "/",
+		},
+		nil,
+		nil)
+	req.HTTPRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if m.ServerIDHeader != "" {
+		req.HTTPRequest.Header.Set(awsIAMServerIDHeader, m.ServerIDHeader)
+	}
+	req.SetStringBody(awsSTSRequestBody)
+
+	v4.Sign(req)
+	if req.Error != nil {
+		return "", nil, fmt.Errorf("error signing STS request: %s", req.Error)
+	}
+
+	headers, err := json.Marshal(req.HTTPRequest.Header)
+	if err != nil {
+		return "", nil, err
+	}
+	body, err := ioutil.ReadAll(req.HTTPRequest.Body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	data := map[string]interface{}{
+		"iam_http_request_method": req.HTTPRequest.Method,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(req.HTTPRequest.URL.String())),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+		"iam_request_body":        base64.StdEncoding.EncodeToString(body),
+	}
+	if m.Role != "" {
+		data["role"] = m.Role
+	}
+
+	return loginPath(m.Mount, "aws"), data, nil
+}
@@ -0,0 +1,46 @@
+package autoauth
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// DefaultKubernetesTokenPath is the location Kubernetes mounts the pod's
+// service account token at.
+const DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// KubernetesMethod authenticates using the service account JWT mounted
+// into a Kubernetes pod.
+type KubernetesMethod struct {
+	// Mount is the mount point of the backend. Defaults to "kubernetes".
+	Mount string
+
+	// Role is the role to log in against.
+	Role string
+
+	// TokenPath is the path to the service account token. Defaults to
+	// DefaultKubernetesTokenPath.
+	TokenPath string
+}
+
+func (m *KubernetesMethod) Authenticate(c *api.Client) (string, map[string]interface{}, error) {
+	if m.Role == "" {
+		return "", nil, fmt.Errorf("'role' must be specified")
+	}
+
+	tokenPath := m.TokenPath
+	if tokenPath == "" {
+		tokenPath = DefaultKubernetesTokenPath
+	}
+
+	jwt, err := readCredentialFile(tokenPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading service account token: %s", err)
+	}
+
+	return loginPath(m.Mount, "kubernetes"), map[string]interface{}{
+		"role": m.Role,
+		"jwt":  jwt,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package autoauth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileSink writes tokens to a file on disk. The token is first written to
+// a temporary file in the same directory and then renamed into place, so
+// readers never observe a partially-written token.
+type FileSink struct {
+	// Path is the location of the token file.
+	Path string
+
+	// Mode is the file mode of the token file. If zero, 0640 is used.
+	Mode os.FileMode
+}
+
+func (s *FileSink) WriteToken(token string) error {
+	if s.Path == "" {
+		return fmt.Errorf("'path' must be specified")
+	}
+
+	mode := s.Mode
+	if mode == 0 {
+		mode = 0640
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.Path), ".vault-token")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if _, err := f.WriteString(token); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, s.Path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
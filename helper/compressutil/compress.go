@@ -0,0 +1,111 @@
+// Package compressutil compresses internal storage entries. Compressed
+// data is prefixed with a canary byte naming the algorithm, so that it
+// can be told apart from the uncompressed JSON written by earlier
+// versions, which always starts with '{' or '['.
+package compressutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+)
+
+const (
+	// CompressionTypeGzip compresses with gzip, which is slower but gives
+	// the best ratio; suited to large entries written rarely
+	CompressionTypeGzip = "gzip"
+
+	// CompressionTypeSnappy compresses with snappy, which is fast with a
+	// modest ratio; suited to entries written often
+	CompressionTypeSnappy = "snappy"
+
+	// CompressionCanaryGzip prefixes gzip compressed data
+	CompressionCanaryGzip byte = 'G'
+
+	// CompressionCanarySnappy prefixes snappy compressed data
+	CompressionCanarySnappy byte = 'S'
+)
+
+// CompressionConfig selects the algorithm used by Compress
+type CompressionConfig struct {
+	// Type is CompressionTypeGzip or CompressionTypeSnappy
+	Type string
+
+	// GzipCompressionLevel is passed to gzip; if zero, the default
+	// compression level is used
+	GzipCompressionLevel int
+}
+
+// Compress compresses the data with the configured algorithm and prefixes
+// the result with the matching canary byte.
+func Compress(data []byte, config *CompressionConfig) ([]byte, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+
+	var buf bytes.Buffer
+	switch config.Type {
+	case CompressionTypeGzip:
+		buf.WriteByte(CompressionCanaryGzip)
+
+		level := config.GzipCompressionLevel
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		writer, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip writer: %v", err)
+		}
+		if _, err := writer.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+
+	case CompressionTypeSnappy:
+		buf.WriteByte(CompressionCanarySnappy)
+		buf.Write(snappy.Encode(nil, data))
+
+	default:
+		return nil, fmt.Errorf("unsupported compression type %q", config.Type)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress reverses Compress. If the data does not start with a known
+// canary byte, it is returned as is and uncompressed is true.
+func Decompress(data []byte) (result []byte, uncompressed bool, err error) {
+	if len(data) == 0 {
+		return data, true, nil
+	}
+
+	switch data[0] {
+	case CompressionCanaryGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data[1:]))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create gzip reader: %v", err)
+		}
+		defer reader.Close()
+
+		result, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decompress: %v", err)
+		}
+		return result, false, nil
+
+	case CompressionCanarySnappy:
+		result, err = snappy.Decode(nil, data[1:])
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decompress: %v", err)
+		}
+		return result, false, nil
+
+	default:
+		return data, true, nil
+	}
+}
@@ -0,0 +1,70 @@
+package compressutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	input := []byte(`{"entries":[` + strings.Repeat(`{"path":"secret/","type":"generic"},`, 100) + `{}]}`)
+
+	cases := map[string]byte{
+		CompressionTypeGzip:   CompressionCanaryGzip,
+		CompressionTypeSnappy: CompressionCanarySnappy,
+	}
+	for typ, canary := range cases {
+		compressed, err := Compress(input, &CompressionConfig{Type: typ})
+		if err != nil {
+			t.Fatalf("%s: err: %v", typ, err)
+		}
+		if compressed[0] != canary {
+			t.Fatalf("%s: bad canary: %q", typ, compressed[0])
+		}
+		if len(compressed) >= len(input) {
+			t.Fatalf("%s: not compressed: %d >= %d", typ, len(compressed), len(input))
+		}
+
+		out, uncompressed, err := Decompress(compressed)
+		if err != nil {
+			t.Fatalf("%s: err: %v", typ, err)
+		}
+		if uncompressed {
+			t.Fatalf("%s: expected compressed data", typ)
+		}
+		if !bytes.Equal(out, input) {
+			t.Fatalf("%s: bad: %s", typ, out)
+		}
+	}
+}
+
+func TestCompress_BadType(t *testing.T) {
+	if _, err := Compress([]byte("foo"), &CompressionConfig{Type: "lzma"}); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := Compress([]byte("foo"), nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestDecompress_Uncompressed(t *testing.T) {
+	input := []byte(`{"foo":"bar"}`)
+	out, uncompressed, err := Decompress(input)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !uncompressed {
+		t.Fatalf("expected uncompressed data")
+	}
+	if !bytes.Equal(out, input) {
+		t.Fatalf("bad: %s", out)
+	}
+}
+
+func TestDecompress_Corrupt(t *testing.T) {
+	for _, canary := range []byte{CompressionCanaryGzip, CompressionCanarySnappy} {
+		if _, _, err := Decompress([]byte{canary, 0xff, 0xfe}); err == nil {
+			t.Fatalf("%q: expected error", canary)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package ratelimit provides a simple in-memory limiter for failed
+// attempts, used by credential backends to slow down guessing of
+// passwords on unauthenticated endpoints.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxFailures is the number of failures allowed within the
+	// window before further attempts are refused.
+	DefaultMaxFailures = 5
+
+	// DefaultWindow is the window over which failures are counted.
+	DefaultWindow = 5 * time.Minute
+)
+
+// FailureLimiter tracks failed attempts by key. Once a key has
+// accumulated MaxFailures failures within Window, Allow returns false
+// until the oldest failure falls outside of the window.
+//
+// Every attempt Allow lets through is counted as a failure straight
+// away, so that concurrent attempts can't all pass before any of them
+// fails. Call Reset once an attempt succeeds, or Release if it could not
+// be made, such as when the server checking it is unavailable.
+type FailureLimiter struct {
+	MaxFailures int
+	Window      time.Duration
+
+	l         sync.Mutex
+	failures  map[string][]time.Time
+	lastSweep time.Time
+
+	// now is overridable for testing
+	now func() time.Time
+}
+
+// NewFailureLimiter returns a FailureLimiter. Zero values for either
+// argument are replaced with the defaults.
+func NewFailureLimiter(maxFailures int, window time.Duration) *FailureLimiter {
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxFailures
+	}
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &FailureLimiter{
+		MaxFailures: maxFailures,
+		Window:      window,
+		failures:    make(map[string][]time.Time),
+		now:         time.Now,
+	}
+}
+
+// Allow returns whether an attempt for the given key may proceed, and if
+// not, how long until it may be retried. An attempt that may proceed is
+// recorded as a failure until Reset is called.
+func (f *FailureLimiter) Allow(key string) (bool, time.Duration) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	now := f.now()
+	f.sweep(now)
+	recent := f.prune(key, now)
+	if len(recent) >= f.MaxFailures {
+		return false, recent[0].Add(f.Window).Sub(now)
+	}
+	f.failures[key] = append(recent, now)
+	return true, 0
+}
+
+// Reset clears any failures recorded for the given key, typically after
+// a successful attempt.
+func (f *FailureLimiter) Reset(key string) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	delete(f.failures, key)
+}
+
+// Release removes the failure recorded by Allow for an attempt that
+// neither succeeded nor failed, so that it does not count against the
+// key.
+func (f *FailureLimiter) Release(key string) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	attempts := f.failures[key]
+	if len(attempts) <= 1 {
+		delete(f.failures, key)
+		return
+	}
+	f.failures[key] = attempts[:len(attempts)-1]
+}
+
+// sweep prunes every key once per window, so that keys which are never
+// used again don't hold on to memory. Must be called with the lock held.
+func (f *FailureLimiter) sweep(now time.Time) {
+	if now.Sub(f.lastSweep) < f.Window {
+		return
+	}
+	f.lastSweep = now
+	for key := range f.failures {
+		f.prune(key, now)
+	}
+}
+
+// prune drops failures outside of the window for key and returns the
+// remainder. Must be called with the lock held.
+func (f *FailureLimiter) prune(key string, now time.Time) []time.Time {
+	attempts := f.failures[key]
+	cutoff := now.Add(-f.Window)
+	i := 0
+	for i < len(attempts) && !attempts[i].After(cutoff) {
+		i++
+	}
+	attempts = attempts[i:]
+	if len(attempts) == 0 {
+		delete(f.failures, key)
+		return nil
+	}
+	f.failures[key] = attempts
+	return attempts
+}
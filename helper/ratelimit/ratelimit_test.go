@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFailureLimiter(t *testing.T) {
+	now := time.Now()
+	f := NewFailureLimiter(3, time.Minute)
+	f.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := f.Allow("foo"); !ok {
+			t.Fatalf("bad: attempt %d refused", i)
+		}
+	}
+
+	ok, wait := f.Allow("foo")
+	if ok {
+		t.Fatalf("bad: attempt allowed after max failures")
+	}
+	if wait != time.Minute {
+		t.Fatalf("bad: %v", wait)
+	}
+
+	// Other keys are unaffected
+	if ok, _ := f.Allow("bar"); !ok {
+		t.Fatalf("bad: unrelated key refused")
+	}
+
+	// Failures age out of the window, and keys that are not used again
+	// are swept
+	now = now.Add(time.Minute + time.Second)
+	if ok, _ := f.Allow("foo"); !ok {
+		t.Fatalf("bad: attempt refused after window")
+	}
+	if _, ok := f.failures["bar"]; ok || len(f.failures["foo"]) != 1 {
+		t.Fatalf("bad: %#v", f.failures)
+	}
+}
+
+func TestFailureLimiter_Reset(t *testing.T) {
+	f := NewFailureLimiter(1, time.Minute)
+	if ok, _ := f.Allow("foo"); !ok {
+		t.Fatalf("bad: first attempt refused")
+	}
+	if ok, _ := f.Allow("foo"); ok {
+		t.Fatalf("bad: attempt allowed after max failures")
+	}
+	f.Reset("foo")
+	if ok, _ := f.Allow("foo"); !ok {
+		t.Fatalf("bad: attempt refused after reset")
+	}
+}
+
+func TestFailureLimiter_Release(t *testing.T) {
+	f := NewFailureLimiter(2, time.Minute)
+
+	// Released attempts don't count
+	for i := 0; i < 5; i++ {
+		if ok, _ := f.Allow("foo"); !ok {
+			t.Fatalf("bad: attempt %d refused", i)
+		}
+		f.Release("foo")
+	}
+	if _, ok := f.failures["foo"]; ok {
+		t.Fatalf("bad: %#v", f.failures)
+	}
+
+	// Only the released attempt is removed
+	f.Allow("foo")
+	f.Allow("foo")
+	f.Release("foo")
+	if len(f.failures["foo"]) != 1 {
+		t.Fatalf("bad: %#v", f.failures)
+	}
+}
@@ -15,6 +15,13 @@ import (
 // AuthHeaderName is the name of the header containing the token.
 const AuthHeaderName = "X-Vault-Token"
 
+// NonceHeaderName and TimestampHeaderName are the names of the headers
+// checked when replay protection is enabled.
+const (
+	NonceHeaderName     = "X-Vault-Request-Nonce"
+	TimestampHeaderName = "X-Vault-Request-Timestamp"
+)
+
 // Handler returns an http.Handler for the API. This can be used on
 // its own to mount the Vault API within another web server.
 func Handler(core *vault.Core) http.Handler {
@@ -34,24 +41,61 @@ func Handler(core *vault.Core) http.Handler {
 	mux.Handle("/v1/sys/revoke-prefix/", proxySysRequest(core))
 	mux.Handle("/v1/sys/auth", proxySysRequest(core))
 	mux.Handle("/v1/sys/auth/", proxySysRequest(core))
+	mux.Handle("/v1/sys/login-dry-run/", handleLogical(core, false))
 	mux.Handle("/v1/sys/audit-hash/", proxySysRequest(core))
 	mux.Handle("/v1/sys/audit", proxySysRequest(core))
 	mux.Handle("/v1/sys/audit/", proxySysRequest(core))
 	mux.Handle("/v1/sys/leader", handleSysLeader(core))
 	mux.Handle("/v1/sys/health", handleSysHealth(core))
 	mux.Handle("/v1/sys/rotate", proxySysRequest(core))
+	mux.Handle("/v1/sys/revocation-failures", proxySysRequest(core))
+	mux.Handle("/v1/sys/leases", proxySysRequest(core))
+	mux.Handle("/v1/sys/in-flight-req", proxySysRequest(core))
+	mux.Handle("/v1/sys/maintenance", proxySysRequest(core))
+	mux.Handle("/v1/sys/internal/counters/requests", proxySysRequest(core))
+	mux.Handle("/v1/sys/copy", handleSysCopy(core))
 	mux.Handle("/v1/sys/key-status", proxySysRequest(core))
+	mux.Handle("/v1/sys/storage/snapshot", handleSysSnapshot(core))
 	mux.Handle("/v1/sys/rekey/init", handleSysRekeyInit(core))
 	mux.Handle("/v1/sys/rekey/backup", proxySysRequest(core))
 	mux.Handle("/v1/sys/rekey/update", handleSysRekeyUpdate(core))
+	mux.Handle("/v1/sys/generate-recovery-token/init", handleSysRecoveryTokenInit(core))
+	mux.Handle("/v1/sys/generate-recovery-token/update", handleSysRecoveryTokenUpdate(core))
 	mux.Handle("/v1/", handleLogical(core, false))
 
+	// Wrap the handler in another handler to reject replayed requests
+	// to sensitive unauthenticated paths, if enabled.
+	handler := handleReplayProtection(mux, core)
+
 	// Wrap the handler in another handler to trigger all help paths.
-	handler := handleHelpHandler(mux, core)
+	handler = handleHelpHandler(handler, core)
 
 	return handler
 }
 
+// handleReplayProtection rejects requests to replay protected paths that
+// reuse a nonce or carry a stale timestamp.
+func handleReplayProtection(h http.Handler, core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path, ok := stripPrefix("/v1/", req.URL.Path)
+		if ok && core.ReplayProtected(path) {
+			err := core.CheckReplay(
+				req.Header.Get(NonceHeaderName), req.Header.Get(TimestampHeaderName))
+			switch err {
+			case nil:
+			case vault.ErrReplayedRequest:
+				respondError(w, http.StatusConflict, err)
+				return
+			default:
+				respondError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
 // stripPrefix is a helper to strip a prefix from the path. It will
 // return false from the second return value if it the prefix doesn't exist.
 func stripPrefix(prefix, path string) (string, bool) {
@@ -184,7 +228,7 @@ func respondCommon(w http.ResponseWriter, resp *logical.Response, err error) boo
 			statusCode = http.StatusForbidden
 		case logical.ErrUnsupportedOperation:
 			statusCode = http.StatusMethodNotAllowed
-		case logical.ErrUnsupportedPath:
+		case logical.ErrUnsupportedPath, logical.ErrNotFound:
 			statusCode = http.StatusNotFound
 		case logical.ErrInvalidRequest:
 			statusCode = http.StatusBadRequest
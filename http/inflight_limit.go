@@ -0,0 +1,50 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/armon/go-metrics"
+)
+
+// inFlightRetryAfter is the number of seconds clients are told to wait
+// before retrying a request rejected by the in-flight limit.
+const inFlightRetryAfter = "1"
+
+// inFlightExemptPaths are always served, so that load balancers and
+// monitoring can still check on a Vault that is at its limit.
+var inFlightExemptPaths = map[string]bool{
+	"/v1/sys/health":      true,
+	"/v1/sys/seal-status": true,
+	"/v1/sys/leader":      true,
+}
+
+// InFlightLimitHandler wraps a handler to serve at most max requests at
+// once. Further requests are rejected with a 503 and a Retry-After header
+// rather than queued. If max is zero or less, h is returned unwrapped.
+func InFlightLimitHandler(h http.Handler, max int) http.Handler {
+	if max <= 0 {
+		return h
+	}
+
+	sem := make(chan struct{}, max)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if inFlightExemptPaths[req.URL.Path] {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		select {
+		case sem <- struct{}{}:
+		default:
+			metrics.IncrCounter([]string{"http", "in-flight-rejected"}, 1)
+			w.Header().Set("Retry-After", inFlightRetryAfter)
+			respondError(w, http.StatusServiceUnavailable, fmt.Errorf(
+				"too many requests in flight, retry later"))
+			return
+		}
+		defer func() { <-sem }()
+
+		h.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInFlightLimitHandler(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/secret/foo" {
+			started <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := InFlightLimitHandler(blocking, 1)
+
+	// Occupy the only slot
+	done := make(chan struct{})
+	go func() {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/secret/foo", nil))
+		close(done)
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/secret/bar", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("bad: %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != inFlightRetryAfter {
+		t.Fatalf("bad: %#v", w.Header())
+	}
+
+	// Health checks are still served
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/sys/health", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("bad: %d", w.Code)
+	}
+
+	close(release)
+	<-done
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/secret/bar", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("bad: %d", w.Code)
+	}
+}
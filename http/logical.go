@@ -65,6 +65,7 @@ func handleLogical(core *vault.Core, dataOnly bool) http.Handler {
 			Path:       path,
 			Data:       req,
 			Connection: getConnection(r),
+			Headers:    r.Header,
 		}))
 		if !ok {
 			return
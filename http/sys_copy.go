@@ -0,0 +1,89 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func handleSysCopy(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT", "POST":
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		// Parse the request
+		var req CopyRequest
+		if err := parseRequest(r, &req); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Source == "" || req.Destination == "" {
+			respondError(
+				w, http.StatusBadRequest,
+				errors.New("'source' and 'destination' must be specified in request body as JSON"))
+			return
+		}
+
+		auth := requestAuth(r, &logical.Request{})
+		copied, err := core.CopySecrets(auth.ClientToken, req.Source, req.Destination, req.Move)
+		if err == vault.ErrStandby {
+			respondStandby(core, w, r.URL)
+			return
+		}
+		if err != nil {
+			respondCopyError(w, err, copied)
+			return
+		}
+
+		respondOk(w, &CopyResponse{
+			Copied: copied,
+		})
+	})
+}
+
+// respondCopyError responds with the error of a copy along with the
+// secrets copied before it occurred, since a move has already deleted
+// them from the source
+func respondCopyError(w http.ResponseWriter, err error, copied []string) {
+	status := http.StatusInternalServerError
+	switch err {
+	case vault.ErrSealed:
+		status = http.StatusServiceUnavailable
+	case logical.ErrPermissionDenied:
+		status = http.StatusForbidden
+	case logical.ErrInvalidRequest, logical.ErrUnsupportedPath, logical.ErrUnsupportedOperation:
+		status = http.StatusBadRequest
+	}
+	if t, ok := err.(logical.HTTPCodedError); ok {
+		status = t.Code()
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&CopyErrorResponse{
+		Errors: []string{err.Error()},
+		Copied: copied,
+	})
+}
+
+type CopyRequest struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Move        bool   `json:"move"`
+}
+
+type CopyResponse struct {
+	Copied []string `json:"copied"`
+}
+
+type CopyErrorResponse struct {
+	Errors []string `json:"errors"`
+	Copied []string `json:"copied"`
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysCopy(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/secret/app/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/copy", map[string]interface{}{
+		"source":      "secret/app/",
+		"destination": "secret/moved/",
+		"move":        true,
+	})
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	expected := map[string]interface{}{
+		"copied": []interface{}{"foo"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/secret/moved/foo")
+	testResponseStatus(t, resp, 200)
+	resp = testHttpGet(t, token, addr+"/v1/secret/app/foo")
+	testResponseStatus(t, resp, 404)
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/copy", map[string]interface{}{
+		"source":      "secret/app/foo",
+		"destination": "secret/other",
+	})
+	testResponseStatus(t, resp, 404)
+}
+
+func TestSysCopy_partialMove(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	// The token may only write one of the destinations
+	resp := testHttpPut(t, token, addr+"/v1/sys/policy/mover", map[string]interface{}{
+		"rules": `
+path "secret/app/*" { policy = "write" }
+path "secret/moved/a" { policy = "write" }
+`,
+	})
+	testResponseStatus(t, resp, 204)
+	resp = testHttpPost(t, token, addr+"/v1/auth/token/create", map[string]interface{}{
+		"policies": []string{"mover"},
+	})
+	testResponseStatus(t, resp, 200)
+	var tokenResp map[string]interface{}
+	testResponseBody(t, resp, &tokenResp)
+	mover := tokenResp["auth"].(map[string]interface{})["client_token"].(string)
+
+	for _, key := range []string{"a", "b"} {
+		resp = testHttpPut(t, token, addr+"/v1/secret/app/"+key, map[string]interface{}{
+			"data": "bar",
+		})
+		testResponseStatus(t, resp, 204)
+	}
+
+	// The error lists the secret already moved
+	resp = testHttpPut(t, mover, addr+"/v1/sys/copy", map[string]interface{}{
+		"source":      "secret/app/",
+		"destination": "secret/moved/",
+		"move":        true,
+	})
+	testResponseStatus(t, resp, 403)
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	if !reflect.DeepEqual(actual["copied"], []interface{}{"a"}) || actual["errors"] == nil {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// Mismatched paths are bad input
+	resp = testHttpPut(t, token, addr+"/v1/sys/copy", map[string]interface{}{
+		"source":      "secret/app/",
+		"destination": "secret/other",
+	})
+	testResponseStatus(t, resp, 400)
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysMaintenance(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/sys/policy/writer", map[string]interface{}{
+		"rules": `path "secret/*" { policy = "write" }`,
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPost(t, token, addr+"/v1/auth/token/create", map[string]interface{}{
+		"policies": []string{"writer"},
+	})
+	testResponseStatus(t, resp, 200)
+	var tokenResp map[string]interface{}
+	testResponseBody(t, resp, &tokenResp)
+	writer := tokenResp["auth"].(map[string]interface{})["client_token"].(string)
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/maintenance", map[string]interface{}{
+		"paths":   "secret/*",
+		"message": "migrating storage",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPut(t, writer, addr+"/v1/secret/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, 503)
+	var errResp map[string]interface{}
+	testResponseBody(t, resp, &errResp)
+	errs := errResp["errors"].([]interface{})
+	if len(errs) != 1 || !strings.Contains(errs[0].(string), "migrating storage") {
+		t.Fatalf("bad: %#v", errResp)
+	}
+
+	// Root tokens are exempt
+	resp = testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpDelete(t, token, addr+"/v1/sys/maintenance")
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPut(t, writer, addr+"/v1/secret/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, 204)
+}
@@ -373,8 +373,11 @@ func TestSysTuneMount(t *testing.T) {
 	resp = testHttpGet(t, token, addr+"/v1/sys/mounts/foo/tune")
 	actual = map[string]interface{}{}
 	expected = map[string]interface{}{
-		"default_lease_ttl": float64(259196400),
-		"max_lease_ttl":     float64(259200000),
+		"default_lease_ttl":           float64(259196400),
+		"max_lease_ttl":               float64(259200000),
+		"force_no_cache":              false,
+		"passthrough_request_headers": []interface{}{},
+		"mask_not_found":              false,
 	}
 
 	testResponseStatus(t, resp, 200)
@@ -393,8 +396,11 @@ func TestSysTuneMount(t *testing.T) {
 	resp = testHttpGet(t, token, addr+"/v1/sys/mounts/secret/tune")
 	actual = map[string]interface{}{}
 	expected = map[string]interface{}{
-		"default_lease_ttl": float64(40),
-		"max_lease_ttl":     float64(80),
+		"default_lease_ttl":           float64(40),
+		"max_lease_ttl":               float64(80),
+		"force_no_cache":              false,
+		"passthrough_request_headers": []interface{}{},
+		"mask_not_found":              false,
 	}
 
 	testResponseStatus(t, resp, 200)
@@ -403,6 +409,33 @@ func TestSysTuneMount(t *testing.T) {
 		t.Fatalf("bad:\nExpected: %#v\nActual:%#v", expected, actual)
 	}
 
+	// Disable caching, pass through a header and mask missing items
+	resp = testHttpPost(t, token, addr+"/v1/sys/mounts/foo/tune", map[string]interface{}{
+		"force_no_cache":              true,
+		"passthrough_request_headers": "x-custom-header, X-Other",
+		"mask_not_found":              true,
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/mounts/foo/tune")
+	actual = map[string]interface{}{}
+	expected = map[string]interface{}{
+		"default_lease_ttl":           float64(259196400),
+		"max_lease_ttl":               float64(259200000),
+		"force_no_cache":              true,
+		"passthrough_request_headers": []interface{}{"X-Custom-Header", "X-Other"},
+		"mask_not_found":              true,
+	}
+
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad:\nExpected: %#v\nActual:%#v", expected, actual)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/foo/missing")
+	testResponseStatus(t, resp, 403)
+
 	// First try with lease above backend max
 	resp = testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{
 		"data": "bar",
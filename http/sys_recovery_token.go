@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/vault"
+)
+
+func handleSysRecoveryTokenInit(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			handleSysRecoveryTokenInitGet(core, w, r)
+		case "POST", "PUT":
+			handleSysRecoveryTokenInitPut(core, w, r)
+		case "DELETE":
+			handleSysRecoveryTokenInitDelete(core, w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+		}
+	})
+}
+
+func handleSysRecoveryTokenInitGet(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	// Get the current seal configuration
+	sealConfig, err := core.SealConfig()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if sealConfig == nil {
+		respondError(w, http.StatusBadRequest, fmt.Errorf(
+			"server is not yet initialized"))
+		return
+	}
+
+	// Get the generation configuration
+	conf, err := core.RecoveryTokenConfiguration()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Get the progress
+	progress, err := core.RecoveryTokenProgress()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Format the status
+	status := &RecoveryTokenStatusResponse{
+		Started:  false,
+		Progress: progress,
+		Required: sealConfig.SecretThreshold,
+	}
+	if conf != nil {
+		status.Nonce = conf.Nonce
+		status.Started = true
+		if conf.PGPKey != "" {
+			fingerprints, err := pgpkeys.GetFingerprints([]string{conf.PGPKey}, nil)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			status.PGPFingerprint = fingerprints[0]
+		}
+	}
+	respondOk(w, status)
+}
+
+func handleSysRecoveryTokenInitPut(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	// Parse the request, which may be empty as the PGP key is optional
+	var req RecoveryTokenInitRequest
+	if err := parseRequest(r, &req); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Start the generation
+	if err := core.RecoveryTokenInit(req.PGPKey); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	handleSysRecoveryTokenInitGet(core, w, r)
+}
+
+func handleSysRecoveryTokenInitDelete(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	err := core.RecoveryTokenCancel()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondOk(w, nil)
+}
+
+func handleSysRecoveryTokenUpdate(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Parse the request
+		var req RecoveryTokenUpdateRequest
+		if err := parseRequest(r, &req); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Key == "" {
+			respondError(
+				w, http.StatusBadRequest,
+				errors.New("'key' must specified in request body as JSON"))
+			return
+		}
+
+		// Decode the key, which is hex encoded
+		key, err := hex.DecodeString(req.Key)
+		if err != nil {
+			respondError(
+				w, http.StatusBadRequest,
+				errors.New("'key' must be a valid hex-string"))
+			return
+		}
+
+		// Use the key to make progress on the generation
+		result, err := core.RecoveryTokenUpdate(key, req.Nonce)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Format the response
+		resp := &RecoveryTokenUpdateResponse{
+			Nonce: req.Nonce,
+		}
+		if result != nil {
+			resp.Complete = true
+			resp.EncodedToken = result.EncodedToken
+			resp.PGPFingerprint = result.PGPFingerprint
+			resp.TTL = int64(result.TTL.Seconds())
+		} else {
+			progress, err := core.RecoveryTokenProgress()
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			resp.Progress = progress
+		}
+		respondOk(w, resp)
+	})
+}
+
+type RecoveryTokenInitRequest struct {
+	PGPKey string `json:"pgp_key"`
+}
+
+type RecoveryTokenStatusResponse struct {
+	Nonce          string `json:"nonce"`
+	Started        bool   `json:"started"`
+	Progress       int    `json:"progress"`
+	Required       int    `json:"required"`
+	PGPFingerprint string `json:"pgp_fingerprint"`
+}
+
+type RecoveryTokenUpdateRequest struct {
+	Nonce string
+	Key   string
+}
+
+type RecoveryTokenUpdateResponse struct {
+	Nonce          string `json:"nonce"`
+	Complete       bool   `json:"complete"`
+	Progress       int    `json:"progress"`
+	EncodedToken   string `json:"encoded_token"`
+	PGPFingerprint string `json:"pgp_fingerprint"`
+	TTL            int64  `json:"ttl"`
+}
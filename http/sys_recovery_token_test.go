@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysRecoveryToken_Status(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpGet(t, "", addr+"/v1/sys/generate-recovery-token/init")
+
+	var actual map[string]interface{}
+	expected := map[string]interface{}{
+		"nonce":           "",
+		"started":         false,
+		"progress":        float64(0),
+		"required":        float64(1),
+		"pgp_fingerprint": "",
+	}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("\nexpected: %#v\nactual: %#v", expected, actual)
+	}
+}
+
+func TestSysRecoveryToken_Update(t *testing.T) {
+	core, master, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, "", addr+"/v1/sys/generate-recovery-token/init", nil)
+	var status map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &status)
+	if status["started"] != true {
+		t.Fatalf("bad: %#v", status)
+	}
+
+	resp = testHttpPut(t, "", addr+"/v1/sys/generate-recovery-token/update", map[string]interface{}{
+		"nonce": status["nonce"],
+		"key":   hex.EncodeToString(master),
+	})
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if actual["complete"] != true || actual["encoded_token"] == "" || actual["ttl"] != float64(1800) {
+		t.Fatalf("bad: %#v", actual)
+	}
+	recoveryToken := actual["encoded_token"].(string)
+
+	// The recovery token can read raw storage but not secrets
+	resp = testHttpGet(t, recoveryToken, addr+"/v1/sys/raw/core/mounts")
+	testResponseStatus(t, resp, 200)
+	resp = testHttpGet(t, recoveryToken, addr+"/v1/secret/foo")
+	testResponseStatus(t, resp, 403)
+}
+
+func TestSysRecoveryToken_Cancel(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, "", addr+"/v1/sys/generate-recovery-token/init", nil)
+	testResponseStatus(t, resp, 200)
+
+	resp = testHttpDelete(t, "", addr+"/v1/sys/generate-recovery-token/init")
+	testResponseStatus(t, resp, 204)
+
+	config, err := core.RecoveryTokenConfiguration()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if config != nil {
+		t.Fatalf("bad: %#v", config)
+	}
+}
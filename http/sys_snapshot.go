@@ -0,0 +1,64 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func handleSysSnapshot(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			handleSysSnapshotSave(core, w, r)
+		case "PUT":
+			fallthrough
+		case "POST":
+			handleSysSnapshotRestore(core, w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+		}
+	})
+}
+
+func handleSysSnapshotSave(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	req := requestAuth(r, &logical.Request{})
+
+	// Buffer the snapshot so that a failure part way can still be
+	// reported with an error status
+	var buf bytes.Buffer
+	if err := core.Snapshot(req.ClientToken, &buf); err != nil {
+		respondSnapshotError(core, w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+func handleSysSnapshotRestore(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	req := requestAuth(r, &logical.Request{})
+
+	// The compressed snapshot is held to the same limit as its contents
+	body := http.MaxBytesReader(w, r.Body, vault.SnapshotMaxSize)
+	if err := core.RestoreSnapshot(req.ClientToken, body); err != nil {
+		respondSnapshotError(core, w, r, err)
+		return
+	}
+
+	respondOk(w, nil)
+}
+
+func respondSnapshotError(core *vault.Core, w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case vault.ErrStandby:
+		respondStandby(core, w, r.URL)
+	case logical.ErrPermissionDenied:
+		respondError(w, http.StatusForbidden, err)
+	default:
+		respondError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysSnapshot(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	req, err := http.NewRequest("GET", addr+"/v1/sys/storage/snapshot", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, 200)
+	snapshot, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	req, err = http.NewRequest("PUT", addr+"/v1/sys/storage/snapshot", bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, 204)
+
+	if sealed, _ := core.Sealed(); !sealed {
+		t.Fatalf("should be sealed after restoring")
+	}
+}
+
+func TestSysSnapshot_badToken(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, "bogus", addr+"/v1/sys/storage/snapshot", nil)
+	testResponseStatus(t, resp, 403)
+
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatalf("should not be sealed")
+	}
+}
@@ -51,6 +51,19 @@ type Backend struct {
 	Rollback       RollbackFunc
 	RollbackMinAge time.Duration
 
+	// PeriodicFunc is called each time the rollback manager triggers a
+	// RollbackOperation for the backend, which is roughly once a minute.
+	// Backends can use it for periodic housekeeping such as cleaning up
+	// credentials that failed to be revoked.
+	PeriodicFunc PeriodicFunc
+
+	// MaskNotFound, if true, returns requests for items that do not exist
+	// as permission denied rather than not found, so that clients cannot
+	// probe for the names of roles, keys and so on. This applies to errors
+	// returned with NotFound and to reads that return no response. It is
+	// also enabled by the mask_not_found option of the mount.
+	MaskNotFound bool
+
 	// Clean is called on unload to clean up e.g any existing connections
 	// to the backend, if required.
 	Clean CleanupFunc
@@ -72,6 +85,9 @@ type OperationFunc func(*logical.Request, *FieldData) (*logical.Response, error)
 // RollbackFunc is the callback for rollbacks.
 type RollbackFunc func(*logical.Request, string, interface{}) error
 
+// PeriodicFunc is the callback for periodic housekeeping.
+type PeriodicFunc func(*logical.Request) error
+
 // CleanupFunc is the callback for backend unload.
 type CleanupFunc func()
 
@@ -181,12 +197,19 @@ func (b *Backend) HandleRequest(req *logical.Request) (*logical.Response, error)
 	if req.Operation != logical.HelpOperation {
 		err := fd.Validate()
 		if err != nil {
-			return nil, err
+			return InvalidRequest(err.Error())
 		}
 	}
 
 	// Call the callback with the request and the data
-	return callback(req, &fd)
+	resp, err := callback(req, &fd)
+	if b.MaskNotFound || (b.system != nil && b.system.MaskNotFound()) {
+		if err == logical.ErrNotFound ||
+			(req.Operation == logical.ReadOperation && resp == nil && err == nil) {
+			return PermissionDenied(logical.ErrPermissionDenied.Error())
+		}
+	}
+	return resp, err
 }
 
 // logical.Backend impl.
@@ -394,17 +417,39 @@ func (b *Backend) handleAuthRenew(req *logical.Request) (*logical.Response, erro
 
 func (b *Backend) handleRollback(
 	req *logical.Request) (*logical.Response, error) {
-	if b.Rollback == nil {
+	if b.Rollback == nil && b.PeriodicFunc == nil {
 		return nil, logical.ErrUnsupportedOperation
 	}
 
+	var merr error
+	if b.PeriodicFunc != nil {
+		if err := b.PeriodicFunc(req); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	if b.Rollback != nil {
+		if err := b.handleWALRollback(req); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	if merr == nil {
+		return nil, nil
+	}
+
+	return logical.ErrorResponse(merr.Error()), nil
+}
+
+// handleWALRollback rolls back any WAL entries that are old enough.
+func (b *Backend) handleWALRollback(req *logical.Request) error {
 	var merr error
 	keys, err := ListWAL(req.Storage)
 	if err != nil {
-		return logical.ErrorResponse(err.Error()), nil
+		return err
 	}
 	if len(keys) == 0 {
-		return nil, nil
+		return nil
 	}
 
 	// Calculate the minimum time that the WAL entries could be
@@ -447,11 +492,7 @@ func (b *Backend) handleRollback(
 		}
 	}
 
-	if merr == nil {
-		return nil, nil
-	}
-
-	return logical.ErrorResponse(merr.Error()), nil
+	return merr
 }
 
 // FieldSchema is a basic schema to describe the format of a path field.
@@ -366,6 +366,28 @@ func TestBackendHandleRequest_rollbackMinAge(t *testing.T) {
 	}
 }
 
+func TestBackendHandleRequest_periodic(t *testing.T) {
+	var called uint32
+	b := &Backend{
+		PeriodicFunc: func(req *logical.Request) error {
+			atomic.AddUint32(&called, 1)
+			return nil
+		},
+	}
+
+	_, err := b.HandleRequest(&logical.Request{
+		Operation: logical.RollbackOperation,
+		Path:      "",
+		Storage:   new(logical.InmemStorage),
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v := atomic.LoadUint32(&called); v != 1 {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
 func TestBackendHandleRequest_unsupportedOperation(t *testing.T) {
 	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
 		return &logical.Response{
@@ -399,6 +421,94 @@ func TestBackendHandleRequest_unsupportedOperation(t *testing.T) {
 	}
 }
 
+func TestBackendHandleRequest_maskNotFound(t *testing.T) {
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		if data.Get("name").(string) == "missing" {
+			return NotFound("unknown role")
+		}
+		return nil, nil
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: `roles/(?P<name>\w+)`,
+				Fields: map[string]*FieldSchema{
+					"name": &FieldSchema{Type: TypeString},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation:   callback,
+					logical.UpdateOperation: callback,
+				},
+			},
+		},
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/missing",
+	})
+	if err != logical.ErrNotFound || !resp.IsError() {
+		t.Fatalf("bad: %#v %s", resp, err)
+	}
+
+	b.MaskNotFound = true
+	for _, op := range []logical.Operation{logical.UpdateOperation, logical.ReadOperation} {
+		resp, err = b.HandleRequest(&logical.Request{
+			Operation: op,
+			Path:      "roles/missing",
+		})
+		if err != logical.ErrPermissionDenied || !resp.IsError() {
+			t.Fatalf("bad: %s: %#v %s", op, resp, err)
+		}
+	}
+
+	// A read that returns no response is also masked
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/empty",
+	})
+	if err != logical.ErrPermissionDenied || !resp.IsError() {
+		t.Fatalf("bad: %#v %s", resp, err)
+	}
+
+	// But other operations are left alone
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/empty",
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %#v %s", resp, err)
+	}
+}
+
+func TestBackendHandleRequest_invalidField(t *testing.T) {
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: `foo/bar`,
+				Fields: map[string]*FieldSchema{
+					"value": &FieldSchema{Type: TypeInt},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: func(*logical.Request, *FieldData) (*logical.Response, error) {
+						return nil, nil
+					},
+				},
+			},
+		},
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "foo/bar",
+		Data:      map[string]interface{}{"value": "bar"},
+	})
+	if err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("bad: %#v %s", resp, err)
+	}
+}
+
 func TestBackendHandleRequest_urlPriority(t *testing.T) {
 	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
 		return &logical.Response{
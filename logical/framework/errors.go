@@ -0,0 +1,29 @@
+package framework
+
+import (
+	"github.com/hashicorp/vault/logical"
+)
+
+// The helpers below return an error response paired with the error that
+// the HTTP layer uses to pick the status code, so that backends report
+// the same kind of failure in the same way. They can be returned directly
+// from an OperationFunc.
+
+// NotFound is used when the request refers to something, such as a role
+// or certificate, that does not exist. It results in a 404, or a 403 if
+// the backend or its mount has MaskNotFound set.
+func NotFound(msg string) (*logical.Response, error) {
+	return logical.ErrorResponse(msg), logical.ErrNotFound
+}
+
+// PermissionDenied is used when the client is not allowed to perform the
+// request. It results in a 403.
+func PermissionDenied(msg string) (*logical.Response, error) {
+	return logical.ErrorResponse(msg), logical.ErrPermissionDenied
+}
+
+// InvalidRequest is used when the request is malformed or its parameters
+// are not valid. It results in a 400.
+func InvalidRequest(msg string) (*logical.Response, error) {
+	return logical.ErrorResponse(msg), logical.ErrInvalidRequest
+}
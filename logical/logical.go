@@ -64,4 +64,8 @@ type Paths struct {
 
 	// Unauthenticated are the paths that can be accessed without any auth.
 	Unauthenticated []string
+
+	// Connection are the paths that are passed the connection of the
+	// request. Other than these, only Unauthenticated paths are passed it.
+	Connection []string
 }
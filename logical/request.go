@@ -52,6 +52,10 @@ type Request struct {
 	// paths relative to itself. The `Path` is effectively the client
 	// request path with the MountPoint trimmed off.
 	MountPoint string
+
+	// Headers are the headers of the client request. Only the headers
+	// the mount has been tuned to pass through reach the backend.
+	Headers map[string][]string
 }
 
 // Get returns a data field and guards for nil Data
@@ -128,6 +132,13 @@ const (
 	ListOperation             = "list"
 	HelpOperation             = "help"
 
+	// ResolveLoginOperation is sent to the login path of a credential
+	// backend by a login dry run. The backend responds with the Auth that a
+	// login by the identity given in the data would be granted, resolved
+	// from its stored configuration without verifying any credentials and
+	// without side effects.
+	ResolveLoginOperation = "resolve-login"
+
 	// The operations below are called globally, the path is less relevant.
 	RevokeOperation   Operation = "revoke"
 	RenewOperation              = "renew"
@@ -148,4 +159,8 @@ var (
 
 	// ErrPermissionDenied is returned if the client is not authorized
 	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrNotFound is returned if the request refers to an item, such as
+	// a role or certificate, that does not exist
+	ErrNotFound = errors.New("not found")
 )
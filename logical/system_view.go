@@ -16,12 +16,17 @@ type SystemView interface {
 	// SudoPrivilege returns true if given path has sudo privileges
 	// for the given client token
 	SudoPrivilege(path string, token string) bool
+
+	// MaskNotFound returns true if requests for items that do not exist
+	// should be reported as permission denied rather than not found
+	MaskNotFound() bool
 }
 
 type StaticSystemView struct {
 	DefaultLeaseTTLVal time.Duration
 	MaxLeaseTTLVal     time.Duration
 	SudoPrivilegeVal   bool
+	MaskNotFoundVal    bool
 }
 
 func (d StaticSystemView) DefaultLeaseTTL() time.Duration {
@@ -35,3 +40,7 @@ func (d StaticSystemView) MaxLeaseTTL() time.Duration {
 func (d StaticSystemView) SudoPrivilege(path string, token string) bool {
 	return d.SudoPrivilegeVal
 }
+
+func (d StaticSystemView) MaskNotFound() bool {
+	return d.MaskNotFoundVal
+}
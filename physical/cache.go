@@ -2,6 +2,7 @@ package physical
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/golang-lru"
 )
@@ -18,6 +19,10 @@ const (
 type Cache struct {
 	backend Backend
 	lru     *lru.TwoQueueCache
+
+	// noCache is the set of prefixes whose keys are never cached
+	noCacheLock sync.RWMutex
+	noCache     map[string]struct{}
 }
 
 // NewCache returns a physical cache of the given size.
@@ -30,10 +35,43 @@ func NewCache(b Backend, size int) *Cache {
 	c := &Cache{
 		backend: b,
 		lru:     cache,
+		noCache: make(map[string]struct{}),
 	}
 	return c
 }
 
+// SetNoCache controls whether keys under the given prefix bypass the
+// cache. Any cached keys under the prefix are evicted when it is set.
+func (c *Cache) SetNoCache(prefix string, noCache bool) {
+	c.noCacheLock.Lock()
+	defer c.noCacheLock.Unlock()
+
+	if !noCache {
+		delete(c.noCache, prefix)
+		return
+	}
+
+	c.noCache[prefix] = struct{}{}
+	for _, raw := range c.lru.Keys() {
+		if key := raw.(string); strings.HasPrefix(key, prefix) {
+			c.lru.Remove(key)
+		}
+	}
+}
+
+// cacheable returns whether the key may be cached.
+func (c *Cache) cacheable(key string) bool {
+	c.noCacheLock.RLock()
+	defer c.noCacheLock.RUnlock()
+
+	for prefix := range c.noCache {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
 // Purge is used to clear the cache
 func (c *Cache) Purge() {
 	c.lru.Purge()
@@ -41,11 +79,17 @@ func (c *Cache) Purge() {
 
 func (c *Cache) Put(entry *Entry) error {
 	err := c.backend.Put(entry)
-	c.lru.Add(entry.Key, entry)
+	if c.cacheable(entry.Key) {
+		c.lru.Add(entry.Key, entry)
+	}
 	return err
 }
 
 func (c *Cache) Get(key string) (*Entry, error) {
+	if !c.cacheable(key) {
+		return c.backend.Get(key)
+	}
+
 	// Check the LRU first
 	if raw, ok := c.lru.Get(key); ok {
 		if raw == nil {
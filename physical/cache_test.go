@@ -46,3 +46,51 @@ func TestCache_Purge(t *testing.T) {
 		t.Fatalf("should not have key")
 	}
 }
+
+func TestCache_NoCache(t *testing.T) {
+	inm := NewInmem()
+	cache := NewCache(inm, 0)
+
+	for _, key := range []string{"foo/bar", "baz"} {
+		err := cache.Put(&Entry{Key: key, Value: []byte("value")})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Keys under the prefix are evicted and read through from now on
+	cache.SetNoCache("foo/", true)
+	inm.Delete("foo/bar")
+	inm.Delete("baz")
+
+	out, err := cache.Get("foo/bar")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("should not have key")
+	}
+
+	// Other keys are still cached
+	out, err = cache.Get("baz")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("should have key")
+	}
+
+	// Writes under the prefix are not cached either
+	err = cache.Put(&Entry{Key: "foo/bar", Value: []byte("value")})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	inm.Delete("foo/bar")
+	out, err = cache.Get("foo/bar")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("should not have key")
+	}
+}
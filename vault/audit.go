@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"sync"
@@ -78,6 +79,7 @@ func (c *Core) enableAudit(entry *MountEntry) error {
 	newTable := c.audit.ShallowClone()
 	newTable.Entries = append(newTable.Entries, entry)
 	if err := c.persistAudit(newTable); err != nil {
+		closeAuditBackend(backend)
 		return errors.New("failed to update audit table")
 	}
 
@@ -198,6 +200,7 @@ func (c *Core) setupAudits() error {
 			c.logger.Printf(
 				"[ERR] core: failed to create audit entry %s: %v",
 				entry.Path, err)
+			broker.DeregisterAll()
 			return errLoadAuditFailed
 		}
 
@@ -214,11 +217,22 @@ func (c *Core) teardownAudits() error {
 	c.auditLock.Lock()
 	defer c.auditLock.Unlock()
 
+	if c.auditBroker != nil {
+		c.auditBroker.DeregisterAll()
+	}
 	c.audit = nil
 	c.auditBroker = nil
 	return nil
 }
 
+// closeAuditBackend releases the resources of a backend that holds any,
+// such as a spool
+func closeAuditBackend(b audit.Backend) {
+	if closer, ok := b.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
 // newAuditBackend is used to create and configure a new audit backend by name
 func (c *Core) newAuditBackend(t string, view logical.Storage, conf map[string]string) (audit.Backend, error) {
 	f, ok := c.auditBackends[t]
@@ -232,10 +246,23 @@ func (c *Core) newAuditBackend(t string, view logical.Storage, conf map[string]s
 	if err != nil {
 		return nil, fmt.Errorf("[ERR] core: unable to generate salt: %v", err)
 	}
-	return f(&audit.BackendConfig{
+	be, err := f(&audit.BackendConfig{
 		Salt:   salter,
 		Config: conf,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Spool entries locally for guaranteed delivery if requested
+	if spoolPath := conf["spool_path"]; spoolPath != "" {
+		entryBackend, ok := be.(audit.EntryBackend)
+		if !ok {
+			return nil, fmt.Errorf("audit backend type %s does not support spooling", t)
+		}
+		return audit.NewSpool(spoolPath, entryBackend, c.logger)
+	}
+	return be, nil
 }
 
 // defaultAuditTable creates a default audit table
@@ -280,9 +307,22 @@ func (a *AuditBroker) Register(name string, b audit.Backend, v *BarrierView) {
 func (a *AuditBroker) Deregister(name string) {
 	a.l.Lock()
 	defer a.l.Unlock()
+	if be, ok := a.backends[name]; ok {
+		closeAuditBackend(be.backend)
+	}
 	delete(a.backends, name)
 }
 
+// DeregisterAll is used to remove every audit backend from the broker
+func (a *AuditBroker) DeregisterAll() {
+	a.l.Lock()
+	defer a.l.Unlock()
+	for name, be := range a.backends {
+		closeAuditBackend(be.backend)
+		delete(a.backends, name)
+	}
+}
+
 // IsRegistered is used to check if a given audit backend is registered
 func (a *AuditBroker) IsRegistered(name string) bool {
 	a.l.RLock()
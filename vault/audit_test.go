@@ -101,6 +101,29 @@ func TestCore_EnableAudit(t *testing.T) {
 	}
 }
 
+func TestCore_EnableAudit_SpoolUnsupported(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	c.auditBackends["noop"] = func(config *audit.BackendConfig) (audit.Backend, error) {
+		return &NoopAudit{
+			Config: config,
+		}, nil
+	}
+
+	me := &MountEntry{
+		Path: "foo",
+		Type: "noop",
+		Options: map[string]string{
+			"spool_path": "/tmp/vault-audit.spool",
+		},
+	}
+	if err := c.enableAudit(me); err == nil {
+		t.Fatalf("expected error")
+	}
+	if c.auditBroker.IsRegistered("foo/") {
+		t.Fatalf("audit backend should not be registered")
+	}
+}
+
 func TestCore_DisableAudit(t *testing.T) {
 	c, key, _ := TestCoreUnsealed(t)
 	c.auditBackends["noop"] = func(config *audit.BackendConfig) (audit.Backend, error) {
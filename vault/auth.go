@@ -1,7 +1,6 @@
 package vault
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -36,9 +35,9 @@ func (c *Core) enableCredential(entry *MountEntry) error {
 		entry.Path += "/"
 	}
 
-	// Ensure there is a name
-	if entry.Path == "/" {
-		return fmt.Errorf("backend path must be specified")
+	// Ensure the path is well-formed
+	if err := validateMountPath(entry.Path); err != nil {
+		return err
 	}
 
 	c.authLock.Lock()
@@ -142,6 +141,7 @@ func (c *Core) disableCredential(path string) error {
 			return err
 		}
 	}
+	c.setViewNoCache(view, false)
 
 	// Remove the mount table entry
 	if err := c.removeCredEntry(path); err != nil {
@@ -201,7 +201,7 @@ func (c *Core) loadCredentials() error {
 	defer c.authLock.Unlock()
 
 	if raw != nil {
-		if err := json.Unmarshal(raw.Value, authTable); err != nil {
+		if err := decodeTable(raw.Value, authTable); err != nil {
 			c.logger.Printf("[ERR] core: failed to decode auth table: %v", err)
 			return errLoadAuthFailed
 		}
@@ -225,7 +225,7 @@ func (c *Core) loadCredentials() error {
 // persistAuth is used to persist the auth table after modification
 func (c *Core) persistAuth(table *MountTable) error {
 	// Marshal the table
-	raw, err := json.Marshal(table)
+	raw, err := encodeTable(table)
 	if err != nil {
 		c.logger.Printf("[ERR] core: failed to encode auth table: %v", err)
 		return err
@@ -258,6 +258,9 @@ func (c *Core) setupCredentials() error {
 	for _, entry := range c.auth.Entries {
 		// Create a barrier view using the UUID
 		view = NewBarrierView(c.barrier, credentialBarrierPrefix+entry.UUID+"/")
+		if entry.Config.ForceNoCache {
+			c.setViewNoCache(view, true)
+		}
 
 		// Initialize the backend
 		backend, err = c.newCredentialBackend(entry.Type, c.mountEntrySysView(entry), view, nil)
@@ -0,0 +1,178 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/logical"
+)
+
+// CopySecrets copies the secret at source to destination, or every
+// secret under source to the same relative path under destination if
+// both end in "/". Both paths must be in generic or cubbyhole mounts.
+// If move is set, each source secret is deleted once it has been copied.
+//
+// Every read, list, write and delete is made as a request with the given
+// token, so it is subject to the token's policies and is audited, but the
+// data never leaves the Vault. The relative paths copied are returned,
+// including those copied before an error occurred.
+func (c *Core) CopySecrets(token, source, destination string, move bool) ([]string, error) {
+	defer metrics.MeasureSince([]string{"core", "copy_secrets"}, time.Now())
+
+	if source == "" || destination == "" {
+		return nil, logical.CodedError(400, "source and destination must be given")
+	}
+	if strings.HasSuffix(source, "/") != strings.HasSuffix(destination, "/") {
+		return nil, logical.CodedError(400, "source and destination must both end in '/' to copy a tree of secrets")
+	}
+	if source == destination {
+		return nil, logical.CodedError(400, "source and destination must differ")
+	}
+	if strings.HasSuffix(source, "/") &&
+		(strings.HasPrefix(destination, source) || strings.HasPrefix(source, destination)) {
+		return nil, logical.CodedError(400, "source and destination may not contain each other")
+	}
+	if err := c.checkCopyMounts(source, destination); err != nil {
+		return nil, err
+	}
+
+	keys := []string{""}
+	if strings.HasSuffix(source, "/") {
+		var err error
+		keys, err = c.listSecretsRecursive(token, source, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	copied := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if err := c.copySecret(token, source+key, destination+key, move); err != nil {
+			return copied, err
+		}
+		copied = append(copied, key)
+	}
+	return copied, nil
+}
+
+// checkCopyMounts verifies that the source and destination are stored as
+// given, rather than generated by a backend
+func (c *Core) checkCopyMounts(paths ...string) error {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return ErrSealed
+	}
+	if c.standby {
+		return ErrStandby
+	}
+
+	for _, path := range paths {
+		switch c.router.MatchingBackend(path).(type) {
+		case *PassthroughBackend, *CubbyholeBackend:
+		default:
+			return logical.CodedError(400, fmt.Sprintf(
+				"'%s' is not in a generic or cubbyhole mount", path))
+		}
+	}
+	return nil
+}
+
+// listSecretsRecursive returns the paths of the secrets under prefix+dir,
+// relative to prefix
+func (c *Core) listSecretsRecursive(token, prefix, dir string) ([]string, error) {
+	resp, err := c.copyRequest(&logical.Request{
+		Operation:   logical.ListOperation,
+		Path:        prefix + dir,
+		ClientToken: token,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, nil
+	}
+
+	rawKeys, _ := resp.Data["keys"].([]string)
+	var keys []string
+	for _, key := range rawKeys {
+		if !strings.HasSuffix(key, "/") {
+			keys = append(keys, dir+key)
+			continue
+		}
+		subKeys, err := c.listSecretsRecursive(token, prefix, dir+key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, subKeys...)
+	}
+	return keys, nil
+}
+
+// copySecret copies a single secret, deleting the source if move is set
+func (c *Core) copySecret(token, source, destination string, move bool) error {
+	resp, err := c.copyRequest(&logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        source,
+		ClientToken: token,
+	})
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		return logical.CodedError(404, fmt.Sprintf(
+			"no secret at '%s'", source))
+	}
+
+	_, err = c.copyRequest(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        destination,
+		Data:        resp.Data,
+		ClientToken: token,
+	})
+	if err != nil {
+		return err
+	}
+
+	if move {
+		_, err = c.copyRequest(&logical.Request{
+			Operation:   logical.DeleteOperation,
+			Path:        source,
+			ClientToken: token,
+		})
+	}
+	return err
+}
+
+// copyRequest handles a request made on behalf of CopySecrets, turning
+// an error response into an error naming the request
+func (c *Core) copyRequest(req *logical.Request) (*logical.Response, error) {
+	resp, err := c.HandleRequest(req)
+	switch err {
+	case nil:
+	case ErrSealed, ErrStandby:
+		return nil, err
+	case logical.ErrPermissionDenied:
+		return nil, logical.CodedError(403, fmt.Sprintf(
+			"permission denied to %s '%s'", req.Operation, req.Path))
+	case logical.ErrInvalidRequest, logical.ErrUnsupportedPath, logical.ErrUnsupportedOperation:
+		if resp == nil || !resp.IsError() {
+			return nil, logical.CodedError(400, fmt.Sprintf(
+				"failed to %s '%s': %v", req.Operation, req.Path, err))
+		}
+	default:
+		if _, ok := err.(logical.HTTPCodedError); ok {
+			return nil, err
+		}
+		if resp == nil || !resp.IsError() {
+			return nil, fmt.Errorf("failed to %s '%s': %v", req.Operation, req.Path, err)
+		}
+	}
+	if resp != nil && resp.IsError() {
+		return nil, logical.CodedError(400, fmt.Sprintf(
+			"failed to %s '%s': %s", req.Operation, req.Path, resp.Data["error"]))
+	}
+	return resp, nil
+}
@@ -0,0 +1,152 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_CopySecrets(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	for _, path := range []string{"secret/app/a", "secret/app/b/c", "secret/other"} {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data: map[string]interface{}{
+				"value": path,
+				"ttl":   "1h",
+			},
+			ClientToken: root,
+		}
+		if _, err := c.HandleRequest(req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	read := func(path string) map[string]interface{} {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: root,
+		}
+		resp, err := c.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp == nil {
+			return nil
+		}
+		return resp.Data
+	}
+
+	// A single secret
+	copied, err := c.CopySecrets(root, "secret/other", "secret/copy", false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(copied, []string{""}) {
+		t.Fatalf("bad: %#v", copied)
+	}
+	if data := read("secret/copy"); data["value"] != "secret/other" || data["ttl"] != "1h" {
+		t.Fatalf("bad: %#v", data)
+	}
+	if read("secret/other") == nil {
+		t.Fatalf("source deleted")
+	}
+
+	// A tree of secrets, moved to another mount
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/mounts/kv",
+		Data:        map[string]interface{}{"type": "generic"},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	copied, err = c.CopySecrets(root, "secret/app/", "kv/app/", true)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(copied, []string{"a", "b/c"}) {
+		t.Fatalf("bad: %#v", copied)
+	}
+	for _, key := range copied {
+		if data := read("kv/app/" + key); data["value"] != "secret/app/"+key {
+			t.Fatalf("bad: %s %#v", key, data)
+		}
+		if data := read("secret/app/" + key); data != nil {
+			t.Fatalf("source not deleted: %s %#v", key, data)
+		}
+	}
+}
+
+func TestCore_CopySecrets_Invalid(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "secret/foo",
+		Data:        map[string]interface{}{"value": "bar"},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		source, destination string
+		code                int
+	}{
+		{"secret/foo", "", 400},
+		{"secret/foo", "secret/foo", 400},
+		{"secret/foo", "secret/bar/", 400},
+		{"secret/", "secret/sub/", 400},
+		{"secret/foo", "sys/foo", 400},
+		{"secret/foo", "auth/token/foo", 400},
+		{"secret/missing", "secret/bar", 404},
+	}
+	for _, tc := range cases {
+		_, err := c.CopySecrets(root, tc.source, tc.destination, false)
+		coded, ok := err.(logical.HTTPCodedError)
+		if !ok || coded.Code() != tc.code {
+			t.Fatalf("%s -> %s: err: %v", tc.source, tc.destination, err)
+		}
+	}
+}
+
+func TestCore_CopySecrets_ACL(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sys/policy/reader",
+		Data: map[string]interface{}{
+			"rules": `path "secret/*" { policy = "read" }`,
+		},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	testCoreMakeToken(t, c, root, "reader", "", []string{"reader"})
+
+	req = &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "secret/foo",
+		Data:        map[string]interface{}{"value": "bar"},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The token may read the source but not write the destination
+	_, err := c.CopySecrets("reader", "secret/foo", "secret/bar", false)
+	coded, ok := err.(logical.HTTPCodedError)
+	if !ok || coded.Code() != 403 {
+		t.Fatalf("err: %v", err)
+	}
+}
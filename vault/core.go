@@ -23,6 +23,7 @@ import (
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/mlock"
 	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/shamir"
@@ -219,6 +220,17 @@ type Core struct {
 	rekeyProgress [][]byte
 	rekeyLock     sync.Mutex
 
+	// recoveryTokenProgress holds the shares we have until we reach
+	// enough to verify the master key and issue a recovery token.
+	recoveryTokenConfig   *RecoveryTokenConfig
+	recoveryTokenProgress [][]byte
+	recoveryTokenLock     sync.Mutex
+
+	// maintenance holds the paths rejecting writes during maintenance,
+	// if maintenance mode is on
+	maintenance     *MaintenanceConfig
+	maintenanceLock sync.RWMutex
+
 	// mounts is loaded after unseal since it is a protected
 	// configuration
 	mounts *MountTable
@@ -273,6 +285,28 @@ type Core struct {
 	defaultLeaseTTL time.Duration
 	maxLeaseTTL     time.Duration
 
+	// maxTTLCeiling caps every lease and token TTL if non-zero
+	maxTTLCeiling time.Duration
+
+	// replay tracks request nonces if replay protection is enabled
+	replay *replayCache
+
+	// inFlight tracks the requests currently being handled
+	inFlight *inFlightRequests
+
+	// inFlightSalt keys the client token hashes of in-flight requests
+	inFlightSalt *salt.Salt
+
+	// requestCounters counts the requests handled, per month
+	requestCounters *requestCounters
+
+	// enableRaw exposes the sys/raw endpoints on the system backend
+	enableRaw bool
+
+	// physicalCache is the read cache wrapping the physical backend, or
+	// nil if caching is disabled
+	physicalCache *physical.Cache
+
 	logger *log.Logger
 }
 
@@ -290,6 +324,19 @@ type CoreConfig struct {
 	AdvertiseAddr      string // Set as the leader address for HA
 	DefaultLeaseTTL    time.Duration
 	MaxLeaseTTL        time.Duration
+
+	// ReplayWindow enables replay protection for sensitive unauthenticated
+	// endpoints when non-zero. Requests to those endpoints must carry a
+	// unique nonce and a timestamp within this window of the current time.
+	ReplayWindow time.Duration
+
+	// EnableRaw exposes the barrier storage through the sudo-protected
+	// sys/raw endpoints, for repairing entries by hand.
+	EnableRaw bool
+
+	// MaxTTLCeiling, if non-zero, caps the TTL of every lease and token
+	// regardless of the system and mount settings.
+	MaxTTLCeiling time.Duration
 }
 
 // NewCore is used to construct a new core
@@ -304,6 +351,14 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	if conf.MaxLeaseTTL == 0 {
 		conf.MaxLeaseTTL = maxLeaseTTL
 	}
+	if conf.MaxTTLCeiling > 0 {
+		if conf.MaxLeaseTTL > conf.MaxTTLCeiling {
+			conf.MaxLeaseTTL = conf.MaxTTLCeiling
+		}
+		if conf.DefaultLeaseTTL > conf.MaxTTLCeiling {
+			conf.DefaultLeaseTTL = conf.MaxTTLCeiling
+		}
+	}
 	if conf.DefaultLeaseTTL > conf.MaxLeaseTTL {
 		return nil, fmt.Errorf("cannot have DefaultLeaseTTL larger than MaxLeaseTTL")
 	}
@@ -369,7 +424,16 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		logger:          conf.Logger,
 		defaultLeaseTTL: conf.DefaultLeaseTTL,
 		maxLeaseTTL:     conf.MaxLeaseTTL,
+		inFlight:        newInFlightRequests(),
+		requestCounters: newRequestCounters(),
+		enableRaw:       conf.EnableRaw,
+		maxTTLCeiling:   conf.MaxTTLCeiling,
+	}
+
+	if conf.ReplayWindow > 0 {
+		c.replay = newReplayCache(conf.ReplayWindow)
 	}
+	c.physicalCache, _ = conf.Physical.(*physical.Cache)
 
 	// Setup the backends
 	logicalBackends := make(map[string]logical.Factory)
@@ -429,6 +493,8 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 		return nil, ErrStandby
 	}
 
+	defer c.trackInFlight(req)()
+
 	var auth *logical.Auth
 	if c.router.LoginPath(req.Path) {
 		resp, auth, err = c.handleLoginRequest(req)
@@ -461,6 +527,14 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 
 	// Validate the token
 	auth, te, err := c.checkToken(req)
+
+	// Count the request against the auth method that issued the token
+	var authPath string
+	if te != nil {
+		authPath = te.Path
+	}
+	c.countRequest(req, authPath)
+
 	if te != nil {
 		defer func() {
 			// Attempt to use the token (decrement num_uses)
@@ -501,6 +575,15 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		return logical.ErrorResponse(err.Error()), nil, errType
 	}
 
+	// Reject writes to paths under maintenance
+	if err := c.checkMaintenance(req, auth); err != nil {
+		if err := c.auditBroker.LogRequest(auth, req, err); err != nil {
+			c.logger.Printf("[ERR] core: failed to audit request with path (%s): %v",
+				req.Path, err)
+		}
+		return nil, auth, err
+	}
+
 	// Attach the display name
 	req.DisplayName = auth.DisplayName
 
@@ -528,6 +611,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if resp.Secret.TTL == 0 {
 			resp.Secret.TTL = sysView.DefaultLeaseTTL()
 		}
+		c.checkTTLCeiling(req, resp, resp.Secret.TTL)
 
 		// Limit the lease duration
 		maxTTL := sysView.MaxLeaseTTL()
@@ -583,12 +667,14 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if resp.Auth.TTL == 0 && !strListContains(resp.Auth.Policies, "root") {
 			resp.Auth.TTL = sysView.DefaultLeaseTTL()
 		}
+		c.checkTTLCeiling(req, resp, resp.Auth.TTL)
 
 		// Limit the lease duration
 		maxTTL := sysView.MaxLeaseTTL()
 		if resp.Auth.TTL > maxTTL {
 			resp.Auth.TTL = maxTTL
 		}
+		resp.Auth.TTL = c.capRootTokenTTL(req, resp, resp.Auth.TTL)
 
 		// Register with the expiration manager
 		if err := c.expiration.RegisterAuth(req.Path, resp.Auth); err != nil {
@@ -606,6 +692,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 // unauthenticated request to the backend.
 func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *logical.Auth, error) {
 	defer metrics.MeasureSince([]string{"core", "handle_login_request"}, time.Now())
+	c.countRequest(req, req.Path)
 
 	// Create an audit trail of the request, auth is not available on login requests
 	if err := c.auditBroker.LogRequest(nil, req, nil); err != nil {
@@ -629,45 +716,21 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 	if resp != nil && resp.Auth != nil {
 		auth = resp.Auth
 
-		// Determine the source of the login
-		source := c.router.MatchingMount(req.Path)
-		source = strings.TrimPrefix(source, credentialRoutePrefix)
-		source = strings.Replace(source, "/", "-", -1)
-
-		// Prepend the source to the display name
-		auth.DisplayName = strings.TrimSuffix(source+auth.DisplayName, "-")
-
-		sysView := c.router.MatchingSystemView(req.Path)
-		if sysView == nil {
-			c.logger.Printf("[ERR] core: unable to look up sys view for login path"+
-				"(request path: %s)", req.Path)
-			return nil, nil, ErrInternalError
-		}
-
-		// Set the default lease if non-provided, root tokens are exempt
-		if auth.TTL == 0 && !strListContains(auth.Policies, "root") {
-			auth.TTL = sysView.DefaultLeaseTTL()
-		}
-
-		// Limit the lease duration
-		if auth.TTL > sysView.MaxLeaseTTL() {
-			auth.TTL = sysView.MaxLeaseTTL()
+		policies, err := c.prepareLoginAuth(req, resp)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		// Generate a token
 		te := TokenEntry{
 			Path:         req.Path,
-			Policies:     auth.Policies,
+			Policies:     policies,
 			Meta:         auth.Metadata,
 			DisplayName:  auth.DisplayName,
 			CreationTime: time.Now().Unix(),
 			TTL:          auth.TTL,
 		}
 
-		if !strListSubset(te.Policies, []string{"root"}) {
-			te.Policies = append(te.Policies, "default")
-		}
-
 		if err := c.tokenStore.create(&te); err != nil {
 			c.logger.Printf("[ERR] core: failed to create token: %v", err)
 			return nil, auth, ErrInternalError
@@ -690,6 +753,79 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 	return resp, auth, err
 }
 
+// prepareLoginAuth completes the auth returned by a login: it prepends
+// the source of the login to the display name and applies the default
+// and maximum TTLs. It returns the policies of the token to create, which
+// include "default" unless it is a root token. LoginDryRun uses it too, so
+// that a dry run reports exactly what a login would grant.
+func (c *Core) prepareLoginAuth(req *logical.Request, resp *logical.Response) ([]string, error) {
+	auth := resp.Auth
+
+	// Determine the source of the login
+	source := c.router.MatchingMount(req.Path)
+	source = strings.TrimPrefix(source, credentialRoutePrefix)
+	source = strings.Replace(source, "/", "-", -1)
+
+	// Prepend the source to the display name
+	auth.DisplayName = strings.TrimSuffix(source+auth.DisplayName, "-")
+
+	sysView := c.router.MatchingSystemView(req.Path)
+	if sysView == nil {
+		c.logger.Printf("[ERR] core: unable to look up sys view for login path"+
+			"(request path: %s)", req.Path)
+		return nil, ErrInternalError
+	}
+
+	// Set the default lease if non-provided, root tokens are exempt
+	if auth.TTL == 0 && !strListContains(auth.Policies, "root") {
+		auth.TTL = sysView.DefaultLeaseTTL()
+	}
+	c.checkTTLCeiling(req, resp, auth.TTL)
+
+	// Limit the lease duration
+	if auth.TTL > sysView.MaxLeaseTTL() {
+		auth.TTL = sysView.MaxLeaseTTL()
+	}
+	auth.TTL = c.capRootTokenTTL(req, resp, auth.TTL)
+
+	policies := append([]string{}, auth.Policies...)
+	if !strListSubset(policies, []string{"root"}) {
+		policies = append(policies, "default")
+	}
+	return policies, nil
+}
+
+// checkTTLCeiling adds a warning to the response, which is recorded in the
+// audit log, if the TTL exceeds the TTL ceiling. The TTL itself is capped
+// by the system view of the mount, which applies the ceiling.
+func (c *Core) checkTTLCeiling(req *logical.Request, resp *logical.Response, ttl time.Duration) {
+	if c.maxTTLCeiling == 0 || ttl <= c.maxTTLCeiling {
+		return
+	}
+
+	metrics.IncrCounter([]string{"core", "ttl-ceiling-capped"}, 1)
+	c.logger.Printf("[WARN] core: TTL of %s exceeds the ceiling of %s and was capped "+
+		"(request path: %s)", ttl, c.maxTTLCeiling, req.Path)
+	resp.AddWarning(fmt.Sprintf(
+		"TTL of %s exceeded the maximum TTL ceiling of %s and was capped", ttl, c.maxTTLCeiling))
+}
+
+// capRootTokenTTL returns the TTL ceiling in place of a TTL of zero, which
+// only root tokens are left with, so that no token outlives the ceiling.
+// As with checkTTLCeiling, a warning is added to the response.
+func (c *Core) capRootTokenTTL(req *logical.Request, resp *logical.Response, ttl time.Duration) time.Duration {
+	if c.maxTTLCeiling == 0 || ttl != 0 {
+		return ttl
+	}
+
+	metrics.IncrCounter([]string{"core", "ttl-ceiling-capped"}, 1)
+	c.logger.Printf("[WARN] core: root token given the TTL ceiling of %s "+
+		"(request path: %s)", c.maxTTLCeiling, req.Path)
+	resp.AddWarning(fmt.Sprintf(
+		"root token would not expire and was given the maximum TTL ceiling of %s", c.maxTTLCeiling))
+	return c.maxTTLCeiling
+}
+
 func (c *Core) fetchACLandTokenEntry(req *logical.Request) (*ACL, *TokenEntry, error) {
 	defer metrics.MeasureSince([]string{"core", "fetch_acl_and_token"}, time.Now())
 
@@ -1266,6 +1402,15 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := c.setupAudits(); err != nil {
 		return err
 	}
+	if err := c.loadMaintenance(); err != nil {
+		return err
+	}
+	if err := c.setupRequestCounters(); err != nil {
+		return err
+	}
+	if err := c.setupInFlight(); err != nil {
+		return err
+	}
 	c.metricsCh = make(chan struct{})
 	go c.emitMetrics(c.metricsCh)
 	c.logger.Printf("[INFO] core: post-unseal setup complete")
@@ -1282,11 +1427,24 @@ func (c *Core) preSeal() error {
 	c.rekeyConfig = nil
 	c.rekeyProgress = nil
 
+	// Clear any recovery token progress
+	c.recoveryTokenConfig = nil
+	c.recoveryTokenProgress = nil
+
+	// Maintenance mode is reloaded from storage on unseal
+	c.maintenanceLock.Lock()
+	c.maintenance = nil
+	c.maintenanceLock.Unlock()
+
 	if c.metricsCh != nil {
 		close(c.metricsCh)
 		c.metricsCh = nil
 	}
+	c.inFlightSalt = nil
 	var result error
+	if err := c.stopRequestCounters(); err != nil {
+		result = multierror.Append(result, errwrap.Wrapf("[ERR] error stopping request counters: {{err}}", err))
+	}
 	if err := c.teardownAudits(); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("[ERR] error tearing down audits: {{err}}", err))
 	}
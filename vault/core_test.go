@@ -457,6 +457,73 @@ func TestCore_HandleRequest_Lease_MaxLength(t *testing.T) {
 	}
 }
 
+func TestCore_HandleRequest_Lease_TTLCeiling(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.maxTTLCeiling = time.Hour
+
+	// Tune the mount past the ceiling
+	me := c.router.MatchingMountEntry("secret/")
+	me.Config.MaxLeaseTTL = 1000 * time.Hour
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "secret/test",
+		Data: map[string]interface{}{
+			"foo":   "bar",
+			"lease": "100h",
+		},
+		ClientToken: root,
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Read the key
+	req.Operation = logical.ReadOperation
+	req.Data = nil
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Secret == nil || resp.Data == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Secret.TTL != time.Hour {
+		t.Fatalf("bad: %#v", resp.Secret)
+	}
+	if len(resp.Warnings()) != 1 {
+		t.Fatalf("bad: %#v", resp.Warnings())
+	}
+}
+
+func TestCore_HandleRequest_RootToken_TTLCeiling(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.maxTTLCeiling = time.Hour
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "auth/token/create",
+		ClientToken: root,
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.TTL != time.Hour {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+	if len(resp.Warnings()) != 1 {
+		t.Fatalf("bad: %#v", resp.Warnings())
+	}
+}
+
 func TestCore_HandleRequest_Lease_DefaultLength(t *testing.T) {
 	c, _, root := TestCoreUnsealed(t)
 
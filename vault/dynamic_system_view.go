@@ -21,6 +21,14 @@ func (d dynamicSystemView) MaxLeaseTTL() time.Duration {
 	return max
 }
 
+// MaskNotFound reads the config under the router lock, as tuning the
+// mount may change it
+func (d dynamicSystemView) MaskNotFound() bool {
+	d.core.router.l.RLock()
+	defer d.core.router.l.RUnlock()
+	return d.mountEntry.Config.MaskNotFound
+}
+
 func (d dynamicSystemView) SudoPrivilege(path string, token string) bool {
 	// Resolve the token policy
 	te, err := d.core.tokenStore.Lookup(token)
@@ -62,5 +70,15 @@ func (d dynamicSystemView) fetchTTLs() (def, max time.Duration) {
 		max = d.mountEntry.Config.MaxLeaseTTL
 	}
 
+	// No mount may exceed the ceiling, whatever it has been tuned to
+	if ceiling := d.core.maxTTLCeiling; ceiling > 0 {
+		if max > ceiling {
+			max = ceiling
+		}
+		if def > ceiling {
+			def = ceiling
+		}
+	}
+
 	return
 }
@@ -55,6 +55,9 @@ type ExpirationManager struct {
 
 	pending     map[string]*time.Timer
 	pendingLock sync.Mutex
+
+	// failures tracks recent revocation failures
+	failures *revocationFailures
 }
 
 // NewExpirationManager creates a new ExpirationManager that is backed
@@ -70,6 +73,7 @@ func NewExpirationManager(router *Router, view *BarrierView, ts *TokenStore, log
 		tokenStore: ts,
 		logger:     logger,
 		pending:    make(map[string]*time.Timer),
+		failures:   newRevocationFailures(),
 	}
 	return exp
 }
@@ -519,6 +523,7 @@ func (m *ExpirationManager) revokeEntry(le *leaseEntry) error {
 	// backend and directly interact with the token store
 	if le.Auth != nil {
 		if err := m.tokenStore.RevokeTree(le.Auth.ClientToken); err != nil {
+			m.recordRevocationFailure(le, err)
 			return fmt.Errorf("failed to revoke token: %v", err)
 		}
 
@@ -529,6 +534,7 @@ func (m *ExpirationManager) revokeEntry(le *leaseEntry) error {
 	_, err := m.router.Route(logical.RevokeRequest(
 		le.Path, le.Secret, le.Data))
 	if err != nil {
+		m.recordRevocationFailure(le, err)
 		return fmt.Errorf("failed to revoke entry: %v", err)
 	}
 	return nil
@@ -0,0 +1,162 @@
+package vault
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// maxRecentRevocationFailures is the number of recent revocation
+	// failures kept in memory for inspection through the sys backend.
+	maxRecentRevocationFailures = 100
+)
+
+// Classes of revocation failure, used to distinguish failures that are
+// likely to resolve themselves from those that need operator attention.
+const (
+	revokeFailureTimeout     = "timeout"
+	revokeFailureConnection  = "connection"
+	revokeFailureUnsupported = "unsupported"
+	revokeFailurePermission  = "permission_denied"
+	revokeFailureBackend     = "backend"
+)
+
+// RevocationFailure records a single failed attempt to revoke a lease.
+type RevocationFailure struct {
+	LeaseID string    `json:"lease_id" structs:"lease_id" mapstructure:"lease_id"`
+	Mount   string    `json:"mount" structs:"mount" mapstructure:"mount"`
+	Class   string    `json:"class" structs:"class" mapstructure:"class"`
+	Error   string    `json:"error" structs:"error" mapstructure:"error"`
+	Time    time.Time `json:"time" structs:"time" mapstructure:"time"`
+}
+
+// revocationFailures tracks recent revocation failures and totals by
+// mount and class since the expiration manager was started.
+type revocationFailures struct {
+	l      sync.Mutex
+	recent []*RevocationFailure
+	counts map[string]map[string]int
+}
+
+func newRevocationFailures() *revocationFailures {
+	return &revocationFailures{
+		counts: make(map[string]map[string]int),
+	}
+}
+
+// record stores a failure, evicting the oldest if over the limit, and
+// emits a counter keyed by mount and class.
+func (r *revocationFailures) record(f *RevocationFailure) {
+	metrics.IncrCounter([]string{"expire", "revoke-failure", metricsMountName(f.Mount), f.Class}, 1)
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.recent = append(r.recent, f)
+	if len(r.recent) > maxRecentRevocationFailures {
+		r.recent = r.recent[len(r.recent)-maxRecentRevocationFailures:]
+	}
+
+	byClass, ok := r.counts[f.Mount]
+	if !ok {
+		byClass = make(map[string]int)
+		r.counts[f.Mount] = byClass
+	}
+	byClass[f.Class]++
+}
+
+// snapshot returns copies of the recent failures, newest first, and of
+// the counts.
+func (r *revocationFailures) snapshot() ([]*RevocationFailure, map[string]map[string]int) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	recent := make([]*RevocationFailure, 0, len(r.recent))
+	for i := len(r.recent) - 1; i >= 0; i-- {
+		f := *r.recent[i]
+		recent = append(recent, &f)
+	}
+
+	counts := make(map[string]map[string]int, len(r.counts))
+	for mount, byClass := range r.counts {
+		c := make(map[string]int, len(byClass))
+		for class, n := range byClass {
+			c[class] = n
+		}
+		counts[mount] = c
+	}
+	return recent, counts
+}
+
+// RevocationFailures returns the recent revocation failures, newest first,
+// along with the number of failures by mount and class since the
+// expiration manager was started.
+func (m *ExpirationManager) RevocationFailures() ([]*RevocationFailure, map[string]map[string]int) {
+	return m.failures.snapshot()
+}
+
+// recordRevocationFailure classifies and records a failure to revoke the
+// given lease.
+func (m *ExpirationManager) recordRevocationFailure(le *leaseEntry, err error) {
+	mount := m.router.MatchingMount(le.Path)
+	if mount == "" {
+		mount = le.Path
+	}
+	m.failures.record(&RevocationFailure{
+		LeaseID: le.LeaseID,
+		Mount:   mount,
+		Class:   revocationFailureClass(err),
+		Error:   err.Error(),
+		Time:    time.Now().UTC(),
+	})
+}
+
+// revocationFailureClass determines the class of a revocation error.
+// Backends often wrap the errors of their clients, so this falls back to
+// inspecting the message.
+func revocationFailureClass(err error) string {
+	switch err {
+	case logical.ErrUnsupportedPath, logical.ErrUnsupportedOperation:
+		return revokeFailureUnsupported
+	case logical.ErrPermissionDenied:
+		return revokeFailurePermission
+	}
+
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return revokeFailureTimeout
+		}
+		return revokeFailureConnection
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "timeout"),
+		strings.Contains(msg, "timed out"),
+		strings.Contains(msg, "deadline exceeded"):
+		return revokeFailureTimeout
+	case strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "connection reset"),
+		strings.Contains(msg, "broken pipe"),
+		strings.Contains(msg, "no route to host"),
+		strings.Contains(msg, "no such host"),
+		strings.Contains(msg, "bad connection"):
+		return revokeFailureConnection
+	}
+	return revokeFailureBackend
+}
+
+// metricsMountName converts a mount path into a single metric key
+// element, e.g. "auth/github/" becomes "auth-github".
+func metricsMountName(mount string) string {
+	name := strings.Replace(strings.Trim(mount, "/"), "/", "-", -1)
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
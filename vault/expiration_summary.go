@@ -0,0 +1,66 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+// MountLeaseSummary aggregates the leases held against a single mount.
+// Leases that never expire are counted but do not contribute to the
+// expiry or TTL figures.
+type MountLeaseSummary struct {
+	Count         int           `json:"count" structs:"count" mapstructure:"count"`
+	SoonestExpiry time.Time     `json:"soonest_expiry" structs:"soonest_expiry" mapstructure:"soonest_expiry"`
+	AverageTTL    time.Duration `json:"average_ttl" structs:"average_ttl" mapstructure:"average_ttl"`
+
+	// expiring is the number of leases included in AverageTTL
+	expiring int
+}
+
+// Summary returns the leases currently held, grouped by the mount that
+// issued them.
+func (m *ExpirationManager) Summary() (map[string]*MountLeaseSummary, error) {
+	existing, err := CollectKeys(m.idView)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for leases: %v", err)
+	}
+
+	now := time.Now().UTC()
+	summary := make(map[string]*MountLeaseSummary)
+	totals := make(map[string]time.Duration)
+	for _, leaseID := range existing {
+		le, err := m.loadEntry(leaseID)
+		if err != nil {
+			return nil, err
+		}
+		if le == nil {
+			continue
+		}
+
+		mount := m.router.MatchingMount(le.Path)
+		s, ok := summary[mount]
+		if !ok {
+			s = &MountLeaseSummary{}
+			summary[mount] = s
+		}
+		s.Count++
+
+		if le.ExpireTime.IsZero() {
+			continue
+		}
+		if s.SoonestExpiry.IsZero() || le.ExpireTime.Before(s.SoonestExpiry) {
+			s.SoonestExpiry = le.ExpireTime
+		}
+		if ttl := le.ExpireTime.Sub(now); ttl > 0 {
+			totals[mount] += ttl
+		}
+		s.expiring++
+	}
+
+	for mount, s := range summary {
+		if s.expiring > 0 {
+			s.AverageTTL = totals[mount] / time.Duration(s.expiring)
+		}
+	}
+	return summary, nil
+}
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
@@ -216,6 +217,79 @@ func TestExpiration_Revoke(t *testing.T) {
 	}
 }
 
+func TestExpiration_RevokeFailure(t *testing.T) {
+	exp := mockExpiration(t)
+	noop := &NoopBackend{}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp.router.Mount(noop, "prod/db/", &MountEntry{UUID: meUUID}, view)
+
+	req := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "prod/db/creds/foo",
+	}
+	resp := &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Hour,
+			},
+		},
+		Data: map[string]interface{}{
+			"username": "foo",
+		},
+	}
+
+	id, err := exp.Register(req, resp)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	noop.Err = fmt.Errorf("dial tcp 127.0.0.1:5432: connection refused")
+	if err := exp.Revoke(id); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	recent, counts := exp.RevocationFailures()
+	if len(recent) != 1 {
+		t.Fatalf("bad: %#v", recent)
+	}
+	f := recent[0]
+	if f.LeaseID != id || f.Mount != "prod/db/" || f.Class != revokeFailureConnection {
+		t.Fatalf("bad: %#v", f)
+	}
+	expected := map[string]map[string]int{
+		"prod/db/": {revokeFailureConnection: 1},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("bad: %#v", counts)
+	}
+
+	// The lease is kept so the revocation can be retried
+	noop.Err = nil
+	if err := exp.Revoke(id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestRevocationFailureClass(t *testing.T) {
+	cases := map[string]error{
+		revokeFailureUnsupported: logical.ErrUnsupportedPath,
+		revokeFailurePermission:  logical.ErrPermissionDenied,
+		revokeFailureTimeout:     fmt.Errorf("read tcp: i/o timeout"),
+		revokeFailureConnection:  fmt.Errorf("driver: bad connection"),
+		revokeFailureBackend:     fmt.Errorf("pq: role \"foo\" does not exist"),
+	}
+	for expected, err := range cases {
+		if class := revocationFailureClass(err); class != expected {
+			t.Fatalf("bad: %v: %s", err, class)
+		}
+	}
+}
+
 func TestExpiration_RevokeOnExpire(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
@@ -333,6 +407,53 @@ func TestExpiration_RevokePrefix(t *testing.T) {
 	}
 }
 
+func TestExpiration_Summary(t *testing.T) {
+	exp := mockExpiration(t)
+	noop := &NoopBackend{}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp.router.Mount(noop, "prod/aws/", &MountEntry{UUID: meUUID}, view)
+
+	for _, ttl := range []time.Duration{time.Hour, 3 * time.Hour} {
+		req := &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "prod/aws/foo",
+		}
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: ttl,
+				},
+			},
+		}
+		if _, err := exp.Register(req, resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	summary, err := exp.Summary()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s, ok := summary["prod/aws/"]
+	if !ok || len(summary) != 1 {
+		t.Fatalf("bad: %#v", summary)
+	}
+	if s.Count != 2 {
+		t.Fatalf("bad: %#v", s)
+	}
+	if d := time.Now().Add(time.Hour).Sub(s.SoonestExpiry); d < 0 || d > time.Minute {
+		t.Fatalf("bad: %#v", s)
+	}
+	if d := 2*time.Hour - s.AverageTTL; d < 0 || d > time.Minute {
+		t.Fatalf("bad: %#v", s)
+	}
+}
+
 func TestExpiration_RevokeByToken(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
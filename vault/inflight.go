@@ -0,0 +1,144 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// inFlightSubPath is the sub-path used for the in-flight request
+	// salt, within the system barrier view
+	inFlightSubPath = "in-flight/"
+)
+
+// InFlightRequest describes a request that is currently being handled.
+type InFlightRequest struct {
+	ID              string    `json:"id" structs:"id" mapstructure:"id"`
+	Path            string    `json:"path" structs:"path" mapstructure:"path"`
+	Operation       string    `json:"operation" structs:"operation" mapstructure:"operation"`
+	Mount           string    `json:"mount" structs:"mount" mapstructure:"mount"`
+	ClientTokenHash string    `json:"client_token_hash" structs:"client_token_hash" mapstructure:"client_token_hash"`
+	StartTime       time.Time `json:"start_time" structs:"start_time" mapstructure:"start_time"`
+}
+
+// inFlightRequests tracks the requests being handled by the core and the
+// number in flight for each mount.
+type inFlightRequests struct {
+	l        sync.Mutex
+	nextID   uint64
+	requests map[string]*InFlightRequest
+	counts   map[string]int
+}
+
+func newInFlightRequests() *inFlightRequests {
+	return &inFlightRequests{
+		requests: make(map[string]*InFlightRequest),
+		counts:   make(map[string]int),
+	}
+}
+
+// start records the request and returns the ID used to finish it.
+func (f *inFlightRequests) start(r *InFlightRequest) string {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	f.nextID++
+	r.ID = strconv.FormatUint(f.nextID, 10)
+	f.requests[r.ID] = r
+	f.counts[r.Mount]++
+	f.emit(r.Mount)
+	return r.ID
+}
+
+// finish removes a request once it has been handled.
+func (f *inFlightRequests) finish(id string) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	r, ok := f.requests[id]
+	if !ok {
+		return
+	}
+	delete(f.requests, id)
+	f.counts[r.Mount]--
+	f.emit(r.Mount)
+	if f.counts[r.Mount] == 0 {
+		delete(f.counts, r.Mount)
+	}
+}
+
+// emit updates the gauges for the given mount and the total. The lock
+// must be held.
+func (f *inFlightRequests) emit(mount string) {
+	metrics.SetGauge([]string{"core", "in-flight", metricsMountName(mount)}, float32(f.counts[mount]))
+	metrics.SetGauge([]string{"core", "in-flight"}, float32(len(f.requests)))
+}
+
+// snapshot returns copies of the in-flight requests, oldest first.
+func (f *inFlightRequests) snapshot() []*InFlightRequest {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	requests := make([]*InFlightRequest, 0, len(f.requests))
+	for _, r := range f.requests {
+		c := *r
+		requests = append(requests, &c)
+	}
+	sort.Sort(inFlightByStart(requests))
+	return requests
+}
+
+type inFlightByStart []*InFlightRequest
+
+func (s inFlightByStart) Len() int           { return len(s) }
+func (s inFlightByStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s inFlightByStart) Less(i, j int) bool { return s[i].StartTime.Before(s[j].StartTime) }
+
+// setupInFlight is invoked after we've loaded the mount table to load the
+// salt used to hash the client tokens of in-flight requests. It is kept
+// apart from the token store's salt, so that the hashes cannot be used to
+// find tokens in storage.
+func (c *Core) setupInFlight() error {
+	view := c.systemBarrierView.SubView(inFlightSubPath)
+	s, err := salt.NewSalt(view, &salt.Config{
+		HMAC:     sha256.New,
+		HMACType: "hmac-sha256",
+	})
+	if err != nil {
+		return err
+	}
+	c.inFlightSalt = s
+	return nil
+}
+
+// trackInFlight records a request as in flight, returning the function
+// to call once it has been handled. The core must be unsealed.
+func (c *Core) trackInFlight(req *logical.Request) func() {
+	r := &InFlightRequest{
+		Path:      req.Path,
+		Operation: string(req.Operation),
+		Mount:     c.router.MatchingMount(req.Path),
+		StartTime: time.Now().UTC(),
+	}
+	if req.ClientToken != "" {
+		r.ClientTokenHash = c.inFlightSalt.GetIdentifiedHMAC(req.ClientToken)
+	}
+
+	id := c.inFlight.start(r)
+	return func() {
+		c.inFlight.finish(id)
+	}
+}
+
+// InFlightRequests returns the requests currently being handled, oldest
+// first.
+func (c *Core) InFlightRequests() []*InFlightRequest {
+	return c.inFlight.snapshot()
+}
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestInFlightRequests(t *testing.T) {
+	f := newInFlightRequests()
+	now := time.Now()
+
+	id1 := f.start(&InFlightRequest{Path: "secret/foo", Mount: "secret/", StartTime: now})
+	id2 := f.start(&InFlightRequest{Path: "secret/bar", Mount: "secret/", StartTime: now.Add(-time.Second)})
+	if id1 == id2 {
+		t.Fatalf("bad: duplicate id %s", id1)
+	}
+
+	requests := f.snapshot()
+	if len(requests) != 2 || requests[0].Path != "secret/bar" || requests[1].Path != "secret/foo" {
+		t.Fatalf("bad: %#v", requests)
+	}
+	if f.counts["secret/"] != 2 {
+		t.Fatalf("bad: %#v", f.counts)
+	}
+
+	f.finish(id1)
+	f.finish(id2)
+	f.finish(id2)
+	if len(f.snapshot()) != 0 || len(f.counts) != 0 {
+		t.Fatalf("bad: %#v %#v", f.requests, f.counts)
+	}
+}
+
+func TestCore_InFlightRequests(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	// The request to list in-flight requests is itself in flight
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/in-flight-req",
+		ClientToken: root,
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	requests := resp.Data["requests"].([]map[string]interface{})
+	if len(requests) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	r := requests[0]
+	if r["path"] != "sys/in-flight-req" || r["mount"] != "sys/" || r["operation"] != "read" {
+		t.Fatalf("bad: %#v", r)
+	}
+	if r["client_token_hash"] != c.inFlightSalt.GetIdentifiedHMAC(root) {
+		t.Fatalf("bad: %#v", r)
+	}
+
+	// The hash is not the token's storage key
+	if r["client_token_hash"] == c.tokenStore.SaltID(root) {
+		t.Fatalf("bad: %#v", r)
+	}
+	if counts := resp.Data["counts"].(map[string]int); counts["sys/"] != 1 {
+		t.Fatalf("bad: %#v", counts)
+	}
+
+	// Once finished it is no longer tracked
+	if requests := c.InFlightRequests(); len(requests) != 0 {
+		t.Fatalf("bad: %#v", requests)
+	}
+}
@@ -2,9 +2,11 @@ package vault
 
 import (
 	"fmt"
+	"net/textproto"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/vault/helper/compressutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	"github.com/mitchellh/mapstructure"
@@ -34,8 +36,19 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				"revoke-prefix/*",
 				"audit",
 				"audit/*",
+				"raw",
 				"raw/*",
 				"rotate",
+				"revocation-failures",
+				"in-flight-req",
+				"login-dry-run/*",
+				"maintenance",
+			},
+
+			// The dry run passes the connection on to the login path, for
+			// backends that authenticate the client by it
+			Connection: []string{
+				"login-dry-run/*",
 			},
 		},
 
@@ -70,6 +83,18 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 						Type:        framework.TypeString,
 						Description: strings.TrimSpace(sysHelp["tune_max_lease_ttl"][0]),
 					},
+					"force_no_cache": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Description: strings.TrimSpace(sysHelp["tune_force_no_cache"][0]),
+					},
+					"passthrough_request_headers": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_passthrough_request_headers"][0]),
+					},
+					"mask_not_found": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Description: strings.TrimSpace(sysHelp["tune_mask_not_found"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -276,6 +301,24 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				HelpDescription: strings.TrimSpace(sysHelp["policy"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "login-dry-run/(?P<path>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["login-dry-run_path"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleLoginDryRun,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["login-dry-run"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["login-dry-run"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "audit-hash/(?P<path>.+)",
 
@@ -341,33 +384,86 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 			},
 
 			&framework.Path{
-				Pattern: "raw/(?P<path>.+)",
+				Pattern: "key-status$",
 
-				Fields: map[string]*framework.FieldSchema{
-					"path": &framework.FieldSchema{
-						Type: framework.TypeString,
-					},
-					"value": &framework.FieldSchema{
-						Type: framework.TypeString,
-					},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleKeyStatus,
 				},
 
+				HelpSynopsis:    strings.TrimSpace(sysHelp["key-status"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["key-status"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "revocation-failures$",
+
 				Callbacks: map[logical.Operation]framework.OperationFunc{
-					logical.ReadOperation:   b.handleRawRead,
-					logical.UpdateOperation: b.handleRawWrite,
-					logical.DeleteOperation: b.handleRawDelete,
+					logical.ReadOperation: b.handleRevocationFailures,
 				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["revocation-failures"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["revocation-failures"][1]),
 			},
 
 			&framework.Path{
-				Pattern: "key-status$",
+				Pattern: "leases$",
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
-					logical.ReadOperation: b.handleKeyStatus,
+					logical.ReadOperation: b.handleLeaseSummary,
 				},
 
-				HelpSynopsis:    strings.TrimSpace(sysHelp["key-status"][0]),
-				HelpDescription: strings.TrimSpace(sysHelp["key-status"][1]),
+				HelpSynopsis:    strings.TrimSpace(sysHelp["leases"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["leases"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "in-flight-req$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleInFlightRequests,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["in-flight-req"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["in-flight-req"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "internal/counters/requests$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleRequestCounters,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["request-counters"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["request-counters"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "maintenance$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"paths": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["maintenance_paths"][0]),
+					},
+					"message": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["maintenance_message"][0]),
+					},
+					"exempt_policies": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["maintenance_exempt_policies"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleMaintenanceRead,
+					logical.UpdateOperation: b.handleMaintenanceUpdate,
+					logical.DeleteOperation: b.handleMaintenanceDelete,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["maintenance"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["maintenance"][1]),
 			},
 
 			&framework.Path{
@@ -383,11 +479,61 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 		},
 	}
 
+	if core.enableRaw {
+		b.Backend.Paths = append(b.Backend.Paths, b.rawPaths()...)
+	}
+
 	b.Backend.Setup(config)
 
 	return b.Backend
 }
 
+// rawPaths returns the paths giving direct access to the barrier. They
+// are only registered if enabled in the core config.
+func (b *SystemBackend) rawPaths() []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "raw/?$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type: framework.TypeString,
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleRawList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raw"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raw"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "raw/(?P<path>.+)",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type: framework.TypeString,
+				},
+				"value": &framework.FieldSchema{
+					Type: framework.TypeString,
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleRawRead,
+				logical.UpdateOperation: b.handleRawWrite,
+				logical.DeleteOperation: b.handleRawDelete,
+				logical.ListOperation:   b.handleRawList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raw"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raw"][1]),
+		},
+	}
+}
+
 // SystemBackend implements logical.Backend and is used to interact with
 // the core of the system. This backend is hardcoded to exist at the "sys"
 // prefix. Conceptually it is similar to procfs on Linux.
@@ -619,6 +765,16 @@ func (b *SystemBackend) handleMountTuneRead(
 		},
 	}
 
+	if mountEntry := b.Core.router.MatchingMountEntry(path); mountEntry != nil {
+		headers := mountEntry.Config.PassthroughRequestHeaders
+		if headers == nil {
+			headers = []string{}
+		}
+		resp.Data["force_no_cache"] = mountEntry.Config.ForceNoCache
+		resp.Data["passthrough_request_headers"] = headers
+		resp.Data["mask_not_found"] = mountEntry.Config.MaskNotFound
+	}
+
 	return resp, nil
 }
 
@@ -685,14 +841,45 @@ func (b *SystemBackend) handleMountTuneWrite(
 
 		if newDefault != nil || newMax != nil {
 			b.Core.mountsLock.Lock()
-			defer b.Core.mountsLock.Unlock()
-			if err := b.tuneMountTTLs(path, &mountEntry.Config, newDefault, newMax); err != nil {
+			err := b.tuneMountTTLs(path, &mountEntry.Config, newDefault, newMax)
+			b.Core.mountsLock.Unlock()
+			if err != nil {
 				b.Backend.Logger().Printf("[ERR] sys: tune of path '%s' failed: %v", path, err)
 				return handleError(err)
 			}
 		}
 	}
 
+	// Caching and header parameters
+	{
+		var forceNoCache *bool
+		if raw, ok := data.GetOk("force_no_cache"); ok {
+			tmpNoCache := raw.(bool)
+			forceNoCache = &tmpNoCache
+		}
+
+		var headers []string
+		if raw, ok := data.GetOk("passthrough_request_headers"); ok {
+			headers = []string{}
+			for _, h := range strings.Split(raw.(string), ",") {
+				if h = strings.TrimSpace(h); h != "" {
+					headers = append(headers, textproto.CanonicalMIMEHeaderKey(h))
+				}
+			}
+		}
+
+		var maskNotFound *bool
+		if raw, ok := data.GetOk("mask_not_found"); ok {
+			tmpMask := raw.(bool)
+			maskNotFound = &tmpMask
+		}
+
+		if err := b.tuneMountOptions(path, mountEntry, forceNoCache, headers, maskNotFound); err != nil {
+			b.Backend.Logger().Printf("[ERR] sys: tune of path '%s' failed: %v", path, err)
+			return handleError(err)
+		}
+	}
+
 	return nil, nil
 }
 
@@ -918,6 +1105,13 @@ func (b *SystemBackend) handleAuditHash(
 	}, nil
 }
 
+// handleLoginDryRun is used to check what a login would be granted
+func (b *SystemBackend) handleLoginDryRun(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := data.Get("path").(string)
+	return b.Core.LoginDryRun(path, req.Data, req.Connection)
+}
+
 // handleEnableAudit is used to enable a new audit backend
 func (b *SystemBackend) handleEnableAudit(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -986,14 +1180,37 @@ func (b *SystemBackend) handleRawRead(
 	if entry == nil {
 		return nil, nil
 	}
+
+	// Entries that Vault writes compressed are returned decompressed. Any
+	// other entry is returned as stored, even if it looks compressed. A
+	// compressed entry that fails to decompress is also returned as
+	// stored so that it can be inspected.
+	value := entry.Value
+	if rawCompressed(path) {
+		if decompressed, _, err := compressutil.Decompress(value); err == nil {
+			value = decompressed
+		}
+	}
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"value": string(entry.Value),
+			"value": string(value),
 		},
 	}
 	return resp, nil
 }
 
+// rawCompressed returns if the entry at the given barrier path is written
+// compressed: the mount and auth tables and the policies.
+func rawCompressed(path string) bool {
+	switch {
+	case path == coreMountConfigPath, path == coreAuthConfigPath:
+		return true
+	case strings.HasPrefix(path, systemBarrierPrefix+policySubPath):
+		return true
+	}
+	return false
+}
+
 // handleRawWrite is used to write directly to the barrier
 func (b *SystemBackend) handleRawWrite(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -1037,6 +1254,29 @@ func (b *SystemBackend) handleRawDelete(
 	return nil, nil
 }
 
+// handleRawList is used to list the keys under a prefix of the barrier
+func (b *SystemBackend) handleRawList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := data.Get("path").(string)
+	if path != "" && !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+
+	// Prevent access of protected paths
+	for _, p := range protectedPaths {
+		if strings.HasPrefix(path, p) {
+			err := fmt.Sprintf("cannot list '%s'", path)
+			return logical.ErrorResponse(err), logical.ErrInvalidRequest
+		}
+	}
+
+	keys, err := b.Core.barrier.List(path)
+	if err != nil {
+		return handleError(err)
+	}
+	return logical.ListResponse(keys), nil
+}
+
 // handleKeyStatus returns status information about the backend key
 func (b *SystemBackend) handleKeyStatus(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -1055,6 +1295,171 @@ func (b *SystemBackend) handleKeyStatus(
 	return resp, nil
 }
 
+// handleRevocationFailures returns recent lease revocation failures
+func (b *SystemBackend) handleRevocationFailures(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	recent, counts := b.Core.expiration.RevocationFailures()
+
+	failures := make([]map[string]interface{}, 0, len(recent))
+	for _, f := range recent {
+		failures = append(failures, map[string]interface{}{
+			"lease_id": f.LeaseID,
+			"mount":    f.Mount,
+			"class":    f.Class,
+			"error":    f.Error,
+			"time":     f.Time.Format(time.RFC3339),
+		})
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"failures": failures,
+			"counts":   counts,
+		},
+	}
+	return resp, nil
+}
+
+// handleLeaseSummary returns lease counts and expiry figures by mount
+func (b *SystemBackend) handleLeaseSummary(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	summary, err := b.Core.expiration.Summary()
+	if err != nil {
+		b.Backend.Logger().Printf("[ERR] sys: lease summary failed: %v", err)
+		return handleError(err)
+	}
+
+	total := 0
+	mounts := make(map[string]interface{}, len(summary))
+	for mount, s := range summary {
+		soonest := ""
+		if !s.SoonestExpiry.IsZero() {
+			soonest = s.SoonestExpiry.Format(time.RFC3339)
+		}
+		mounts[mount] = map[string]interface{}{
+			"count":          s.Count,
+			"soonest_expiry": soonest,
+			"average_ttl":    int64(s.AverageTTL.Seconds()),
+		}
+		total += s.Count
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"mounts": mounts,
+			"total":  total,
+		},
+	}
+	return resp, nil
+}
+
+// handleInFlightRequests lists the requests currently being handled
+func (b *SystemBackend) handleInFlightRequests(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	now := time.Now()
+	inFlight := b.Core.InFlightRequests()
+
+	requests := make([]map[string]interface{}, 0, len(inFlight))
+	counts := make(map[string]int)
+	for _, r := range inFlight {
+		requests = append(requests, map[string]interface{}{
+			"id":                r.ID,
+			"path":              r.Path,
+			"operation":         r.Operation,
+			"mount":             r.Mount,
+			"client_token_hash": r.ClientTokenHash,
+			"start_time":        r.StartTime.Format(time.RFC3339),
+			"duration":          now.Sub(r.StartTime).Seconds(),
+		})
+		counts[r.Mount]++
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"requests": requests,
+			"counts":   counts,
+		},
+	}
+	return resp, nil
+}
+
+// handleRequestCounters returns the request counts of each month
+func (b *SystemBackend) handleRequestCounters(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	months, err := b.Core.RequestCounters()
+	if err != nil {
+		return handleError(err)
+	}
+
+	counters := make([]map[string]interface{}, 0, len(months))
+	for _, m := range months {
+		counters = append(counters, map[string]interface{}{
+			"start_time":     m.StartTime.Format(time.RFC3339),
+			"total":          m.Total,
+			"by_mount":       m.ByMount,
+			"by_auth_method": m.ByAuthMethod,
+		})
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"counters": counters,
+		},
+	}
+	return resp, nil
+}
+
+// handleMaintenanceRead returns the maintenance mode configuration
+func (b *SystemBackend) handleMaintenanceRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf := b.Core.Maintenance()
+	if conf == nil {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"enabled": false,
+			},
+		}, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":         true,
+			"paths":           conf.Paths,
+			"message":         conf.Message,
+			"exempt_policies": conf.ExemptPolicies,
+			"start_time":      conf.StartTime.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// handleMaintenanceUpdate turns on maintenance mode
+func (b *SystemBackend) handleMaintenanceUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf := &MaintenanceConfig{
+		Paths:          splitCommaList(data.Get("paths").(string)),
+		Message:        data.Get("message").(string),
+		ExemptPolicies: splitCommaList(data.Get("exempt_policies").(string)),
+	}
+	if len(conf.Paths) == 0 {
+		return logical.ErrorResponse("missing paths"), nil
+	}
+
+	if err := b.Core.SetMaintenance(conf); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
+
+// handleMaintenanceDelete turns off maintenance mode
+func (b *SystemBackend) handleMaintenanceDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.ClearMaintenance(); err != nil {
+		b.Backend.Logger().Printf("[ERR] sys: disabling maintenance mode failed: %v", err)
+		return handleError(err)
+	}
+	return nil, nil
+}
+
 // handleRotate is used to trigger a key rotation
 func (b *SystemBackend) handleRotate(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -1137,6 +1542,18 @@ and max_lease_ttl.`,
 		`The max lease TTL for this mount.`,
 	},
 
+	"tune_force_no_cache": {
+		`If true, reads of this mount's storage bypass the physical cache.`,
+	},
+
+	"tune_passthrough_request_headers": {
+		`Comma-separated list of client request headers passed to the backend.`,
+	},
+
+	"tune_mask_not_found": {
+		`If true, requests for items that do not exist return permission denied rather than not found.`,
+	},
+
 	"remount": {
 		"Move the mount point of an already-mounted backend.",
 		`
@@ -1263,6 +1680,28 @@ or delete a policy.
 		"",
 	},
 
+	"login-dry-run": {
+		"Check what a login would be granted without issuing a token.",
+		`
+This path takes the identity of a user, in the form the login endpoint of the
+credential backend describes, and returns the policies, TTL, display name and
+metadata a login by that user would be granted, which is useful for debugging
+how a backend maps users and groups to policies. No token is issued.
+
+The backend resolves this from its stored users, roles and group mappings.
+Credentials are not verified and the login itself is not run, so nothing
+such as failed login counts is changed. Only backends that support dry runs
+can be checked: app-id, cert, github, ldap and userpass.
+
+This path requires sudo privileges.
+		`,
+	},
+
+	"login-dry-run_path": {
+		`The login path to check, relative to "auth/", such as "userpass/login/foo".`,
+		"",
+	},
+
 	"audit-hash": {
 		"The hash of the given string via the given audit backend",
 		"",
@@ -1303,6 +1742,20 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"raw": {
+		"Access the barrier storage directly.",
+		`
+		Reads, writes, deletes and lists entries in the storage underneath
+		the barrier by their raw key rather than their logical path. This
+		is intended for repairing corrupted entries, such as a bad mount
+		table, and bypasses all of the checks made by the backends that own
+		the data. The keyring and master key cannot be accessed.
+
+		These endpoints are only available if "raw_storage_endpoint" is set
+		in the server configuration, and require sudo capability.
+		`,
+	},
+
 	"key-status": {
 		"Provides information about the backend encryption key.",
 		`
@@ -1310,6 +1763,86 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"leases": {
+		"Summarizes the current leases by mount.",
+		`
+		Returns, for each mount with leases outstanding, the number of
+		leases, the soonest expiration time and the average remaining TTL in
+		seconds, along with the total number of leases. Leases that do not
+		expire are counted but are not included in the expiration and TTL
+		figures.
+		`,
+	},
+
+	"in-flight-req": {
+		"Lists the requests currently being handled.",
+		`
+		Returns the requests that this Vault is currently handling, oldest
+		first, with their path, operation, mount, how long they have been
+		running in seconds and a hash of the client token, along with the
+		number of requests in flight for each mount. This is useful to see
+		which requests are stuck when latency rises.
+		`,
+	},
+
+	"request-counters": {
+		"Returns the number of requests handled each month.",
+		`
+		Returns, for each month, the total number of requests handled by
+		this Vault along with the number per mount and per auth method,
+		oldest first. The auth method is the mount of the credential backend
+		that issued the token used, or that a login was made against.
+
+		The counters are kept in storage so that they survive restarts and
+		leader changes; they are written every minute, and the requests
+		counted since the last write are lost if the active node fails.
+		The last 24 months are kept.
+		`,
+	},
+
+	"maintenance": {
+		"Configures maintenance mode, which rejects writes to selected paths.",
+		`
+		While maintenance mode is on, create, update and delete requests on
+		the configured paths fail with a 503 status code and the configured
+		message. Reads, lists and logins are not affected, and neither is
+		the system backend. This allows storage migrations or maintenance of
+		a backend to proceed without unmounting it.
+
+		Tokens with the root policy or one of the exempt policies may still
+		write, so that operators can verify their work. The configuration is
+		persisted, and stays in effect across restarts until it is deleted.
+		`,
+	},
+
+	"maintenance_paths": {
+		`Comma-separated list of paths to reject writes on. A path ending in
+"*" covers every path with that prefix, such as "secret/*". Paths under
+"sys/" may not be given.`,
+		"",
+	},
+
+	"maintenance_message": {
+		"Message returned to clients whose writes are rejected.",
+		"",
+	},
+
+	"maintenance_exempt_policies": {
+		"Comma-separated list of policies whose tokens may still write.",
+		"",
+	},
+
+	"revocation-failures": {
+		"Lists recent failures to revoke leases.",
+		`
+		Returns the most recent failures to revoke leases, newest first, along
+		with the number of failures by mount and class since the Vault was
+		unsealed. The class is one of "timeout", "connection", "unsupported",
+		"permission_denied" or "backend", and helps to tell a transient problem
+		from a broken backend configuration such as an unreachable database.
+		`,
+	},
+
 	"rotate": {
 		"Rotates the backend encryption key used to persist data.",
 		`
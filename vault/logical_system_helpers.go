@@ -3,6 +3,8 @@ package vault
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -67,3 +69,58 @@ func (b *SystemBackend) tuneMountTTLs(path string, meConfig *MountConfig, newDef
 
 	return nil
 }
+
+// tuneMountOptions sets the caching, header and not found masking options
+// of a mount point. A nil value leaves the option unchanged.
+func (b *SystemBackend) tuneMountOptions(path string, me *MountEntry, forceNoCache *bool, headers []string, maskNotFound *bool) error {
+	if forceNoCache == nil && headers == nil && maskNotFound == nil {
+		return nil
+	}
+
+	// Hold the lock of the table the mount belongs to while it is changed
+	// and persisted
+	var lock *sync.RWMutex
+	var persist func() error
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		lock = &b.Core.authLock
+		persist = func() error { return b.Core.persistAuth(b.Core.auth) }
+	} else {
+		lock = &b.Core.mountsLock
+		persist = func() error { return b.Core.persistMounts(b.Core.mounts) }
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	oldNoCache := me.Config.ForceNoCache
+	oldHeaders := me.Config.PassthroughRequestHeaders
+	oldMaskNotFound := me.Config.MaskNotFound
+	b.Core.router.UpdateMountConfig(path, func(config *MountConfig) {
+		if forceNoCache != nil {
+			config.ForceNoCache = *forceNoCache
+		}
+		if headers != nil {
+			config.PassthroughRequestHeaders = headers
+		}
+		if maskNotFound != nil {
+			config.MaskNotFound = *maskNotFound
+		}
+	})
+
+	// Update the mount table, restoring the old options if that fails
+	if err := persist(); err != nil {
+		b.Core.router.UpdateMountConfig(path, func(config *MountConfig) {
+			config.ForceNoCache = oldNoCache
+			config.PassthroughRequestHeaders = oldHeaders
+			config.MaskNotFound = oldMaskNotFound
+		})
+		return errors.New("failed to update mount table")
+	}
+
+	if forceNoCache != nil {
+		b.Core.setViewNoCache(b.Core.router.MatchingStorageView(path), *forceNoCache)
+	}
+
+	b.Core.logger.Printf("[INFO] core: tuned '%s'", path)
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/compressutil"
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
 )
@@ -19,8 +20,13 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 		"revoke-prefix/*",
 		"audit",
 		"audit/*",
+		"raw",
 		"raw/*",
 		"rotate",
+		"revocation-failures",
+		"in-flight-req",
+		"login-dry-run/*",
+		"maintenance",
 	}
 
 	b := testSystemBackend(t)
@@ -707,6 +713,32 @@ func TestSystemBackend_rawRead(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_rawRead_notCompressed(t *testing.T) {
+	b := testSystemBackend(t)
+
+	// A value that only looks compressed is returned as written
+	raw, err := compressutil.Compress([]byte("foo"), &compressutil.CompressionConfig{
+		Type: compressutil.CompressionTypeGzip,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	req := logical.TestRequest(t, logical.UpdateOperation, "raw/foo")
+	req.Data["value"] = string(raw)
+	if _, err := b.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "raw/foo")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["value"].(string) != string(raw) {
+		t.Fatalf("bad: %v", resp)
+	}
+}
+
 func TestSystemBackend_rawWrite_Protected(t *testing.T) {
 	b := testSystemBackend(t)
 
@@ -784,6 +816,52 @@ func TestSystemBackend_rawDelete(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_rawList(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ListOperation, "raw/core")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	found := false
+	for _, k := range resp.Data["keys"].([]string) {
+		if "core/"+k == coreMountConfigPath {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	// Listing the root should show the top level prefixes
+	req = logical.TestRequest(t, logical.ListOperation, "raw/")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	keys := resp.Data["keys"].([]string)
+	if len(keys) == 0 || keys[0] != "core/" {
+		t.Fatalf("bad: %v", resp)
+	}
+}
+
+func TestSystemBackend_rawDisabled(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	c.enableRaw = false
+	b := NewSystemBackend(c, &logical.BackendConfig{
+		Logger: c.logger,
+		System: logical.StaticSystemView{},
+	})
+
+	req := logical.TestRequest(t, logical.ReadOperation, "raw/"+coreMountConfigPath)
+	_, err := b.HandleRequest(req)
+	if err != logical.ErrUnsupportedPath {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestSystemBackend_keyStatus(t *testing.T) {
 	b := testSystemBackend(t)
 	req := logical.TestRequest(t, logical.ReadOperation, "key-status")
@@ -801,6 +879,40 @@ func TestSystemBackend_keyStatus(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_revocationFailures(t *testing.T) {
+	b := testSystemBackend(t)
+	req := logical.TestRequest(t, logical.ReadOperation, "revocation-failures")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	exp := map[string]interface{}{
+		"failures": []map[string]interface{}{},
+		"counts":   map[string]map[string]int{},
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+}
+
+func TestSystemBackend_leases(t *testing.T) {
+	b := testSystemBackend(t)
+	req := logical.TestRequest(t, logical.ReadOperation, "leases")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	exp := map[string]interface{}{
+		"mounts": map[string]interface{}{},
+		"total":  0,
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+}
+
 func TestSystemBackend_rotate(t *testing.T) {
 	b := testSystemBackend(t)
 
@@ -851,3 +963,96 @@ func testCoreSystemBackend(t *testing.T) (*Core, logical.Backend, string) {
 	}
 	return c, NewSystemBackend(c, bc), root
 }
+
+func TestSystemBackend_maintenance(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "maintenance")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["enabled"] != false {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "maintenance")
+	req.Data["paths"] = "secret/*, transit/keys/foo"
+	req.Data["message"] = "back soon"
+	req.Data["exempt_policies"] = "admin"
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "maintenance")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	delete(resp.Data, "start_time")
+	exp := map[string]interface{}{
+		"enabled":         true,
+		"paths":           []string{"secret/*", "transit/keys/foo"},
+		"message":         "back soon",
+		"exempt_policies": []string{"admin"},
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+
+	// The system backend cannot be put under maintenance
+	req = logical.TestRequest(t, logical.UpdateOperation, "maintenance")
+	req.Data["paths"] = "sys/*"
+	resp, err = b.HandleRequest(req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "maintenance")
+	if _, err := b.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "maintenance")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["enabled"] != false {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestSystemBackend_requestCounters(t *testing.T) {
+	c, b, root := testCoreSystemBackend(t)
+
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "secret/foo",
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/requests")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	exp := map[string]interface{}{
+		"counters": []map[string]interface{}{
+			map[string]interface{}{
+				"start_time":     monthStart(time.Now()).Format(time.RFC3339),
+				"total":          uint64(1),
+				"by_mount":       map[string]uint64{"secret/": 1},
+				"by_auth_method": map[string]uint64{"auth/token/": 1},
+			},
+		},
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+}
@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// LoginDryRun asks the credential backend at a login path what a login
+// by the identity in data would be granted, and returns what the token
+// would be issued with without creating it. The backend resolves this
+// from its stored configuration, so no credentials are checked and the
+// login itself is not run. The path is relative to "auth/", such as
+// "userpass/login/foo".
+func (c *Core) LoginDryRun(path string, data map[string]interface{},
+	conn *logical.Connection) (*logical.Response, error) {
+	req := &logical.Request{
+		Operation:  logical.ResolveLoginOperation,
+		Path:       credentialRoutePrefix + strings.TrimPrefix(path, "/"),
+		Data:       data,
+		Connection: conn,
+	}
+	if !c.router.LoginPath(req.Path) {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"'%s' is not a login path", req.Path)), logical.ErrInvalidRequest
+	}
+
+	resp, err := c.router.Route(req)
+	if err == logical.ErrUnsupportedOperation {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"the backend at '%s' does not support login dry runs", req.Path)), logical.ErrInvalidRequest
+	}
+	if err != nil || (resp != nil && resp.IsError()) {
+		return resp, err
+	}
+	if resp == nil || resp.Auth == nil {
+		return logical.ErrorResponse("the login would not be granted a token"), nil
+	}
+	if resp.Secret != nil {
+		c.logger.Printf("[ERR] core: unexpected Secret response for login path"+
+			"(request path: %s)", req.Path)
+		return nil, ErrInternalError
+	}
+	auth := resp.Auth
+
+	policies, err := c.prepareLoginAuth(req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &logical.Response{
+		Data: map[string]interface{}{
+			"display_name": auth.DisplayName,
+			"policies":     policies,
+			"metadata":     auth.Metadata,
+			"ttl":          int64(auth.TTL.Seconds()),
+			"renewable":    auth.Renewable,
+		},
+	}
+	for _, w := range resp.Warnings() {
+		result.AddWarning(w)
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_LoginDryRun(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies: []string{"foo", "bar"},
+				Metadata: map[string]string{
+					"user": "armon",
+				},
+				DisplayName: "armon",
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	// Enable the credential backend
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/auth/foo")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	conn := &logical.Connection{RemoteAddr: "127.0.0.1"}
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/login-dry-run/foo/login")
+	req.Data["user"] = "armon"
+	req.ClientToken = root
+	req.Connection = conn
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Auth != nil {
+		t.Fatalf("a dry run must not issue a token: %#v", resp.Auth)
+	}
+
+	expected := map[string]interface{}{
+		"display_name": "foo-armon",
+		"policies":     []string{"foo", "bar", "default"},
+		"metadata": map[string]string{
+			"user": "armon",
+		},
+		"ttl":       int64((24 * time.Hour).Seconds()),
+		"renewable": false,
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: got\n%#v\nexpected\n%#v", resp.Data, expected)
+	}
+
+	// The backend was asked to resolve the login rather than run it, and
+	// saw the identity and the connection
+	last := noop.Requests[len(noop.Requests)-1]
+	if last.Operation != logical.ResolveLoginOperation || last.Path != "login" ||
+		last.Data["user"] != "armon" || last.Connection != conn {
+		t.Fatalf("bad: %#v", last)
+	}
+}
+
+func TestCore_LoginDryRun_NotLoginPath(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/login-dry-run/token/create")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
@@ -0,0 +1,206 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// coreMaintenanceConfigPath is used to store the maintenance mode
+	// configuration, so that it survives a restart or a leader change
+	coreMaintenanceConfigPath = "core/maintenance"
+)
+
+// MaintenanceConfig describes the paths that reject writes while
+// maintenance is carried out on their backends or storage.
+type MaintenanceConfig struct {
+	// Paths are the request paths that are closed to writes. A path
+	// ending in "*" matches any request path with that prefix, any
+	// other path must match exactly.
+	Paths []string `json:"paths"`
+
+	// Message is returned to clients whose writes are rejected
+	Message string `json:"message"`
+
+	// ExemptPolicies are the policies whose tokens may still write.
+	// Root tokens are always exempt.
+	ExemptPolicies []string `json:"exempt_policies"`
+
+	// StartTime is when maintenance mode was last configured
+	StartTime time.Time `json:"start_time"`
+}
+
+// matches returns the entry of Paths matching the request path, if any
+func (m *MaintenanceConfig) matches(path string) (string, bool) {
+	for _, p := range m.Paths {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+				return p, true
+			}
+		} else if path == p {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// exempt checks if a token with the given policies may bypass the
+// maintenance mode
+func (m *MaintenanceConfig) exempt(policies []string) bool {
+	for _, p := range policies {
+		if p == "root" || strListContains(m.ExemptPolicies, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Maintenance returns a copy of the maintenance mode configuration, or
+// nil if maintenance mode is off.
+func (c *Core) Maintenance() *MaintenanceConfig {
+	c.maintenanceLock.RLock()
+	defer c.maintenanceLock.RUnlock()
+
+	if c.maintenance == nil {
+		return nil
+	}
+	conf := new(MaintenanceConfig)
+	*conf = *c.maintenance
+	conf.Paths = append([]string(nil), c.maintenance.Paths...)
+	conf.ExemptPolicies = append([]string(nil), c.maintenance.ExemptPolicies...)
+	return conf
+}
+
+// SetMaintenance turns on maintenance mode for the configured paths,
+// replacing any previous configuration.
+func (c *Core) SetMaintenance(conf *MaintenanceConfig) error {
+	if len(conf.Paths) == 0 {
+		return fmt.Errorf("at least one path must be given")
+	}
+	for _, p := range conf.Paths {
+		if p == "" || strings.HasPrefix(p, "/") {
+			return fmt.Errorf("invalid path '%s'", p)
+		}
+		if strings.Contains(strings.TrimSuffix(p, "*"), "*") {
+			return fmt.Errorf("path '%s' may only contain '*' at the end", p)
+		}
+	}
+
+	// The system backend must stay writable, or maintenance mode could
+	// never be turned off again
+	if p, ok := conf.matches("sys/maintenance"); ok {
+		return fmt.Errorf("path '%s' covers the system backend", p)
+	}
+	for _, p := range conf.Paths {
+		if strings.HasPrefix(p, "sys/") {
+			return fmt.Errorf("path '%s' covers the system backend", p)
+		}
+	}
+
+	conf.StartTime = time.Now().UTC()
+	if err := c.persistMaintenance(conf); err != nil {
+		return err
+	}
+
+	c.maintenanceLock.Lock()
+	c.maintenance = conf
+	c.maintenanceLock.Unlock()
+
+	c.logger.Printf("[INFO] core: maintenance mode enabled for paths %v", conf.Paths)
+	return nil
+}
+
+// ClearMaintenance turns off maintenance mode
+func (c *Core) ClearMaintenance() error {
+	if err := c.barrier.Delete(coreMaintenanceConfigPath); err != nil {
+		c.logger.Printf("[ERR] core: failed to delete maintenance config: %v", err)
+		return err
+	}
+
+	c.maintenanceLock.Lock()
+	wasEnabled := c.maintenance != nil
+	c.maintenance = nil
+	c.maintenanceLock.Unlock()
+
+	if wasEnabled {
+		c.logger.Printf("[INFO] core: maintenance mode disabled")
+	}
+	return nil
+}
+
+// checkMaintenance returns an error if the request writes to a path
+// under maintenance and the token is not exempt.
+func (c *Core) checkMaintenance(req *logical.Request, auth *logical.Auth) error {
+	switch req.Operation {
+	case logical.CreateOperation, logical.UpdateOperation, logical.DeleteOperation:
+	default:
+		return nil
+	}
+
+	c.maintenanceLock.RLock()
+	defer c.maintenanceLock.RUnlock()
+
+	if c.maintenance == nil {
+		return nil
+	}
+	if _, ok := c.maintenance.matches(req.Path); !ok {
+		return nil
+	}
+	if auth != nil && c.maintenance.exempt(auth.Policies) {
+		return nil
+	}
+
+	msg := fmt.Sprintf("writes to '%s' are unavailable during maintenance", req.Path)
+	if c.maintenance.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, c.maintenance.Message)
+	}
+	return logical.CodedError(503, msg)
+}
+
+// loadMaintenance is invoked as part of postUnseal to restore the
+// maintenance mode configuration
+func (c *Core) loadMaintenance() error {
+	raw, err := c.barrier.Get(coreMaintenanceConfigPath)
+	if err != nil {
+		c.logger.Printf("[ERR] core: failed to read maintenance config: %v", err)
+		return err
+	}
+
+	var conf *MaintenanceConfig
+	if raw != nil {
+		conf = new(MaintenanceConfig)
+		if err := json.Unmarshal(raw.Value, conf); err != nil {
+			c.logger.Printf("[ERR] core: failed to decode maintenance config: %v", err)
+			return err
+		}
+		c.logger.Printf("[INFO] core: maintenance mode is enabled for paths %v", conf.Paths)
+	}
+
+	c.maintenanceLock.Lock()
+	c.maintenance = conf
+	c.maintenanceLock.Unlock()
+	return nil
+}
+
+// persistMaintenance is used to persist the maintenance mode configuration
+func (c *Core) persistMaintenance(conf *MaintenanceConfig) error {
+	raw, err := json.Marshal(conf)
+	if err != nil {
+		c.logger.Printf("[ERR] core: failed to encode maintenance config: %v", err)
+		return err
+	}
+
+	entry := &Entry{
+		Key:   coreMaintenanceConfigPath,
+		Value: raw,
+	}
+	if err := c.barrier.Put(entry); err != nil {
+		c.logger.Printf("[ERR] core: failed to persist maintenance config: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_Maintenance(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+	testCoreMakeToken(t, c, root, "writer", "", []string{"writer"})
+	testCoreMakeToken(t, c, root, "admin", "", []string{"writer", "admin"})
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sys/policy/writer",
+		Data: map[string]interface{}{
+			"rules": `path "secret/*" { policy = "write" }`,
+		},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	write := func(token string) error {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+			Data: map[string]interface{}{
+				"foo": "bar",
+			},
+			ClientToken: token,
+		}
+		_, err := c.HandleRequest(req)
+		return err
+	}
+
+	err := c.SetMaintenance(&MaintenanceConfig{
+		Paths:          []string{"secret/*"},
+		Message:        "migrating storage",
+		ExemptPolicies: []string{"admin"},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Writes are rejected with a 503 and the message
+	err = write("writer")
+	coded, ok := err.(logical.HTTPCodedError)
+	if !ok || coded.Code() != 503 {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(err.Error(), "migrating storage") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reads still work
+	req = &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "secret/foo",
+		ClientToken: "writer",
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Exempt and root tokens can write
+	if err := write("admin"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := write(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Maintenance mode survives a seal
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.Unseal(TestKeyCopy(key)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf := c.Maintenance(); conf == nil || conf.Message != "migrating storage" {
+		t.Fatalf("bad: %#v", conf)
+	}
+	if err := write("writer"); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Until it is cleared
+	if err := c.ClearMaintenance(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := write("writer"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestCore_Maintenance_InvalidPaths(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	for _, paths := range [][]string{
+		nil,
+		[]string{""},
+		[]string{"/secret/*"},
+		[]string{"secret/*/foo"},
+		[]string{"*"},
+		[]string{"s*"},
+		[]string{"sys/mounts"},
+	} {
+		if err := c.SetMaintenance(&MaintenanceConfig{Paths: paths}); err == nil {
+			t.Fatalf("%v: expected error", paths)
+		}
+	}
+	if conf := c.Maintenance(); conf != nil {
+		t.Fatalf("bad: %#v", conf)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/compressutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -25,6 +26,14 @@ const (
 	// systemBarrierPrefix is the prefix used for the
 	// system logical backend.
 	systemBarrierPrefix = "sys/"
+
+	// maxMountPathLength is the maximum length of a mount path,
+	// including the trailing slash.
+	maxMountPathLength = 256
+
+	// maxMountPathDepth is the maximum number of segments a mount
+	// path may be made up of, e.g. "team/app/kv/" has a depth of 3.
+	maxMountPathDepth = 8
 )
 
 var (
@@ -133,6 +142,17 @@ type MountEntry struct {
 type MountConfig struct {
 	DefaultLeaseTTL time.Duration `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"` // Override for global default
 	MaxLeaseTTL     time.Duration `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`             // Override for global default
+
+	// ForceNoCache bypasses the physical read cache for the mount's storage
+	ForceNoCache bool `json:"force_no_cache,omitempty" structs:"force_no_cache" mapstructure:"force_no_cache"`
+
+	// PassthroughRequestHeaders lists the client request headers that are
+	// passed to the backend. No headers are passed by default.
+	PassthroughRequestHeaders []string `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
+
+	// MaskNotFound reports requests for items that do not exist in the
+	// mount as permission denied rather than not found
+	MaskNotFound bool `json:"mask_not_found,omitempty" structs:"mask_not_found" mapstructure:"mask_not_found"`
 }
 
 // Returns a deep copy of the mount entry
@@ -141,12 +161,16 @@ func (e *MountEntry) Clone() *MountEntry {
 	for k, v := range e.Options {
 		optClone[k] = v
 	}
+	configClone := e.Config
+	if e.Config.PassthroughRequestHeaders != nil {
+		configClone.PassthroughRequestHeaders = append([]string(nil), e.Config.PassthroughRequestHeaders...)
+	}
 	return &MountEntry{
 		Path:        e.Path,
 		Type:        e.Type,
 		Description: e.Description,
 		UUID:        e.UUID,
-		Config:      e.Config,
+		Config:      configClone,
 		Options:     optClone,
 	}
 }
@@ -158,10 +182,15 @@ func (c *Core) mount(me *MountEntry) error {
 		me.Path += "/"
 	}
 
+	// Ensure the path is well-formed
+	if err := validateMountPath(me.Path); err != nil {
+		return err
+	}
+
 	// Prevent protected paths from being mounted
 	for _, p := range protectedMounts {
 		if strings.HasPrefix(me.Path, p) {
-			return logical.CodedError(403, fmt.Sprintf("cannot mount '%s'", me.Path))
+			return logical.CodedError(403, fmt.Sprintf("cannot mount '%s': '%s' is a reserved prefix", me.Path, p))
 		}
 	}
 
@@ -180,6 +209,14 @@ func (c *Core) mount(me *MountEntry) error {
 	c.mountsLock.Lock()
 	defer c.mountsLock.Unlock()
 
+	// Verify that the new mount would not shadow an existing, more
+	// deeply nested mount, e.g. mounting team/ over team/app/kv/
+	for _, ent := range c.mounts.Entries {
+		if strings.HasPrefix(ent.Path, me.Path) {
+			return logical.CodedError(409, fmt.Sprintf("existing mount at %s", ent.Path))
+		}
+	}
+
 	// Generate a new UUID and view
 	meUUID, err := uuid.GenerateUUID()
 	if err != nil {
@@ -209,6 +246,43 @@ func (c *Core) mount(me *MountEntry) error {
 	return nil
 }
 
+// validateMountPath is used to verify that a mount path, which must
+// already end in a slash, is well-formed and within the length and depth
+// limits. Checking this up front gives a clear error instead of failing
+// later in the router or when building policies.
+func validateMountPath(path string) error {
+	if path == "/" {
+		return logical.CodedError(400, "backend path must be specified")
+	}
+	if strings.HasPrefix(path, "/") {
+		return logical.CodedError(400, fmt.Sprintf("mount path '%s' cannot begin with a slash", path))
+	}
+	if len(path) > maxMountPathLength {
+		return logical.CodedError(400, fmt.Sprintf(
+			"mount path '%s' is %d characters long, the maximum is %d",
+			path, len(path), maxMountPathLength))
+	}
+
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	if len(segments) > maxMountPathDepth {
+		return logical.CodedError(400, fmt.Sprintf(
+			"mount path '%s' has %d segments, the maximum is %d",
+			path, len(segments), maxMountPathDepth))
+	}
+	for _, s := range segments {
+		switch {
+		case s == "":
+			return logical.CodedError(400, fmt.Sprintf("mount path '%s' contains an empty segment", path))
+		case s == "." || s == "..":
+			return logical.CodedError(400, fmt.Sprintf("mount path '%s' cannot contain '%s' segments", path, s))
+		case strings.Contains(s, "*"):
+			return logical.CodedError(400, fmt.Sprintf("mount path '%s' cannot contain '*'", path))
+		}
+	}
+
+	return nil
+}
+
 // Unmount is used to unmount a path.
 func (c *Core) unmount(path string) error {
 	// Ensure we end the path in a slash
@@ -264,6 +338,7 @@ func (c *Core) unmount(path string) error {
 	if err := ClearView(view); err != nil {
 		return err
 	}
+	c.setViewNoCache(view, false)
 
 	// Remove the mount table entry
 	if err := c.removeMountEntry(path); err != nil {
@@ -312,11 +387,19 @@ func (c *Core) remount(src, dst string) error {
 		dst += "/"
 	}
 
+	// Ensure the destination is well-formed
+	if err := validateMountPath(dst); err != nil {
+		return err
+	}
+
 	// Prevent protected paths from being remounted
 	for _, p := range protectedMounts {
 		if strings.HasPrefix(src, p) {
 			return fmt.Errorf("cannot remount '%s'", src)
 		}
+		if strings.HasPrefix(dst, p) {
+			return logical.CodedError(403, fmt.Sprintf("cannot remount to '%s': '%s' is a reserved prefix", dst, p))
+		}
 	}
 
 	// Verify exact match of the route
@@ -332,6 +415,12 @@ func (c *Core) remount(src, dst string) error {
 	c.mountsLock.Lock()
 	defer c.mountsLock.Unlock()
 
+	for _, ent := range c.mounts.Entries {
+		if ent.Path != src && strings.HasPrefix(ent.Path, dst) {
+			return fmt.Errorf("existing mount at '%s'", ent.Path)
+		}
+	}
+
 	// Mark the entry as tainted
 	if err := c.taintMountEntry(src); err != nil {
 		return err
@@ -396,7 +485,7 @@ func (c *Core) loadMounts() error {
 	defer c.mountsLock.Unlock()
 
 	if raw != nil {
-		if err := json.Unmarshal(raw.Value, mountTable); err != nil {
+		if err := decodeTable(raw.Value, mountTable); err != nil {
 			c.logger.Printf("[ERR] core: failed to decode mount table: %v", err)
 			return errLoadMountsFailed
 		}
@@ -439,10 +528,33 @@ func (c *Core) loadMounts() error {
 	return nil
 }
 
+// encodeTable marshals a mount or auth table and compresses it. The tables
+// grow with every mount and are read on each unseal, so gzip is used for
+// the best ratio.
+func encodeTable(table *MountTable) ([]byte, error) {
+	raw, err := json.Marshal(table)
+	if err != nil {
+		return nil, err
+	}
+	return compressutil.Compress(raw, &compressutil.CompressionConfig{
+		Type: compressutil.CompressionTypeGzip,
+	})
+}
+
+// decodeTable reverses encodeTable, also accepting the uncompressed
+// tables written by earlier versions.
+func decodeTable(raw []byte, table *MountTable) error {
+	raw, _, err := compressutil.Decompress(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, table)
+}
+
 // persistMounts is used to persist the mount table after modification
 func (c *Core) persistMounts(table *MountTable) error {
 	// Marshal the table
-	raw, err := json.Marshal(table)
+	raw, err := encodeTable(table)
 	if err != nil {
 		c.logger.Printf("[ERR] core: failed to encode mount table: %v", err)
 		return err
@@ -481,6 +593,9 @@ func (c *Core) setupMounts() error {
 
 		// Create a barrier view using the UUID
 		view = NewBarrierView(c.barrier, barrierPath)
+		if entry.Config.ForceNoCache {
+			c.setViewNoCache(view, true)
+		}
 
 		// Initialize the backend
 		// Create the new backend
@@ -518,6 +633,15 @@ func (c *Core) setupMounts() error {
 	return nil
 }
 
+// setViewNoCache sets whether reads through the view bypass the physical
+// cache, if the cache is enabled.
+func (c *Core) setViewNoCache(view *BarrierView, noCache bool) {
+	if c.physicalCache == nil || view == nil {
+		return
+	}
+	c.physicalCache.SetNoCache(view.prefix, noCache)
+}
+
 // unloadMounts is used before we seal the vault to reset the mounts to
 // their unloaded state, calling Cleanup if defined. This is reversed by load and setup mounts.
 func (c *Core) unloadMounts() error {
@@ -1,10 +1,13 @@
 package vault
 
 import (
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/vault/helper/compressutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -35,6 +38,46 @@ func TestCore_DefaultMountTable(t *testing.T) {
 	}
 }
 
+func TestCore_MountTable_Compressed(t *testing.T) {
+	c, key, _ := TestCoreUnsealed(t)
+
+	raw, err := c.barrier.Get(coreMountConfigPath)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if raw.Value[0] != compressutil.CompressionCanaryGzip {
+		t.Fatalf("mount table not compressed: %q", raw.Value[0])
+	}
+
+	// Tables written by earlier versions are uncompressed
+	plain, err := json.Marshal(c.mounts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.barrier.Put(&Entry{Key: coreMountConfigPath, Value: plain}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	conf := &CoreConfig{
+		Physical:     c.physical,
+		DisableMlock: true,
+	}
+	c2, err := NewCore(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	unseal, err := c2.Unseal(key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !unseal {
+		t.Fatalf("should be unsealed")
+	}
+	if !reflect.DeepEqual(c.mounts, c2.mounts) {
+		t.Fatalf("mismatch: %v %v", c.mounts, c2.mounts)
+	}
+}
+
 func TestCore_Mount(t *testing.T) {
 	c, key, _ := TestCoreUnsealed(t)
 	me := &MountEntry{
@@ -73,6 +116,81 @@ func TestCore_Mount(t *testing.T) {
 	}
 }
 
+func TestCore_Mount_Nested(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	me := &MountEntry{
+		Path: "team/app/kv",
+		Type: "generic",
+	}
+	if err := c.mount(me); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	match := c.router.MatchingMount("team/app/kv/foo")
+	if match != "team/app/kv/" {
+		t.Fatalf("bad: %s", match)
+	}
+
+	// A sibling at the same depth is allowed
+	me = &MountEntry{
+		Path: "team/app/pki",
+		Type: "generic",
+	}
+	if err := c.mount(me); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mounting beneath or above an existing mount is a conflict
+	for _, path := range []string{"team/app/kv/sub", "team/app", "team"} {
+		err := c.mount(&MountEntry{
+			Path: path,
+			Type: "generic",
+		})
+		coded, ok := err.(logical.HTTPCodedError)
+		if !ok || coded.Code() != 409 {
+			t.Fatalf("path %s: bad: %v", path, err)
+		}
+	}
+}
+
+func TestCore_Mount_InvalidPath(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	long := strings.Repeat("a", maxMountPathLength)
+	deep := strings.Repeat("a/", maxMountPathDepth+1)
+	cases := map[string]int{
+		"":             400,
+		"/foo":         400,
+		"foo//bar":     400,
+		"foo/../bar":   400,
+		"foo/./bar":    400,
+		"foo/*":        400,
+		long:           400,
+		deep:           400,
+		"sys/foo":      403,
+		"auth/foo":     403,
+		"cubbyhole/kv": 403,
+	}
+	for path, code := range cases {
+		err := c.mount(&MountEntry{
+			Path: path,
+			Type: "generic",
+		})
+		coded, ok := err.(logical.HTTPCodedError)
+		if !ok || coded.Code() != code {
+			t.Fatalf("path %s: bad: %v", path, err)
+		}
+	}
+
+	// Exactly at the depth limit is fine
+	if err := c.mount(&MountEntry{
+		Path: strings.Repeat("b/", maxMountPathDepth),
+		Type: "generic",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestCore_Unmount(t *testing.T) {
 	c, key, _ := TestCoreUnsealed(t)
 	err := c.unmount("secret")
@@ -224,6 +342,20 @@ func TestCore_Remount(t *testing.T) {
 	}
 }
 
+func TestCore_Remount_ProtectedDestination(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	for _, dst := range []string{"auth/foo", "sys/foo", "foo//bar"} {
+		if err := c.remount("secret", dst); err == nil {
+			t.Fatalf("remount to %s should fail", dst)
+		}
+	}
+
+	match := c.router.MatchingMount("secret/foo")
+	if match != "secret/" {
+		t.Fatalf("bad: %s", match)
+	}
+}
+
 func TestCore_Remount_Cleanup(t *testing.T) {
 	noop := &NoopBackend{}
 	c, _, root := TestCoreUnsealed(t)
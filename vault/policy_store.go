@@ -1,12 +1,14 @@
 package vault
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/vault/helper/compressutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -49,6 +51,19 @@ func (c *Core) setupPolicyStore() error {
 	// Create a sub-view
 	view := c.systemBarrierView.SubView(policySubPath)
 
+	// Refuse to unseal if a stored policy has the name of the built-in
+	// recovery policy, which would otherwise shadow it without notice
+	existing, err := view.Get(recoveryPolicyName)
+	if err != nil {
+		return errwrap.Wrapf("error checking for a stored recovery policy: {{err}}", err)
+	}
+	if existing != nil {
+		c.logger.Printf("[ERR] core: a stored policy is named '%s', which is reserved "+
+			"for the built-in recovery policy; rename it with an earlier version of "+
+			"Vault before upgrading", recoveryPolicyName)
+		return fmt.Errorf("stored policy '%s' conflicts with the built-in recovery policy", recoveryPolicyName)
+	}
+
 	// Create the policy store
 	c.policyStore = NewPolicyStore(view)
 
@@ -79,18 +94,32 @@ func (ps *PolicyStore) SetPolicy(p *Policy) error {
 	if p.Name == "root" {
 		return fmt.Errorf("cannot update root policy")
 	}
+	if p.Name == recoveryPolicyName {
+		return fmt.Errorf("cannot update %s policy", recoveryPolicyName)
+	}
 	if p.Name == "" {
 		return fmt.Errorf("policy name missing")
 	}
 
-	// Create the entry
-	entry, err := logical.StorageEntryJSON(p.Name, &PolicyEntry{
+	// Create the entry, compressed with snappy as policies are small and
+	// read on every cache miss
+	raw, err := json.Marshal(&PolicyEntry{
 		Version: 2,
 		Raw:     p.Raw,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create entry: %v", err)
 	}
+	raw, err = compressutil.Compress(raw, &compressutil.CompressionConfig{
+		Type: compressutil.CompressionTypeSnappy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compress entry: %v", err)
+	}
+	entry := &logical.StorageEntry{
+		Key:   p.Name,
+		Value: raw,
+	}
 	if err := ps.view.Put(entry); err != nil {
 		return fmt.Errorf("failed to persist policy: %v", err)
 	}
@@ -115,6 +144,17 @@ func (ps *PolicyStore) GetPolicy(name string) (*Policy, error) {
 		return p, nil
 	}
 
+	// The recovery policy is built in so that it cannot be widened
+	if name == recoveryPolicyName {
+		p, err := Parse(recoveryPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy: %v", err)
+		}
+		p.Name = recoveryPolicyName
+		ps.lru.Add(p.Name, p)
+		return p, nil
+	}
+
 	// Load the policy in
 	out, err := ps.view.Get(name)
 	if err != nil {
@@ -123,6 +163,9 @@ func (ps *PolicyStore) GetPolicy(name string) (*Policy, error) {
 	if out == nil {
 		return nil, nil
 	}
+	if out.Value, _, err = compressutil.Decompress(out.Value); err != nil {
+		return nil, fmt.Errorf("failed to decompress policy: %v", err)
+	}
 
 	// In Vault 0.1.X we stored the raw policy, but in
 	// Vault 0.2 we switch to the PolicyEntry
@@ -174,6 +217,9 @@ func (ps *PolicyStore) DeletePolicy(name string) error {
 	if name == "default" {
 		return fmt.Errorf("cannot delete default policy")
 	}
+	if name == recoveryPolicyName {
+		return fmt.Errorf("cannot delete %s policy", recoveryPolicyName)
+	}
 	if err := ps.view.Delete(name); err != nil {
 		return fmt.Errorf("failed to delete policy: %v", err)
 	}
@@ -153,3 +153,23 @@ func TestPolicyStore_v1Upgrade(t *testing.T) {
 		t.Fatalf("should enable glob")
 	}
 }
+
+func TestPolicyStore_RecoveryPolicyConflict(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+
+	// A policy stored under the reserved name, as by an earlier version
+	entry := &Entry{
+		Key:   systemBarrierPrefix + policySubPath + recoveryPolicyName,
+		Value: []byte(`path "*" { policy = "read" }`),
+	}
+	if err := c.barrier.Put(entry); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.Unseal(TestKeyCopy(key)); err == nil {
+		t.Fatalf("expected error")
+	}
+}
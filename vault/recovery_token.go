@@ -0,0 +1,297 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/shamir"
+)
+
+const (
+	// recoveryPolicyName is the name of the built-in policy attached to
+	// recovery tokens. Like the root policy it cannot be changed.
+	recoveryPolicyName = "recovery-operation"
+
+	// recoveryTokenPath is recorded as the path of recovery tokens,
+	// which is used for audit trails and lease revocation
+	recoveryTokenPath = "sys/generate-recovery-token"
+
+	// recoveryTokenTTL is how long a recovery token is valid for. It is
+	// meant for a single recovery action or drill, not for standing use.
+	recoveryTokenTTL = 30 * time.Minute
+
+	// recoveryPolicy grants the recovery endpoints and nothing else
+	recoveryPolicy = `
+path "sys/raw" {
+    capabilities = ["list", "sudo"]
+}
+
+path "sys/raw/*" {
+    capabilities = ["create", "read", "update", "delete", "list", "sudo"]
+}
+
+path "sys/storage/snapshot" {
+    capabilities = ["read", "update", "sudo"]
+}
+
+path "auth/token/lookup-self" {
+    capabilities = ["read"]
+}
+
+path "auth/token/revoke-self" {
+    capabilities = ["update"]
+}
+`
+)
+
+// RecoveryTokenConfig holds the state of a recovery token generation
+type RecoveryTokenConfig struct {
+	Nonce  string
+	PGPKey string
+}
+
+// RecoveryTokenResult is returned once enough key shares have been
+// provided. If a PGP key was given, EncodedToken is the token encrypted
+// with it and hex encoded, otherwise it is the token itself.
+type RecoveryTokenResult struct {
+	EncodedToken   string
+	PGPFingerprint string
+	TTL            time.Duration
+}
+
+// RecoveryTokenProgress is used to return the number of shares provided
+func (c *Core) RecoveryTokenProgress() (int, error) {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return 0, ErrSealed
+	}
+	if c.standby {
+		return 0, ErrStandby
+	}
+
+	c.recoveryTokenLock.Lock()
+	defer c.recoveryTokenLock.Unlock()
+	return len(c.recoveryTokenProgress), nil
+}
+
+// RecoveryTokenConfiguration is used to read the configuration of the
+// recovery token generation in progress, if any
+func (c *Core) RecoveryTokenConfiguration() (*RecoveryTokenConfig, error) {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return nil, ErrSealed
+	}
+	if c.standby {
+		return nil, ErrStandby
+	}
+
+	c.recoveryTokenLock.Lock()
+	defer c.recoveryTokenLock.Unlock()
+
+	var conf *RecoveryTokenConfig
+	if c.recoveryTokenConfig != nil {
+		conf = new(RecoveryTokenConfig)
+		*conf = *c.recoveryTokenConfig
+	}
+	return conf, nil
+}
+
+// RecoveryTokenInit is used to start generating a recovery token. The
+// token is encrypted with the PGP key if one is given.
+func (c *Core) RecoveryTokenInit(pgpKey string) error {
+	if pgpKey != "" {
+		if _, err := pgpkeys.GetFingerprints([]string{pgpKey}, nil); err != nil {
+			return fmt.Errorf("invalid PGP key: %v", err)
+		}
+	}
+
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return ErrSealed
+	}
+	if c.standby {
+		return ErrStandby
+	}
+
+	c.recoveryTokenLock.Lock()
+	defer c.recoveryTokenLock.Unlock()
+
+	// Prevent multiple concurrent attempts
+	if c.recoveryTokenConfig != nil {
+		return fmt.Errorf("recovery token generation already in progress")
+	}
+
+	nonce, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	c.recoveryTokenConfig = &RecoveryTokenConfig{
+		Nonce:  nonce,
+		PGPKey: pgpKey,
+	}
+
+	c.logger.Printf("[INFO] core: recovery token generation initialized (nonce: %s)", nonce)
+	return nil
+}
+
+// RecoveryTokenUpdate is used to provide a key share. Once the threshold
+// is reached and the master key verified, a recovery token is created.
+func (c *Core) RecoveryTokenUpdate(key []byte, nonce string) (*RecoveryTokenResult, error) {
+	// Verify the key length
+	min, max := c.barrier.KeyLength()
+	max += shamir.ShareOverhead
+	if len(key) < min {
+		return nil, &ErrInvalidKey{fmt.Sprintf("key is shorter than minimum %d bytes", min)}
+	}
+	if len(key) > max {
+		return nil, &ErrInvalidKey{fmt.Sprintf("key is longer than maximum %d bytes", max)}
+	}
+
+	// Get the seal configuration
+	config, err := c.SealConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the barrier is initialized
+	if config == nil {
+		return nil, ErrNotInit
+	}
+
+	// Ensure we are already unsealed
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return nil, ErrSealed
+	}
+	if c.standby {
+		return nil, ErrStandby
+	}
+
+	c.recoveryTokenLock.Lock()
+	defer c.recoveryTokenLock.Unlock()
+
+	// Ensure a generation is in progress
+	if c.recoveryTokenConfig == nil {
+		return nil, fmt.Errorf("no recovery token generation in progress")
+	}
+
+	if nonce != c.recoveryTokenConfig.Nonce {
+		return nil, fmt.Errorf("incorrect nonce supplied; nonce for this recovery token generation is %s",
+			c.recoveryTokenConfig.Nonce)
+	}
+
+	// Check if we already have this piece
+	for _, existing := range c.recoveryTokenProgress {
+		if bytes.Equal(existing, key) {
+			return nil, nil
+		}
+	}
+
+	// Store this key
+	c.recoveryTokenProgress = append(c.recoveryTokenProgress, key)
+
+	// Check if we don't have enough keys to unlock
+	if len(c.recoveryTokenProgress) < config.SecretThreshold {
+		c.logger.Printf("[DEBUG] core: cannot generate recovery token, have %d of %d keys",
+			len(c.recoveryTokenProgress), config.SecretThreshold)
+		return nil, nil
+	}
+
+	// Recover the master key
+	var masterKey []byte
+	if config.SecretThreshold == 1 {
+		masterKey = c.recoveryTokenProgress[0]
+		c.recoveryTokenProgress = nil
+	} else {
+		masterKey, err = shamir.Combine(c.recoveryTokenProgress)
+		c.recoveryTokenProgress = nil
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute master key: %v", err)
+		}
+	}
+
+	// Verify the master key
+	if err := c.barrier.VerifyMaster(masterKey); err != nil {
+		c.logger.Printf("[ERR] core: recovery token generation aborted, master key verification failed: %v", err)
+		return nil, err
+	}
+
+	// Create the token, which expires on its own and cannot be renewed
+	te := &TokenEntry{
+		Policies:     []string{recoveryPolicyName},
+		Path:         recoveryTokenPath,
+		DisplayName:  "recovery",
+		CreationTime: time.Now().Unix(),
+		TTL:          recoveryTokenTTL,
+	}
+	if err := c.tokenStore.create(te); err != nil {
+		c.logger.Printf("[ERR] core: failed to create recovery token: %v", err)
+		return nil, ErrInternalError
+	}
+	auth := &logical.Auth{
+		ClientToken: te.ID,
+		Policies:    te.Policies,
+		DisplayName: te.DisplayName,
+		LeaseOptions: logical.LeaseOptions{
+			TTL:       recoveryTokenTTL,
+			IssueTime: time.Now().UTC(),
+		},
+	}
+	if err := c.expiration.RegisterAuth(te.Path, auth); err != nil {
+		c.logger.Printf("[ERR] core: failed to register recovery token lease: %v", err)
+		c.tokenStore.Revoke(te.ID)
+		return nil, ErrInternalError
+	}
+
+	result := &RecoveryTokenResult{
+		EncodedToken: te.ID,
+		TTL:          recoveryTokenTTL,
+	}
+	if c.recoveryTokenConfig.PGPKey != "" {
+		fingerprints, encrypted, err := pgpkeys.EncryptShares(
+			[][]byte{[]byte(te.ID)}, []string{c.recoveryTokenConfig.PGPKey})
+		if err != nil {
+			c.logger.Printf("[ERR] core: failed to encrypt recovery token: %v", err)
+			c.tokenStore.Revoke(te.ID)
+			return nil, ErrInternalError
+		}
+		result.EncodedToken = hex.EncodeToString(encrypted[0])
+		result.PGPFingerprint = fingerprints[0]
+	}
+
+	c.logger.Printf("[INFO] core: recovery token generated (nonce: %s)", c.recoveryTokenConfig.Nonce)
+
+	// Done!
+	c.recoveryTokenProgress = nil
+	c.recoveryTokenConfig = nil
+	return result, nil
+}
+
+// RecoveryTokenCancel is used to cancel an in progress generation
+func (c *Core) RecoveryTokenCancel() error {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return ErrSealed
+	}
+	if c.standby {
+		return ErrStandby
+	}
+
+	c.recoveryTokenLock.Lock()
+	defer c.recoveryTokenLock.Unlock()
+
+	// Clear any progress or config
+	c.recoveryTokenConfig = nil
+	c.recoveryTokenProgress = nil
+	return nil
+}
@@ -0,0 +1,149 @@
+package vault
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_RecoveryToken_Lifecycle(t *testing.T) {
+	c, master, _ := TestCoreUnsealed(t)
+
+	// Verify update not allowed
+	if _, err := c.RecoveryTokenUpdate(master, ""); err == nil {
+		t.Fatalf("no generation in progress")
+	}
+
+	// Should be no config
+	conf, err := c.RecoveryTokenConfiguration()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf != nil {
+		t.Fatalf("bad: %v", conf)
+	}
+
+	// Cancel should be idempotent
+	if err := c.RecoveryTokenCancel(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.RecoveryTokenInit(""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.RecoveryTokenInit(""); err == nil {
+		t.Fatalf("should not allow concurrent generations")
+	}
+
+	conf, err = c.RecoveryTokenConfiguration()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf == nil || conf.Nonce == "" {
+		t.Fatalf("bad: %v", conf)
+	}
+
+	// Cancel should be clear
+	if err := c.RecoveryTokenCancel(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	conf, err = c.RecoveryTokenConfiguration()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf != nil {
+		t.Fatalf("bad: %v", conf)
+	}
+}
+
+func TestCore_RecoveryToken_Scope(t *testing.T) {
+	c, master, root := TestCoreUnsealed(t)
+
+	if err := c.RecoveryTokenInit(""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	conf, err := c.RecoveryTokenConfiguration()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A wrong nonce is rejected
+	if _, err := c.RecoveryTokenUpdate(master, "bogus"); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	result, err := c.RecoveryTokenUpdate(master, conf.Nonce)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if result == nil || result.EncodedToken == "" || result.TTL != recoveryTokenTTL {
+		t.Fatalf("bad: %#v", result)
+	}
+	token := result.EncodedToken
+
+	// The generation is done
+	if conf, _ := c.RecoveryTokenConfiguration(); conf != nil {
+		t.Fatalf("bad: %v", conf)
+	}
+
+	// Raw storage is allowed
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/raw/core/mounts",
+		ClientToken: token,
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["value"] == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// So are snapshots
+	var buf bytes.Buffer
+	if err := c.Snapshot(token, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Nothing else is
+	for _, req := range []*logical.Request{
+		&logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "secret/foo",
+		},
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/policy/recovery-operation",
+			Data: map[string]interface{}{
+				"rules": `path "*" { policy = "sudo" }`,
+			},
+		},
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "auth/token/create",
+		},
+	} {
+		req.ClientToken = token
+		if _, err := c.HandleRequest(req); err != logical.ErrPermissionDenied {
+			t.Fatalf("%s: err: %v", req.Path, err)
+		}
+	}
+
+	// Not even with a root token can the policy be widened
+	req = &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sys/policy/recovery-operation",
+		Data: map[string]interface{}{
+			"rules": `path "*" { policy = "sudo" }`,
+		},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err == nil {
+		t.Fatalf("expected error")
+	}
+	if err := c.policyStore.DeletePolicy(recoveryPolicyName); err == nil {
+		t.Fatalf("expected error")
+	}
+}
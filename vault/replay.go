@@ -0,0 +1,130 @@
+package vault
+
+import (
+	"container/heap"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReplayParamsMissing is returned when replay protection is enabled
+	// and a protected request lacks a nonce or timestamp.
+	ErrReplayParamsMissing = errors.New("missing or invalid request nonce or timestamp")
+
+	// ErrReplayedRequest is returned when replay protection is enabled and
+	// a protected request reuses a nonce or has a timestamp outside of the
+	// allowed window.
+	ErrReplayedRequest = errors.New("request has been replayed or has expired")
+)
+
+// replayProtectedPaths are the unauthenticated paths, other than login
+// paths of credential backends, that are checked for replays.
+var replayProtectedPaths = []string{
+	"sys/unseal",
+	"sys/rekey/update",
+	"sys/generate-recovery-token/update",
+}
+
+// replayCache remembers the nonces seen within the replay window.
+type replayCache struct {
+	window time.Duration
+
+	l       sync.Mutex
+	seen    map[string]time.Time
+	expires replayExpiryHeap
+}
+
+func newReplayCache(window time.Duration) *replayCache {
+	return &replayCache{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// check validates the timestamp against the window and records the
+// nonce, returning ErrReplayedRequest if either check fails.
+func (r *replayCache) check(nonce string, timestamp time.Time, now time.Time) error {
+	if timestamp.Before(now.Add(-r.window)) || timestamp.After(now.Add(r.window)) {
+		return ErrReplayedRequest
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	// Expire old nonces, soonest first. A nonce must be kept for long
+	// enough that a request with the same timestamp can no longer pass
+	// the check above.
+	for len(r.expires) > 0 && now.After(r.expires[0].expires) {
+		delete(r.seen, heap.Pop(&r.expires).(replayExpiry).nonce)
+	}
+
+	if _, ok := r.seen[nonce]; ok {
+		return ErrReplayedRequest
+	}
+	expires := timestamp.Add(r.window)
+	r.seen[nonce] = expires
+	heap.Push(&r.expires, replayExpiry{nonce: nonce, expires: expires})
+	return nil
+}
+
+// replayExpiry is a nonce and when it may be forgotten
+type replayExpiry struct {
+	nonce   string
+	expires time.Time
+}
+
+// replayExpiryHeap orders nonces by expiry, soonest first, so that
+// expiring them doesn't require scanning every nonce seen
+type replayExpiryHeap []replayExpiry
+
+func (h replayExpiryHeap) Len() int           { return len(h) }
+func (h replayExpiryHeap) Less(i, j int) bool { return h[i].expires.Before(h[j].expires) }
+func (h replayExpiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *replayExpiryHeap) Push(x interface{}) {
+	*h = append(*h, x.(replayExpiry))
+}
+
+func (h *replayExpiryHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// ReplayProtected returns whether requests to the given API path are
+// subject to replay protection. This is always false if replay protection
+// is not enabled.
+func (c *Core) ReplayProtected(path string) bool {
+	if c.replay == nil {
+		return false
+	}
+	for _, p := range replayProtectedPaths {
+		if path == p {
+			return true
+		}
+	}
+	return c.router.LoginPath(path)
+}
+
+// CheckReplay validates the nonce and timestamp, in seconds since the
+// Unix epoch, given with a request to a replay protected path.
+func (c *Core) CheckReplay(nonce, timestamp string) error {
+	if c.replay == nil {
+		return nil
+	}
+
+	nonce = strings.TrimSpace(nonce)
+	if nonce == "" {
+		return ErrReplayParamsMissing
+	}
+	secs, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return ErrReplayParamsMissing
+	}
+
+	return c.replay.check(nonce, time.Unix(secs, 0), time.Now())
+}
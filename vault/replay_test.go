@@ -0,0 +1,71 @@
+package vault
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReplayCache(t *testing.T) {
+	r := newReplayCache(time.Minute)
+	now := time.Now()
+
+	if err := r.check("foo", now, now); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := r.check("foo", now, now); err != ErrReplayedRequest {
+		t.Fatalf("err: %v", err)
+	}
+	if err := r.check("bar", now.Add(-2*time.Minute), now); err != ErrReplayedRequest {
+		t.Fatalf("err: %v", err)
+	}
+	if err := r.check("bar", now.Add(2*time.Minute), now); err != ErrReplayedRequest {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Once the timestamp is outside of the window the nonce is forgotten
+	later := now.Add(time.Minute + time.Second)
+	if err := r.check("baz", later, later); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := r.seen["foo"]; ok {
+		t.Fatalf("bad: %#v", r.seen)
+	}
+	if len(r.seen) != 1 || len(r.expires) != 1 {
+		t.Fatalf("bad: %#v %#v", r.seen, r.expires)
+	}
+}
+
+func TestCore_CheckReplay(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	// Disabled by default
+	if c.ReplayProtected("sys/unseal") {
+		t.Fatalf("bad: replay protection enabled")
+	}
+	if err := c.CheckReplay("", ""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c.replay = newReplayCache(time.Minute)
+	if !c.ReplayProtected("sys/unseal") {
+		t.Fatalf("bad: sys/unseal not protected")
+	}
+	if c.ReplayProtected("secret/foo") {
+		t.Fatalf("bad: secret/foo protected")
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := c.CheckReplay("", ts); err != ErrReplayParamsMissing {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.CheckReplay("foo", "bar"); err != ErrReplayParamsMissing {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.CheckReplay("foo", ts); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.CheckReplay("foo", ts); err != ErrReplayedRequest {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package vault
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// requestCountersPath is the prefix used to store the request
+	// counters, one entry per month
+	requestCountersPath = "core/counters/requests/"
+
+	// requestCountersRetention is the number of months of request
+	// counters kept, including the current one
+	requestCountersRetention = 24
+
+	// requestCountersMonthFormat formats the start of a month as the key
+	// of its counters
+	requestCountersMonthFormat = "2006-01"
+)
+
+var (
+	// requestCountersPersistInterval is how often the counters are
+	// written to storage. Requests counted since the last write are lost
+	// if the active node fails.
+	requestCountersPersistInterval = time.Minute
+)
+
+// RequestCounts holds the number of requests handled during a month
+type RequestCounts struct {
+	// StartTime is the start of the month, in UTC
+	StartTime time.Time `json:"start_time"`
+
+	// Total is the number of requests
+	Total uint64 `json:"total"`
+
+	// ByMount is the number of requests per mount path
+	ByMount map[string]uint64 `json:"by_mount"`
+
+	// ByAuthMethod is the number of requests per mount path of the
+	// credential backend that issued the token used, or that the login
+	// was made against
+	ByAuthMethod map[string]uint64 `json:"by_auth_method"`
+}
+
+func newRequestCounts(startTime time.Time) *RequestCounts {
+	return &RequestCounts{
+		StartTime:    startTime,
+		ByMount:      make(map[string]uint64),
+		ByAuthMethod: make(map[string]uint64),
+	}
+}
+
+func (r *RequestCounts) clone() *RequestCounts {
+	c := newRequestCounts(r.StartTime)
+	c.Total = r.Total
+	for k, v := range r.ByMount {
+		c.ByMount[k] = v
+	}
+	for k, v := range r.ByAuthMethod {
+		c.ByAuthMethod[k] = v
+	}
+	return c
+}
+
+// requestCounters counts the requests handled by the core. It holds the
+// counts of the current month, and of any previous month that has not
+// been persisted since the month ended.
+type requestCounters struct {
+	l      sync.Mutex
+	months map[string]*RequestCounts
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func newRequestCounters() *requestCounters {
+	return &requestCounters{
+		months: make(map[string]*RequestCounts),
+	}
+}
+
+// monthStart returns the start of the month containing t, in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// increment counts a request against the mount and auth method, either
+// of which may be empty
+func (r *requestCounters) increment(now time.Time, mount, authMethod string) {
+	start := monthStart(now)
+	key := start.Format(requestCountersMonthFormat)
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	counts, ok := r.months[key]
+	if !ok {
+		counts = newRequestCounts(start)
+		r.months[key] = counts
+	}
+	counts.Total++
+	if mount != "" {
+		counts.ByMount[mount]++
+	}
+	if authMethod != "" {
+		counts.ByAuthMethod[authMethod]++
+	}
+}
+
+// snapshot returns copies of the counts held in memory, keyed by month
+func (r *requestCounters) snapshot() map[string]*RequestCounts {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	months := make(map[string]*RequestCounts, len(r.months))
+	for k, v := range r.months {
+		months[k] = v.clone()
+	}
+	return months
+}
+
+// countRequest records a request made with a token issued through the
+// given path, which is empty if there is no valid token
+func (c *Core) countRequest(req *logical.Request, authPath string) {
+	var authMethod string
+	if authPath != "" {
+		authMethod = c.router.MatchingMount(authPath)
+	}
+	c.requestCounters.increment(time.Now(), c.router.MatchingMount(req.Path), authMethod)
+}
+
+// setupRequestCounters is invoked after we've loaded the mount table to
+// restore the counters of the current month and start persisting them
+func (c *Core) setupRequestCounters() error {
+	start := monthStart(time.Now())
+	key := start.Format(requestCountersMonthFormat)
+	counts, err := c.loadRequestCounts(key)
+	if err != nil {
+		return err
+	}
+	if counts == nil {
+		counts = newRequestCounts(start)
+	}
+
+	r := c.requestCounters
+	r.l.Lock()
+	r.months = map[string]*RequestCounts{key: counts}
+	r.stopCh = make(chan struct{})
+	r.doneCh = make(chan struct{})
+	r.l.Unlock()
+
+	go c.runRequestCounters(r.stopCh, r.doneCh)
+	return nil
+}
+
+// stopRequestCounters stops the persistence of the request counters
+// after a final write, before the barrier is sealed
+func (c *Core) stopRequestCounters() error {
+	return c.haltRequestCounters(true)
+}
+
+// discardRequestCounters stops the persistence of the request counters
+// and forgets the counts held in memory without writing them. It is used
+// before the storage is replaced, so that the counters in the new storage
+// are not overwritten when sealing.
+func (c *Core) discardRequestCounters() {
+	c.haltRequestCounters(false)
+}
+
+// haltRequestCounters stops the persistence of the request counters,
+// optionally after a final write
+func (c *Core) haltRequestCounters(persist bool) error {
+	r := c.requestCounters
+	r.l.Lock()
+	stopCh, doneCh := r.stopCh, r.doneCh
+	r.stopCh, r.doneCh = nil, nil
+	r.l.Unlock()
+
+	if stopCh == nil {
+		return nil
+	}
+	close(stopCh)
+	<-doneCh
+
+	var err error
+	if persist {
+		err = c.persistRequestCounters()
+	}
+
+	r.l.Lock()
+	r.months = make(map[string]*RequestCounts)
+	r.l.Unlock()
+	return err
+}
+
+// runRequestCounters persists the counters periodically until stopped
+func (c *Core) runRequestCounters(stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+	for {
+		select {
+		case <-time.After(requestCountersPersistInterval):
+			c.persistRequestCounters()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// persistRequestCounters writes the counters held in memory to storage,
+// forgets those of months that have ended and removes months past the
+// retention period
+func (c *Core) persistRequestCounters() error {
+	months := c.requestCounters.snapshot()
+	for key, counts := range months {
+		raw, err := json.Marshal(counts)
+		if err != nil {
+			c.logger.Printf("[ERR] core: failed to encode request counters: %v", err)
+			return err
+		}
+		entry := &Entry{
+			Key:   requestCountersPath + key,
+			Value: raw,
+		}
+		if err := c.barrier.Put(entry); err != nil {
+			c.logger.Printf("[ERR] core: failed to persist request counters: %v", err)
+			return err
+		}
+	}
+
+	current := monthStart(time.Now()).Format(requestCountersMonthFormat)
+	c.requestCounters.l.Lock()
+	for key := range months {
+		if key != current {
+			delete(c.requestCounters.months, key)
+		}
+	}
+	c.requestCounters.l.Unlock()
+
+	keys, err := c.barrier.List(requestCountersPath)
+	if err != nil {
+		c.logger.Printf("[ERR] core: failed to list request counters: %v", err)
+		return err
+	}
+	sort.Strings(keys)
+	for len(keys) > requestCountersRetention {
+		if err := c.barrier.Delete(requestCountersPath + keys[0]); err != nil {
+			c.logger.Printf("[ERR] core: failed to delete request counters: %v", err)
+			return err
+		}
+		keys = keys[1:]
+	}
+	return nil
+}
+
+// loadRequestCounts reads the counters of the given month from storage
+func (c *Core) loadRequestCounts(key string) (*RequestCounts, error) {
+	raw, err := c.barrier.Get(requestCountersPath + key)
+	if err != nil {
+		c.logger.Printf("[ERR] core: failed to read request counters: %v", err)
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	counts := new(RequestCounts)
+	if err := json.Unmarshal(raw.Value, counts); err != nil {
+		c.logger.Printf("[ERR] core: failed to decode request counters: %v", err)
+		return nil, err
+	}
+	if counts.ByMount == nil {
+		counts.ByMount = make(map[string]uint64)
+	}
+	if counts.ByAuthMethod == nil {
+		counts.ByAuthMethod = make(map[string]uint64)
+	}
+	return counts, nil
+}
+
+// RequestCounters returns the request counts of the retained months,
+// oldest first. The current month includes requests that have not been
+// persisted yet.
+func (c *Core) RequestCounters() ([]*RequestCounts, error) {
+	keys, err := c.barrier.List(requestCountersPath)
+	if err != nil {
+		c.logger.Printf("[ERR] core: failed to list request counters: %v", err)
+		return nil, err
+	}
+
+	months := c.requestCounters.snapshot()
+	for _, key := range keys {
+		if _, ok := months[key]; ok || strings.HasSuffix(key, "/") {
+			continue
+		}
+		counts, err := c.loadRequestCounts(key)
+		if err != nil {
+			return nil, err
+		}
+		if counts != nil {
+			months[key] = counts
+		}
+	}
+
+	keys = make([]string, 0, len(months))
+	for key := range months {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]*RequestCounts, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, months[key])
+	}
+	return result, nil
+}
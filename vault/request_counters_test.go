@@ -0,0 +1,98 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_RequestCounters(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+
+	for i := 0; i < 3; i++ {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+			Data: map[string]interface{}{
+				"foo": "bar",
+			},
+			ClientToken: root,
+		}
+		if _, err := c.HandleRequest(req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Requests with an invalid token are counted too
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "secret/foo",
+		ClientToken: "bogus",
+	}
+	c.HandleRequest(req)
+
+	check := func() {
+		months, err := c.RequestCounters()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(months) != 1 {
+			t.Fatalf("bad: %#v", months)
+		}
+		m := months[0]
+		if !m.StartTime.Equal(monthStart(time.Now())) {
+			t.Fatalf("bad: %v", m.StartTime)
+		}
+		if m.Total != 4 || m.ByMount["secret/"] != 4 || m.ByAuthMethod["auth/token/"] != 3 {
+			t.Fatalf("bad: %#v", m)
+		}
+	}
+	check()
+
+	// The counters survive a seal
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.Unseal(TestKeyCopy(key)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	check()
+}
+
+func TestCore_RequestCounters_Persist(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	// Months past the retention period are removed, and months that have
+	// ended are forgotten once persisted
+	now := monthStart(time.Now())
+	for i := 0; i < requestCountersRetention+2; i++ {
+		c.requestCounters.increment(now.AddDate(0, -i, 0), "secret/", "")
+	}
+	if err := c.persistRequestCounters(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if months := c.requestCounters.snapshot(); len(months) != 1 {
+		t.Fatalf("bad: %#v", months)
+	}
+
+	months, err := c.RequestCounters()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(months) != requestCountersRetention {
+		t.Fatalf("bad: %d", len(months))
+	}
+	oldest := now.AddDate(0, 1-requestCountersRetention, 0)
+	if !months[0].StartTime.Equal(oldest) {
+		t.Fatalf("bad: %v", months[0].StartTime)
+	}
+	for _, m := range months {
+		if m.Total != 1 || m.ByMount["secret/"] != 1 {
+			t.Fatalf("bad: %s %#v", m.StartTime, m)
+		}
+	}
+	if newest := months[len(months)-1].StartTime; !newest.Equal(now) {
+		t.Fatalf("bad: %v", newest)
+	}
+}
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"fmt"
+	"net/textproto"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +36,7 @@ type routeEntry struct {
 	storageView *BarrierView
 	rootPaths   *radix.Tree
 	loginPaths  *radix.Tree
+	connPaths   *radix.Tree
 }
 
 // SaltID is used to apply a salt and hash to an ID to make sure its not reversable
@@ -67,6 +69,7 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 		storageView: storageView,
 		rootPaths:   pathsToRadix(paths.Root),
 		loginPaths:  pathsToRadix(paths.Unauthenticated),
+		connPaths:   pathsToRadix(paths.Connection),
 	}
 	r.root.Insert(prefix, re)
 
@@ -138,6 +141,18 @@ func (r *Router) MatchingMount(path string) string {
 	return mount
 }
 
+// UpdateMountConfig changes the config of the mount entry used for a path
+// while holding the router lock, so that requests being routed see either
+// the old or the new config
+func (r *Router) UpdateMountConfig(path string, update func(*MountConfig)) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	_, raw, ok := r.root.LongestPrefix(path)
+	if ok && raw.(*routeEntry).mountEntry != nil {
+		update(&raw.(*routeEntry).mountEntry.Config)
+	}
+}
+
 // MatchingView returns the view used for a path
 func (r *Router) MatchingStorageView(path string) *BarrierView {
 	r.l.RLock()
@@ -204,6 +219,13 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 		req.Path += "/"
 		mount, raw, ok = r.root.LongestPrefix(req.Path)
 	}
+
+	// Read the headers the mount allows while holding the lock, since
+	// tuning the mount may change them
+	var allowedHeaders []string
+	if ok && raw.(*routeEntry).mountEntry != nil {
+		allowedHeaders = raw.(*routeEntry).mountEntry.Config.PassthroughRequestHeaders
+	}
 	r.l.RUnlock()
 	if !ok {
 		return logical.ErrorResponse(fmt.Sprintf("no handler for route '%s'", req.Path)), false, false, logical.ErrUnsupportedPath
@@ -222,8 +244,10 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 		}
 	}
 
-	// Determine if this path is an unauthenticated path before we modify it
+	// Determine if this path is an unauthenticated path, or otherwise needs
+	// the connection, before we modify it
 	loginPath := r.LoginPath(req.Path)
+	connPath := loginPath || r.ConnectionPath(req.Path)
 
 	// Adjust the path to exclude the routing prefix
 	original := req.Path
@@ -248,12 +272,17 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 		req.ClientToken = re.SaltID(req.ClientToken)
 	}
 
-	// If the request is not a login path, then clear the connection
+	// If the request is not a login path, or one that asked for the
+	// connection, then clear the connection
 	originalConn := req.Connection
-	if !loginPath {
+	if !connPath {
 		req.Connection = nil
 	}
 
+	// Only pass through the headers the mount allows
+	originalHeaders := req.Headers
+	req.Headers = filterHeaders(originalHeaders, allowedHeaders)
+
 	// Reset the request before returning
 	defer func() {
 		req.Path = original
@@ -261,6 +290,7 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 		req.Connection = originalConn
 		req.Storage = nil
 		req.ClientToken = clientToken
+		req.Headers = originalHeaders
 	}()
 
 	// Invoke the backend
@@ -273,6 +303,26 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 	}
 }
 
+// filterHeaders returns the headers named in allowed. The client token
+// is never passed through.
+func filterHeaders(headers map[string][]string, allowed []string) map[string][]string {
+	if len(headers) == 0 || len(allowed) == 0 {
+		return nil
+	}
+
+	result := make(map[string][]string)
+	for _, name := range allowed {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if name == "X-Vault-Token" {
+			continue
+		}
+		if values, ok := headers[name]; ok {
+			result[name] = values
+		}
+	}
+	return result
+}
+
 // RootPath checks if the given path requires root privileges
 func (r *Router) RootPath(path string) bool {
 	r.l.RLock()
@@ -331,6 +381,36 @@ func (r *Router) LoginPath(path string) bool {
 	return match == remain
 }
 
+// ConnectionPath checks if the given path is passed the connection of the
+// request, other than because it is used for logins
+func (r *Router) ConnectionPath(path string) bool {
+	r.l.RLock()
+	mount, raw, ok := r.root.LongestPrefix(path)
+	r.l.RUnlock()
+	if !ok {
+		return false
+	}
+	re := raw.(*routeEntry)
+
+	// Trim to get remaining path
+	remain := strings.TrimPrefix(path, mount)
+
+	// Check the connPaths of this backend
+	match, raw, ok := re.connPaths.LongestPrefix(remain)
+	if !ok {
+		return false
+	}
+	prefixMatch := raw.(bool)
+
+	// Handle the prefix match case
+	if prefixMatch {
+		return strings.HasPrefix(remain, match)
+	}
+
+	// Handle the exact match case
+	return match == remain
+}
+
 // pathsToRadix converts a the mapping of special paths to a mapping
 // of special paths to radix trees.
 func pathsToRadix(paths []string) *radix.Tree {
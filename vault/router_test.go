@@ -2,6 +2,7 @@ package vault
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -19,6 +20,7 @@ type NoopBackend struct {
 	Paths    []string
 	Requests []*logical.Request
 	Response *logical.Response
+	Err      error
 }
 
 func (n *NoopBackend) HandleRequest(req *logical.Request) (*logical.Response, error) {
@@ -32,7 +34,7 @@ func (n *NoopBackend) HandleRequest(req *logical.Request) (*logical.Response, er
 		return nil, fmt.Errorf("missing view")
 	}
 
-	return n.Response, nil
+	return n.Response, n.Err
 }
 
 func (n *NoopBackend) HandleExistenceCheck(req *logical.Request) (bool, bool, error) {
@@ -272,6 +274,54 @@ func TestRouter_LoginPath(t *testing.T) {
 	}
 }
 
+func TestRouter_PassthroughHeaders(t *testing.T) {
+	r := NewRouter()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &NoopBackend{}
+	me := &MountEntry{
+		UUID: meUUID,
+		Config: MountConfig{
+			PassthroughRequestHeaders: []string{"x-custom-header", "X-Vault-Token"},
+		},
+	}
+	err = r.Mount(n, "prod/aws/", me, view)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	headers := map[string][]string{
+		"X-Custom-Header": []string{"foo"},
+		"X-Other-Header":  []string{"bar"},
+		"X-Vault-Token":   []string{"secret"},
+	}
+	req := &logical.Request{
+		Path:    "prod/aws/foo",
+		Headers: headers,
+	}
+	if _, err := r.Route(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Only the allowed header reaches the backend, never the token
+	expected := map[string][]string{
+		"X-Custom-Header": []string{"foo"},
+	}
+	if !reflect.DeepEqual(n.Requests[0].Headers, expected) {
+		t.Fatalf("bad: %#v", n.Requests[0].Headers)
+	}
+
+	// The request is restored afterwards
+	if !reflect.DeepEqual(req.Headers, headers) {
+		t.Fatalf("bad: %#v", req.Headers)
+	}
+}
+
 func TestRouter_Taint(t *testing.T) {
 	r := NewRouter()
 	_, barrier, _ := mockBarrier(t)
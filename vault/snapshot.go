@@ -0,0 +1,387 @@
+package vault
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// snapshotVersion is the version of the snapshot format
+	snapshotVersion = 1
+
+	// snapshotPath is the path checked against the ACL of the token
+	// taking or restoring a snapshot
+	snapshotPath = "sys/storage/snapshot"
+)
+
+var (
+	// SnapshotMaxSize is the largest snapshot that can be restored, in
+	// bytes. It applies to the uncompressed snapshot, which is held in
+	// memory while it is verified.
+	SnapshotMaxSize int64 = 512 << 20
+)
+
+// snapshotExcludedPaths are never saved or restored, as they coordinate
+// the running instances rather than hold data
+var snapshotExcludedPaths = []string{
+	coreLockPath,
+	coreLeaderPrefix,
+}
+
+// A snapshot is a gzip compressed stream of JSON values: a header, an
+// entry for each key of the physical backend, and a trailer holding a
+// checksum of the entries. Values are copied as stored, so everything
+// behind the barrier stays encrypted.
+type snapshotHeader struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Entries int       `json:"entries"`
+}
+
+type snapshotEntry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type snapshotTrailer struct {
+	SHA256 string `json:"sha256"`
+}
+
+// Snapshot writes a snapshot of the physical backend to w. Requests are
+// paused while it is taken so that it is consistent. A root token is
+// required.
+func (c *Core) Snapshot(token string, w io.Writer) error {
+	defer metrics.MeasureSince([]string{"core", "snapshot", "save"}, time.Now())
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+	if c.sealed {
+		return ErrSealed
+	}
+	if c.standby {
+		return ErrStandby
+	}
+
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        snapshotPath,
+		ClientToken: token,
+	}
+	auth, err := c.checkSnapshotToken(req)
+	if err != nil {
+		return err
+	}
+
+	err = c.writeSnapshot(w)
+	if auditErr := c.auditSnapshotResponse(auth, req, err); auditErr != nil {
+		return auditErr
+	}
+	return err
+}
+
+// writeSnapshot writes every key of the physical backend to w
+func (c *Core) writeSnapshot(w io.Writer) error {
+	keys, err := collectPhysicalKeys(c.physical, "")
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %v", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	if err := enc.Encode(&snapshotHeader{
+		Version: snapshotVersion,
+		Created: time.Now().UTC(),
+		Entries: len(keys),
+	}); err != nil {
+		return err
+	}
+
+	sum := sha256.New()
+	for _, key := range keys {
+		pe, err := c.physical.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to read '%s': %v", key, err)
+		}
+		if pe == nil {
+			return fmt.Errorf("'%s' disappeared while taking the snapshot", key)
+		}
+
+		entry := &snapshotEntry{Key: pe.Key, Value: pe.Value}
+		hashSnapshotEntry(sum, entry)
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.Encode(&snapshotTrailer{
+		SHA256: hex.EncodeToString(sum.Sum(nil)),
+	}); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	c.logger.Printf("[INFO] core: saved snapshot of %d entries", len(keys))
+	return nil
+}
+
+// RestoreSnapshot replaces the contents of the physical backend with the
+// snapshot read from r, then seals the Vault. It must be unsealed with
+// the keys that were in use when the snapshot was taken. The snapshot is
+// verified in full before anything is written. A root token is required.
+func (c *Core) RestoreSnapshot(token string, r io.Reader) error {
+	defer metrics.MeasureSince([]string{"core", "snapshot", "restore"}, time.Now())
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+	if c.sealed {
+		return ErrSealed
+	}
+	if c.standby {
+		return ErrStandby
+	}
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        snapshotPath,
+		ClientToken: token,
+	}
+	auth, err := c.checkSnapshotToken(req)
+	if err != nil {
+		return err
+	}
+
+	// Once anything has been written the storage no longer matches the
+	// unsealed state, so seal even if the restore fails part way. The
+	// response is audited first, as sealing disables the audit backends.
+	written, err := c.restoreSnapshot(r)
+	if auditErr := c.auditSnapshotResponse(auth, req, err); auditErr != nil {
+		err = auditErr
+	}
+	if written {
+		if err := c.sealInternal(); err != nil {
+			c.logger.Printf("[ERR] core: failed to seal after restoring snapshot: %v", err)
+		}
+	}
+	return err
+}
+
+// restoreSnapshot verifies the snapshot read from r and writes it to the
+// physical backend, reporting whether anything was changed
+func (c *Core) restoreSnapshot(r io.Reader) (bool, error) {
+	entries, err := readSnapshot(r)
+	if err != nil {
+		return false, logical.CodedError(400, fmt.Sprintf("invalid snapshot: %v", err))
+	}
+
+	existing, err := collectPhysicalKeys(c.physical, "")
+	if err != nil {
+		return false, fmt.Errorf("failed to list keys: %v", err)
+	}
+	restored := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		restored[entry.Key] = struct{}{}
+	}
+
+	// Stop persisting the request counters, which would otherwise replace
+	// the restored counts with those of the current storage
+	c.discardRequestCounters()
+
+	for _, key := range existing {
+		if _, ok := restored[key]; ok {
+			continue
+		}
+		if err := c.physical.Delete(key); err != nil {
+			c.logger.Printf("[ERR] core: failed to delete '%s' while restoring snapshot: %v", key, err)
+			return true, fmt.Errorf("failed to delete '%s': %v", key, err)
+		}
+	}
+	for _, entry := range entries {
+		if err := c.physical.Put(&physical.Entry{Key: entry.Key, Value: entry.Value}); err != nil {
+			c.logger.Printf("[ERR] core: failed to write '%s' while restoring snapshot: %v", entry.Key, err)
+			return true, fmt.Errorf("failed to write '%s': %v", entry.Key, err)
+		}
+	}
+
+	c.logger.Printf("[INFO] core: restored snapshot of %d entries, sealing", len(entries))
+	return true, nil
+}
+
+// checkSnapshotToken verifies that the token of the request has root
+// privileges and audits the request, failing if it cannot be audited.
+// The stateLock must be held.
+func (c *Core) checkSnapshotToken(req *logical.Request) (*logical.Auth, error) {
+	acl, te, err := c.fetchACLandTokenEntry(req)
+	if err == nil {
+		if err := c.tokenStore.UseToken(te); err != nil {
+			c.logger.Printf("[ERR] core: failed to use token: %v", err)
+			return nil, ErrInternalError
+		}
+		if allowed, rootPrivs := acl.AllowOperation(req.Operation, req.Path); !allowed || !rootPrivs {
+			err = logical.ErrPermissionDenied
+		}
+	}
+
+	var auth *logical.Auth
+	if err == nil {
+		auth = &logical.Auth{
+			ClientToken: req.ClientToken,
+			Policies:    te.Policies,
+			Metadata:    te.Meta,
+			DisplayName: te.DisplayName,
+		}
+		req.DisplayName = te.DisplayName
+	}
+
+	// Create an audit trail of the request
+	if auditErr := c.auditBroker.LogRequest(auth, req, err); auditErr != nil {
+		c.logger.Printf("[ERR] core: failed to audit request with path (%s): %v",
+			req.Path, auditErr)
+		return nil, ErrInternalError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// auditSnapshotResponse creates an audit trail of the outcome of taking
+// or restoring a snapshot
+func (c *Core) auditSnapshotResponse(auth *logical.Auth, req *logical.Request, err error) error {
+	if auditErr := c.auditBroker.LogResponse(auth, req, nil, err); auditErr != nil {
+		c.logger.Printf("[ERR] core: failed to audit response (request path: %s): %v",
+			req.Path, auditErr)
+		return ErrInternalError
+	}
+	return nil
+}
+
+// readSnapshot decodes and verifies a snapshot, returning its entries
+func readSnapshot(r io.Reader) ([]*snapshotEntry, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	dec := json.NewDecoder(&snapshotSizeLimiter{r: gz, n: SnapshotMaxSize})
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("failed to decode header: %v", err)
+	}
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported version %d", header.Version)
+	}
+	if header.Entries < 0 {
+		return nil, fmt.Errorf("invalid entry count %d", header.Entries)
+	}
+
+	// The entry count is not trusted to size anything: entries are
+	// appended as they are read, bounded by the size limit
+	sum := sha256.New()
+	var entries []*snapshotEntry
+	for i := 0; i < header.Entries; i++ {
+		entry := new(snapshotEntry)
+		if err := dec.Decode(entry); err != nil {
+			return nil, fmt.Errorf("failed to decode entry %d: %v", i, err)
+		}
+		if entry.Key == "" {
+			// Most likely the trailer, read in place of a missing entry
+			return nil, fmt.Errorf("snapshot holds fewer than %d entries", header.Entries)
+		}
+		if isSnapshotExcluded(entry.Key) {
+			return nil, fmt.Errorf("invalid key '%s'", entry.Key)
+		}
+		hashSnapshotEntry(sum, entry)
+		entries = append(entries, entry)
+	}
+
+	var trailer snapshotTrailer
+	if err := dec.Decode(&trailer); err != nil {
+		return nil, fmt.Errorf("failed to decode trailer: %v", err)
+	}
+	var extra json.RawMessage
+	switch err := dec.Decode(&extra); err {
+	case io.EOF:
+	case nil:
+		return nil, fmt.Errorf("snapshot holds more than %d entries", header.Entries)
+	default:
+		return nil, fmt.Errorf("failed to decode trailer: %v", err)
+	}
+	if trailer.SHA256 != hex.EncodeToString(sum.Sum(nil)) {
+		return nil, fmt.Errorf("checksum mismatch")
+	}
+	return entries, nil
+}
+
+// snapshotSizeLimiter fails reads once more than n bytes have been read
+type snapshotSizeLimiter struct {
+	r io.Reader
+	n int64
+}
+
+func (l *snapshotSizeLimiter) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, fmt.Errorf("snapshot is larger than %d bytes", SnapshotMaxSize)
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// hashSnapshotEntry adds an entry to the checksum of a snapshot
+func hashSnapshotEntry(sum hash.Hash, entry *snapshotEntry) {
+	sum.Write([]byte(entry.Key))
+	sum.Write([]byte{0})
+	sum.Write(entry.Value)
+	sum.Write([]byte{0})
+}
+
+// collectPhysicalKeys lists every key of the physical backend under the
+// prefix, skipping the excluded paths
+func collectPhysicalKeys(b physical.Backend, prefix string) ([]string, error) {
+	keys, err := b.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, key := range keys {
+		key = prefix + key
+		if isSnapshotExcluded(key) {
+			continue
+		}
+		if strings.HasSuffix(key, "/") {
+			sub, err := collectPhysicalKeys(b, key)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, sub...)
+			continue
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
+
+func isSnapshotExcluded(key string) bool {
+	for _, p := range snapshotExcludedPaths {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
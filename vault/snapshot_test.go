@@ -0,0 +1,254 @@
+package vault
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_Snapshot(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+
+	write := func(path, value string) {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data: map[string]interface{}{
+				"foo": value,
+			},
+			ClientToken: root,
+		}
+		if _, err := c.HandleRequest(req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	read := func(path string) *logical.Response {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: root,
+		}
+		resp, err := c.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	write("secret/before", "bar")
+
+	var buf bytes.Buffer
+	if err := c.Snapshot(root, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	write("secret/before", "changed")
+	write("secret/after", "bar")
+
+	if err := c.RestoreSnapshot(root, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sealed, _ := c.Sealed(); !sealed {
+		t.Fatalf("should be sealed after restoring")
+	}
+	if unsealed, err := c.Unseal(key); err != nil || !unsealed {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := read("secret/before")
+	if resp == nil || resp.Data["foo"] != "bar" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp := read("secret/after"); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestCore_Snapshot_Invalid(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	var buf bytes.Buffer
+	if err := c.Snapshot(root, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A truncated snapshot
+	raw := buf.Bytes()
+	if err := c.RestoreSnapshot(root, bytes.NewReader(raw[:len(raw)/2])); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// A snapshot whose checksum does not match
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	plain, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore := func(plain []byte) error {
+		var corrupt bytes.Buffer
+		gzw := gzip.NewWriter(&corrupt)
+		gzw.Write(plain)
+		gzw.Close()
+		return c.RestoreSnapshot(root, &corrupt)
+	}
+	if err := restore(bytes.Replace(plain, []byte(`"sha256":"`), []byte(`"sha256":"00`), 1)); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Snapshots whose header does not match the entries they hold
+	var header snapshotHeader
+	if err := json.NewDecoder(bytes.NewReader(plain)).Decode(&header); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, entries := range []int{-1, header.Entries - 1, header.Entries + 1, 1 << 40} {
+		bad := bytes.Replace(plain,
+			[]byte(fmt.Sprintf(`"entries":%d`, header.Entries)),
+			[]byte(fmt.Sprintf(`"entries":%d`, entries)), 1)
+		if err := restore(bad); err == nil {
+			t.Fatalf("expected error: %d", entries)
+		}
+	}
+
+	// A snapshot larger than the limit
+	old := SnapshotMaxSize
+	SnapshotMaxSize = int64(len(plain) - 1)
+	err = restore(plain)
+	SnapshotMaxSize = old
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Fatalf("err: %v", err)
+	}
+
+	if sealed, _ := c.Sealed(); sealed {
+		t.Fatalf("should not seal on an invalid snapshot")
+	}
+}
+
+func TestCore_Snapshot_PermissionDenied(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "auth/token/create",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"policies": []string{"default"},
+		},
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = c.Snapshot(resp.Auth.ClientToken, &buf)
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestCore_Snapshot_AuditTrail(t *testing.T) {
+	noop := &NoopAudit{}
+	c, key, root := TestCoreUnsealed(t)
+	c.auditBackends["noop"] = func(config *audit.BackendConfig) (audit.Backend, error) {
+		noop = &NoopAudit{
+			Config: config,
+		}
+		return noop, nil
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/audit/noop")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Snapshot(root, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.RestoreSnapshot(root, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Both the request and the response of each are audited
+	for _, reqs := range [][]*logical.Request{noop.Req, noop.RespReq} {
+		if len(reqs) < 2 {
+			t.Fatalf("bad: %#v", reqs)
+		}
+		save, restore := reqs[len(reqs)-2], reqs[len(reqs)-1]
+		if save.Path != snapshotPath || save.Operation != logical.ReadOperation {
+			t.Fatalf("bad: %#v", save)
+		}
+		if restore.Path != snapshotPath || restore.Operation != logical.UpdateOperation {
+			t.Fatalf("bad: %#v", restore)
+		}
+	}
+	if auth := noop.ReqAuth[len(noop.ReqAuth)-1]; auth == nil || auth.ClientToken != root {
+		t.Fatalf("bad: %#v", auth)
+	}
+
+	// Nothing is restored if the request can't be audited
+	if _, err := c.Unseal(TestKeyCopy(key)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	noop.ReqErr = fmt.Errorf("failed")
+	if err := c.RestoreSnapshot(root, bytes.NewReader(buf.Bytes())); err != ErrInternalError {
+		t.Fatalf("err: %v", err)
+	}
+	if sealed, _ := c.Sealed(); sealed {
+		t.Fatalf("should not restore without an audit trail")
+	}
+}
+
+func TestCore_Snapshot_RequestCounters(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "secret/foo",
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.persistRequestCounters(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Snapshot(root, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.HandleRequest(req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// The counters in the snapshot are restored rather than overwritten
+	// by those counted since
+	if err := c.RestoreSnapshot(root, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.Unseal(TestKeyCopy(key)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	months, err := c.RequestCounters()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(months) != 1 || months[0].Total != 1 {
+		t.Fatalf("bad: %#v", months)
+	}
+}
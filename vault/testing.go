@@ -103,6 +103,7 @@ func TestCore(t *testing.T) *Core {
 		LogicalBackends:    logicalBackends,
 		CredentialBackends: noopBackends,
 		DisableMlock:       true,
+		EnableRaw:          true,
 	})
 	if err != nil {
 		t.Fatalf("err: %s", err)
@@ -6,9 +6,11 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
@@ -28,6 +30,10 @@ const (
 	// tokenSubPath is the sub-path used for the token store
 	// view. This is nested under the system view.
 	tokenSubPath = "token/"
+
+	// cubbyholeDestroyParallelism is the number of cubbyholes destroyed
+	// at once when many tokens are revoked together
+	cubbyholeDestroyParallelism = 16
 )
 
 var (
@@ -86,6 +92,7 @@ func NewTokenStore(c *Core, config *logical.BackendConfig) (*TokenStore, error)
 			Root: []string{
 				"revoke-prefix/*",
 				"revoke-orphan/*",
+				"tidy",
 			},
 		},
 
@@ -213,6 +220,17 @@ func NewTokenStore(c *Core, config *logical.BackendConfig) (*TokenStore, error)
 				HelpDescription: strings.TrimSpace(tokenRevokePrefixHelp),
 			},
 
+			&framework.Path{
+				Pattern: "tidy$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: t.handleTidy,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(tokenTidyHelp),
+				HelpDescription: strings.TrimSpace(tokenTidyHelp),
+			},
+
 			&framework.Path{
 				Pattern: "renew-self$",
 
@@ -452,40 +470,49 @@ func (ts *TokenStore) Revoke(id string) error {
 // revokeSalted is used to invalidate a given salted token,
 // any child tokens will be orphaned.
 func (ts *TokenStore) revokeSalted(saltedId string) error {
+	deleted, err := ts.revokeEntrySalted(saltedId)
+	if deleted {
+		// The token can no longer reach its cubbyhole, so destroy it
+		// even if the secrets of the token could not be revoked
+		if cerr := ts.destroyCubbyhole(saltedId); cerr != nil {
+			err = multierror.Append(err, cerr)
+		}
+	}
+	return err
+}
+
+// revokeEntrySalted deletes the given salted token and revokes its
+// secrets, leaving its cubbyhole to the caller. It returns whether the
+// token entry was deleted, even if an error occurred afterwards.
+func (ts *TokenStore) revokeEntrySalted(saltedId string) (bool, error) {
 	// Lookup the token first
 	entry, err := ts.lookupSalted(saltedId)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Nuke the primary key first
 	path := lookupPrefix + saltedId
-	if ts.view.Delete(path); err != nil {
-		return fmt.Errorf("failed to delete entry: %v", err)
+	if err := ts.view.Delete(path); err != nil {
+		return false, fmt.Errorf("failed to delete entry: %v", err)
 	}
 
 	// Clear the secondary index if any
 	if entry != nil && entry.Parent != "" {
 		path := parentPrefix + ts.SaltID(entry.Parent) + "/" + saltedId
-		if ts.view.Delete(path); err != nil {
-			return fmt.Errorf("failed to delete entry: %v", err)
+		if err := ts.view.Delete(path); err != nil {
+			return true, fmt.Errorf("failed to delete entry: %v", err)
 		}
 	}
 
 	// Revoke all secrets under this token
 	if entry != nil {
 		if err := ts.expiration.RevokeByToken(entry.ID); err != nil {
-			return err
+			return true, err
 		}
 	}
 
-	// Destroy the cubby space
-	err = ts.destroyCubbyhole(saltedId)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return true, nil
 }
 
 // RevokeTree is used to invalide a given token and all
@@ -508,8 +535,23 @@ func (ts *TokenStore) RevokeTree(id string) error {
 }
 
 // revokeTreeSalted is used to invalide a given token and all
-// child tokens using a saltedID.
+// child tokens using a saltedID. The cubbyholes of the tokens are
+// destroyed in batches once the tokens are revoked, including when the
+// revocation of the tree fails part way.
 func (ts *TokenStore) revokeTreeSalted(saltedId string) error {
+	var revoked []string
+	err := ts.revokeTreeEntriesSalted(saltedId, &revoked)
+
+	if cerr := ts.destroyCubbyholes(revoked); cerr != nil {
+		err = multierror.Append(err, cerr)
+	}
+	return err
+}
+
+// revokeTreeEntriesSalted revokes the given salted token and all child
+// tokens, children first, adding the salted IDs of the deleted tokens to
+// revoked.
+func (ts *TokenStore) revokeTreeEntriesSalted(saltedId string, revoked *[]string) error {
 	// Scan for child tokens
 	path := parentPrefix + saltedId + "/"
 	children, err := ts.view.List(path)
@@ -521,13 +563,17 @@ func (ts *TokenStore) revokeTreeSalted(saltedId string) error {
 	// we don't have the acutal ID of the child, but we have the salted
 	// value. Turns out, this is good enough!
 	for _, child := range children {
-		if err := ts.revokeTreeSalted(child); err != nil {
+		if err := ts.revokeTreeEntriesSalted(child, revoked); err != nil {
 			return err
 		}
 	}
 
 	// Revoke this entry
-	if err := ts.revokeSalted(saltedId); err != nil {
+	deleted, err := ts.revokeEntrySalted(saltedId)
+	if deleted {
+		*revoked = append(*revoked, saltedId)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to revoke entry: %v", err)
 	}
 	return nil
@@ -906,12 +952,122 @@ func (ts *TokenStore) handleRenew(
 	return resp, nil
 }
 
+// cubbyholeKey returns the key of the cubbyhole of a salted token in the
+// cubbyhole backend
+func (ts *TokenStore) cubbyholeKey(saltedID string) string {
+	return salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash)
+}
+
 func (ts *TokenStore) destroyCubbyhole(saltedID string) error {
 	if ts.cubbyholeBackend == nil {
 		// Should only ever happen in testing
 		return nil
 	}
-	return ts.cubbyholeBackend.revoke(salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash))
+	return ts.cubbyholeBackend.revoke(ts.cubbyholeKey(saltedID))
+}
+
+// destroyCubbyholes destroys the cubbyholes of the given salted tokens,
+// up to cubbyholeDestroyParallelism at a time. Every cubbyhole is
+// destroyed before returning, even if some of them fail.
+func (ts *TokenStore) destroyCubbyholes(saltedIDs []string) error {
+	if ts.cubbyholeBackend == nil {
+		// Should only ever happen in testing
+		return nil
+	}
+
+	keys := make([]string, 0, len(saltedIDs))
+	for _, saltedID := range saltedIDs {
+		keys = append(keys, ts.cubbyholeKey(saltedID))
+	}
+	return ts.destroyCubbyholeKeys(keys)
+}
+
+// destroyCubbyholeKeys destroys the cubbyholes with the given keys in the
+// cubbyhole backend, up to cubbyholeDestroyParallelism at a time
+func (ts *TokenStore) destroyCubbyholeKeys(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	defer metrics.MeasureSince([]string{"token", "destroy-cubbyholes"}, time.Now())
+
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	var result error
+	sem := make(chan struct{}, cubbyholeDestroyParallelism)
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := ts.cubbyholeBackend.revoke(key); err != nil {
+				l.Lock()
+				result = multierror.Append(result, err)
+				l.Unlock()
+			}
+		}(key)
+	}
+	wg.Wait()
+	return result
+}
+
+// tidyCubbyholes destroys the cubbyholes of tokens that no longer exist,
+// such as those left behind when a revocation failed, and returns how
+// many were found
+func (ts *TokenStore) tidyCubbyholes() (int, error) {
+	defer metrics.MeasureSince([]string{"token", "tidy"}, time.Now())
+	if ts.cubbyholeBackend == nil {
+		// Should only ever happen in testing
+		return 0, nil
+	}
+
+	// List the cubbyholes before the tokens, so that a token created in
+	// between cannot have its cubbyhole taken as orphaned
+	cubbyholes, err := ts.cubbyholeBackend.storageView.List("")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cubbyholes: %v", err)
+	}
+	saltedIDs, err := ts.view.List(lookupPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tokens: %v", err)
+	}
+
+	valid := make(map[string]struct{}, len(saltedIDs))
+	for _, saltedID := range saltedIDs {
+		valid[ts.cubbyholeKey(saltedID)] = struct{}{}
+	}
+
+	var orphaned []string
+	for _, cubbyhole := range cubbyholes {
+		key := strings.TrimSuffix(cubbyhole, "/")
+		if _, ok := valid[key]; !ok {
+			orphaned = append(orphaned, key)
+		}
+	}
+
+	metrics.IncrCounter([]string{"token", "tidy", "orphaned-cubbyholes"}, float32(len(orphaned)))
+	if len(orphaned) > 0 {
+		ts.Logger().Printf("[WARN] token: destroying %d orphaned cubbyholes", len(orphaned))
+	}
+	return len(orphaned), ts.destroyCubbyholeKeys(orphaned)
+}
+
+// handleTidy handles the auth/token/tidy path, removing storage left
+// behind by tokens that no longer exist
+func (ts *TokenStore) handleTidy(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	orphaned, err := ts.tidyCubbyholes()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"orphaned_cubbyholes": orphaned,
+		},
+	}
+	return resp, nil
 }
 
 const (
@@ -928,4 +1084,5 @@ as revocation of tokens. The tokens are renewable if associated with a lease.`
 	tokenRevokePrefixHelp = `This endpoint will delete all tokens generated under a prefix with their child tokens.`
 	tokenRenewHelp        = `This endpoint will renew the given token and prevent expiration.`
 	tokenRenewSelfHelp    = `This endpoint will renew the token used to call it and prevent expiration.`
+	tokenTidyHelp         = `This endpoint will destroy the cubbyholes of tokens that no longer exist.`
 )
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"reflect"
@@ -360,6 +361,32 @@ func TestTokenStore_RevokeTree(t *testing.T) {
 	}
 }
 
+func TestTokenStore_RevokeTree_Cubbyholes(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	// Enough children to need several batches
+	ids := []string{"parent"}
+	testCoreMakeToken(t, c, root, "parent", "", []string{"root"})
+	for i := 0; i < 2*cubbyholeDestroyParallelism+1; i++ {
+		id := fmt.Sprintf("child%d", i)
+		testCoreMakeToken(t, c, "parent", id, "", []string{"default"})
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		testCoreWriteCubbyhole(t, c, id)
+	}
+
+	if n := testCoreCubbyholeCount(t, c); n != len(ids) {
+		t.Fatalf("bad: %d", n)
+	}
+	if err := c.tokenStore.RevokeTree("parent"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := testCoreCubbyholeCount(t, c); n != 0 {
+		t.Fatalf("bad: %d", n)
+	}
+}
+
 func TestTokenStore_RevokeSelf(t *testing.T) {
 	_, ts, _ := mockTokenStore(t)
 
@@ -1132,3 +1159,68 @@ func testCoreMakeToken(t *testing.T, c *Core, root, client, ttl string, policy [
 		t.Fatalf("bad: %#v", resp)
 	}
 }
+
+func TestTokenStore_HandleRequest_Tidy(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	testCoreMakeToken(t, c, root, "live", "", []string{"default"})
+	testCoreMakeToken(t, c, root, "lost", "", []string{"default"})
+	testCoreWriteCubbyhole(t, c, "live")
+	testCoreWriteCubbyhole(t, c, "lost")
+
+	// Lose a token entry without destroying its cubbyhole
+	if err := c.tokenStore.view.Delete(lookupPrefix + c.tokenStore.SaltID("lost")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/tidy")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp.Data["orphaned_cubbyholes"] != 1 {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if n := testCoreCubbyholeCount(t, c); n != 1 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// The cubbyhole of the live token is kept
+	req = logical.TestRequest(t, logical.ReadOperation, "cubbyhole/foo")
+	req.ClientToken = "live"
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["value"] != "live" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Nothing is left to tidy
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/tidy")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp.Data["orphaned_cubbyholes"] != 0 {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func testCoreWriteCubbyhole(t *testing.T, c *Core, token string) {
+	req := logical.TestRequest(t, logical.UpdateOperation, "cubbyhole/foo")
+	req.ClientToken = token
+	req.Data["value"] = token
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func testCoreCubbyholeCount(t *testing.T, c *Core) int {
+	keys, err := c.tokenStore.cubbyholeBackend.storageView.List("")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return len(keys)
+}
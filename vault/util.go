@@ -3,6 +3,7 @@ package vault
 import (
 	"crypto/rand"
 	"fmt"
+	"strings"
 )
 
 // memzero is used to zero out a byte buffer. This specific format is optimized
@@ -43,3 +44,14 @@ func strListSubset(super, sub []string) bool {
 	}
 	return true
 }
+
+// splitCommaList splits a comma-separated list, dropping empty items
+func splitCommaList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}